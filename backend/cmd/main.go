@@ -11,11 +11,25 @@ import (
 	"backend/internal/app"
 	"backend/internal/redisdb"
 	"backend/internal/routes"
+	"backend/utils"
 )
 
 func main() {
+	cfg, err := app.LoadConfig("configs/config.yaml")
+	if err != nil {
+		fmt.Println(fmt.Errorf("File Open* %w", err))
+		return
+	}
+
+	keys, err := app.LoadKeySet(cfg)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	utils.SetKeySet(keys)
+
 	// 1. Connect to SQLite with GORM
-	db, err := app.InitializeDBAll()
+	db, err := app.InitializeDBAll(cfg)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -30,7 +44,7 @@ func main() {
 		return
 	}
 
-	r := routes.SetupRouter(db, rds)
+	r := routes.SetupRouter(db, rds, cfg)
 
 	// 6. Start the server
 	log.Println("?? Server running on http://localhost:8080")
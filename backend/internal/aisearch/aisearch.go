@@ -0,0 +1,143 @@
+// Package aisearch is a client for the document index, search and summary API of
+// python_ai_service.
+package aisearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Document is a piece of text in the index. Metadata is stored with it and can be used to
+// filter searches.
+type Document struct {
+	ID       string         `json:"id"`
+	Text     string         `json:"text"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// Result is a document matching a search, best first.
+type Result struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Text     string         `json:"text"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+type searchRequest struct {
+	Query  string         `json:"query"`
+	K      int            `json:"k"`
+	Filter map[string]any `json:"filter,omitempty"`
+}
+
+type summarizeRequest struct {
+	Documents    []Document `json:"documents"`
+	MaxSentences int        `json:"max_sentences"`
+}
+
+// Summary is an extractive summary of some documents. Sources are the ids of the documents
+// its sentences were taken from, in the order the documents were given.
+type Summary struct {
+	Summary string   `json:"summary"`
+	Sources []string `json:"sources"`
+}
+
+// Client talks to one AI service.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a client for the service at baseURL. Requests time out after timeout, or
+// 10 seconds when it is 0.
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Upsert adds doc to the index, replacing any document with the same id.
+func (c *Client) Upsert(doc Document) error {
+	return c.do(http.MethodPost, "/documents", doc, nil)
+}
+
+// Delete removes a document. Removing a document that is not indexed is not an error.
+func (c *Client) Delete(id string) error {
+	err := c.do(http.MethodDelete, "/documents/"+url.PathEscape(id), nil, nil)
+	var se *StatusError
+	if errors.As(err, &se) && se.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Search returns the k documents that best match query among those whose metadata has every
+// key and value in filter.
+func (c *Client) Search(query string, k int, filter map[string]any) ([]Result, error) {
+	var results []Result
+	if err := c.do(http.MethodPost, "/search", searchRequest{Query: query, K: k, Filter: filter}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Summarize summarizes docs in at most maxSentences sentences. The documents are not indexed.
+func (c *Client) Summarize(docs []Document, maxSentences int) (*Summary, error) {
+	var summary Summary
+	if err := c.do(http.MethodPost, "/summarize", summarizeRequest{Documents: docs, MaxSentences: maxSentences}, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// StatusError is returned when the service answers with a non-2xx status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ai service returned %d: %s", e.Code, e.Body)
+}
+
+func (c *Client) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
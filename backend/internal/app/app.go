@@ -5,14 +5,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func InitializeDBAll() (*gorm.DB, error) {
-	var err error
-
-	cfg, err := LoadConfig("configs/config.yaml")
-	// DB
-	if err != nil {
-		return nil, fmt.Errorf("File Open* %w", err)
-	}
+func InitializeDBAll(cfg *Config) (*gorm.DB, error) {
 	db, err := ConnectDB(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("DB Connection: %w", err)
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+
+	"backend/internal/blob"
+)
+
+// NewObjectStore builds the attachment store selected in cfg.
+func NewObjectStore(cfg *Config) (blob.ObjectStore, error) {
+	a := cfg.Attachments
+	switch a.Store {
+	case "", "local":
+		dir := a.Dir
+		if dir == "" {
+			dir = "data/attachments"
+		}
+		return blob.NewLocalStore(dir)
+	case "s3":
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  a.S3.Endpoint,
+			Region:    a.S3.Region,
+			Bucket:    a.S3.Bucket,
+			AccessKey: a.S3.AccessKey,
+			SecretKey: a.S3.SecretKey,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", a.Store)
+	}
+}
+
+// AttachmentSigningSecret returns the secret that signs attachment links. Only development may
+// leave it empty, in which case the attachment service makes up a random one.
+func AttachmentSigningSecret(cfg *Config) ([]byte, error) {
+	if cfg.Attachments.SigningSecret == "" && !cfg.Development() {
+		return nil, fmt.Errorf("attachments.signing_secret is required unless app.env is development")
+	}
+	return []byte(cfg.Attachments.SigningSecret), nil
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
+	"time"
 )
 
 type Config struct {
 	App struct {
 		Port int `yaml:"port"`
+		// Env is "development" to allow conveniences that are unsafe in production, such as
+		// generated signing keys. Anything else, including empty, is treated as production.
+		Env string `yaml:"env"`
 	} `yaml:"app"`
 	FrontEnd struct {
 		Port int `yaml:"port"`
@@ -17,6 +21,104 @@ type Config struct {
 		Dialect string `yaml:"dialect"`
 		DSN     string `yaml:"dsn"`
 	} `yaml:"database"`
+	JWT struct {
+		// Keys lists the signing keys in rotation; the newest activated key signs new tokens.
+		// Without keys an ephemeral Ed25519 key is generated on startup, in development only.
+		Keys []struct {
+			ID             string    `yaml:"kid"`
+			Alg            string    `yaml:"alg"` // RS256 or EdDSA
+			PrivateKeyFile string    `yaml:"private_key_file"`
+			ActivateAt     time.Time `yaml:"activate_at"`
+			RetireAt       time.Time `yaml:"retire_at"`
+		} `yaml:"keys"`
+	} `yaml:"jwt"`
+	Auth struct {
+		// SingleSession logs users out of their other devices when they log in.
+		SingleSession bool `yaml:"single_session"`
+		// Admins are the usernames granted the admin flag on startup.
+		Admins  []string `yaml:"admins"`
+		Lockout struct {
+			UserThreshold int           `yaml:"user_threshold"`
+			IPThreshold   int           `yaml:"ip_threshold"`
+			BaseLockout   time.Duration `yaml:"base_lockout"`
+			MaxLockout    time.Duration `yaml:"max_lockout"`
+		} `yaml:"lockout"`
+	} `yaml:"auth"`
+	OIDC struct {
+		// Enabled turns on single sign-on under /api/auth/oidc.
+		Enabled      bool     `yaml:"enabled"`
+		Issuer       string   `yaml:"issuer"`
+		ClientID     string   `yaml:"client_id"`
+		ClientSecret string   `yaml:"client_secret"`
+		RedirectURL  string   `yaml:"redirect_url"`
+		Scopes       []string `yaml:"scopes"`
+	} `yaml:"oidc"`
+	Mail struct {
+		// Driver is "smtp" to deliver mail, or "log" to write it to LogFile (stdout when empty).
+		Driver  string `yaml:"driver"`
+		LogFile string `yaml:"log_file"`
+		// BaseURL is the frontend address used in verification and password reset links.
+		BaseURL string `yaml:"base_url"`
+		SMTP    struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+			From     string `yaml:"from"`
+		} `yaml:"smtp"`
+	} `yaml:"mail"`
+
+	Webhooks struct {
+		MaxAttempts  int           `yaml:"max_attempts"`
+		BaseBackoff  time.Duration `yaml:"base_backoff"`
+		MaxBackoff   time.Duration `yaml:"max_backoff"`
+		DisableAfter int           `yaml:"disable_after"`
+		Timeout      time.Duration `yaml:"timeout"`
+		// AllowPrivateNetworks lets webhooks reach loopback and private addresses.
+		AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+	} `yaml:"webhooks"`
+
+	Search struct {
+		// Engine picks the message search backend; only "sqlite" (FTS5) is built in.
+		Engine string `yaml:"engine"`
+	} `yaml:"search"`
+
+	SemanticSearch struct {
+		// Enabled indexes messages in python_ai_service at URL and serves semantic search.
+		Enabled bool          `yaml:"enabled"`
+		URL     string        `yaml:"url"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"semantic_search"`
+
+	CatchUp struct {
+		// Enabled serves "what did I miss" summaries made by python_ai_service at URL.
+		Enabled bool          `yaml:"enabled"`
+		URL     string        `yaml:"url"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"catch_up"`
+
+	Attachments struct {
+		// Store is "local" to keep files under Dir, or "s3" for an S3-compatible bucket.
+		Store string `yaml:"store"`
+		Dir   string `yaml:"dir"`
+		S3    struct {
+			Endpoint  string `yaml:"endpoint"`
+			Region    string `yaml:"region"`
+			Bucket    string `yaml:"bucket"`
+			AccessKey string `yaml:"access_key"`
+			SecretKey string `yaml:"secret_key"`
+		} `yaml:"s3"`
+		MaxSize      int64    `yaml:"max_size"`
+		AllowedTypes []string `yaml:"allowed_types"`
+		// SigningSecret signs upload and download links. Only development may leave it empty.
+		SigningSecret string        `yaml:"signing_secret"`
+		LinkTTL       time.Duration `yaml:"link_ttl"`
+	} `yaml:"attachments"`
+}
+
+// Development reports whether the backend runs in a development environment.
+func (c *Config) Development() bool {
+	return c.App.Env == "development"
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -2,20 +2,112 @@ package app
 
 import (
 	"backend/internal/model"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
 func InitDB(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&model.User{},
 		&model.ChatRoom{},
 		&model.Message{},
 		&model.UserSession{},
 		&model.UserChatRoom{},
+		&model.MessageRevision{},
+		&model.MessageReaction{},
+		&model.RoomBan{},
+		&model.RoomInvite{},
+		&model.BlockedJWT{},
+		&model.AccountToken{},
+		&model.UserIdentity{},
+		&model.APIToken{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.IncomingWebhook{},
+		&model.BotCommand{},
+		&model.Mention{},
+		&model.Attachment{},
+		&model.PinnedMessage{},
 	)
+	if err != nil {
+		return err
+	}
+	if err := backfillDirectKeys(db); err != nil {
+		return err
+	}
+	return backfillRoomOwners(db)
+}
+
+// backfillDirectKeys keys direct rooms created before ChatRoom.DirectKey existed by their two
+// members. Group rooms that took a name starting with "dm:" before the prefix was reserved are
+// renamed, so the direct rooms those names belong to can still be created.
+func backfillDirectKeys(db *gorm.DB) error {
+	var squatted []model.ChatRoom
+	if err := db.Where("kind <> ? AND name LIKE ?", model.ChatRoomDirect, "dm:%").Find(&squatted).Error; err != nil {
+		return err
+	}
+	for _, room := range squatted {
+		name := fmt.Sprintf("room-%d-%s", room.ID, strings.TrimPrefix(room.Name, "dm:"))
+		if err := db.Model(&room).Update("name", name).Error; err != nil {
+			return err
+		}
+		log.Printf("Room %d used the reserved name %q; renamed it to %q.", room.ID, room.Name, name)
+	}
+
+	var direct []model.ChatRoom
+	if err := db.Where("kind = ? AND direct_key IS NULL", model.ChatRoomDirect).Find(&direct).Error; err != nil {
+		return err
+	}
+	for _, room := range direct {
+		var userIDs []uint
+		err := db.Model(&model.UserChatRoom{}).Where("chat_room_id = ?", room.ID).Order("user_id").Pluck("user_id", &userIDs).Error
+		if err != nil {
+			return err
+		}
+		if len(userIDs) != 2 {
+			continue
+		}
+		key := fmt.Sprintf("%d:%d", userIDs[0], userIDs[1])
+		if err := db.Model(&room).Update("direct_key", key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillRoomOwners hands rooms created before membership roles existed to
+// their earliest member, so every group room keeps someone able to manage it.
+// Direct rooms have no owner by design.
+func backfillRoomOwners(db *gorm.DB) error {
+	var roomIDs []uint
+	err := db.Model(&model.ChatRoom{}).
+		Where("kind <> ?", model.ChatRoomDirect).
+		Where("NOT EXISTS (SELECT 1 FROM user_chat_rooms WHERE user_chat_rooms.chat_room_id = chat_rooms.id AND user_chat_rooms.role = ?)", model.RoleOwner).
+		Pluck("id", &roomIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, roomID := range roomIDs {
+		var first model.UserChatRoom
+		err := db.Where("chat_room_id = ?", roomID).Order("joined_at, id").First(&first).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&first).Update("role", model.RoleOwner).Error; err != nil {
+			return err
+		}
+		log.Printf("Room %d had no owner; promoted user %d.", roomID, first.UserID)
+	}
+	return nil
 }
 
 func ConnectDB(cfg *Config) (*gorm.DB, error) {
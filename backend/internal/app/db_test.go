@@ -0,0 +1,87 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestInitDB_BackfillsRoomOwners(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, InitDB(db))
+
+	now := time.Now()
+	rooms := []model.ChatRoom{
+		{ID: 1, Name: "legacy"},
+		{ID: 2, Name: "owned"},
+		{ID: 3, Name: "dm", Kind: model.ChatRoomDirect},
+		{ID: 4, Name: "empty"},
+	}
+	for i := range rooms {
+		require.NoError(t, db.Create(&rooms[i]).Error)
+	}
+	members := []model.UserChatRoom{
+		{UserID: 7, ChatRoomID: 1, JoinedAt: now},
+		{UserID: 5, ChatRoomID: 1, JoinedAt: now.Add(-time.Hour)},
+		{UserID: 5, ChatRoomID: 2, JoinedAt: now.Add(-time.Hour)},
+		{UserID: 7, ChatRoomID: 2, JoinedAt: now, Role: model.RoleOwner},
+		{UserID: 5, ChatRoomID: 3, JoinedAt: now},
+		{UserID: 7, ChatRoomID: 3, JoinedAt: now},
+	}
+	for i := range members {
+		require.NoError(t, db.Create(&members[i]).Error)
+	}
+
+	// running the migration again must be idempotent
+	require.NoError(t, InitDB(db))
+	require.NoError(t, InitDB(db))
+
+	roleOf := func(userID, roomID uint) string {
+		var m model.UserChatRoom
+		require.NoError(t, db.Where("user_id = ? AND chat_room_id = ?", userID, roomID).First(&m).Error)
+		return m.Role
+	}
+	require.Equal(t, model.RoleOwner, roleOf(5, 1))
+	require.Equal(t, model.RoleMember, roleOf(7, 1))
+	require.Equal(t, model.RoleMember, roleOf(5, 2))
+	require.Equal(t, model.RoleOwner, roleOf(7, 2))
+	require.Equal(t, model.RoleMember, roleOf(5, 3))
+	require.Equal(t, model.RoleMember, roleOf(7, 3))
+}
+
+func TestInitDB_BackfillsDirectKeys(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, InitDB(db))
+
+	rooms := []model.ChatRoom{
+		{ID: 1, Name: "dm:5:7", Kind: model.ChatRoomDirect},
+		{ID: 2, Name: "dm:5:9", Kind: model.ChatRoomPublic},
+	}
+	for i := range rooms {
+		require.NoError(t, db.Create(&rooms[i]).Error)
+	}
+	for _, m := range []model.UserChatRoom{
+		{UserID: 7, ChatRoomID: 1},
+		{UserID: 5, ChatRoomID: 1},
+		{UserID: 9, ChatRoomID: 2, Role: model.RoleOwner},
+	} {
+		require.NoError(t, db.Create(&m).Error)
+	}
+
+	require.NoError(t, InitDB(db))
+
+	var dm, squatted model.ChatRoom
+	require.NoError(t, db.First(&dm, 1).Error)
+	require.NotNil(t, dm.DirectKey)
+	require.Equal(t, "5:7", *dm.DirectKey)
+	require.NoError(t, db.First(&squatted, 2).Error)
+	require.Nil(t, squatted.DirectKey)
+	require.Equal(t, "room-2-5:9", squatted.Name)
+}
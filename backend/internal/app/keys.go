@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"backend/utils"
+)
+
+// LoadKeySet reads the JWT signing keys listed in cfg. Only development may run without any.
+func LoadKeySet(cfg *Config) (*utils.KeySet, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		// tokens signed with an ephemeral key stop validating on restart and on other backends
+		if !cfg.Development() {
+			return nil, fmt.Errorf("no JWT signing keys configured: jwt.keys is required unless app.env is development")
+		}
+		log.Println("no JWT signing keys configured, using an ephemeral key")
+		return utils.NewEphemeralKeySet()
+	}
+
+	keys := make([]utils.SigningKey, 0, len(cfg.JWT.Keys))
+	for _, k := range cfg.JWT.Keys {
+		pemBytes, err := os.ReadFile(k.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read signing key %q: %w", k.ID, err)
+		}
+		key, err := utils.ParseSigningKey(k.ID, k.Alg, pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.ActivateAt = k.ActivateAt
+		key.RetireAt = k.RetireAt
+		keys = append(keys, *key)
+	}
+	return utils.NewKeySet(keys...)
+}
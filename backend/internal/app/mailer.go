@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"backend/internal/mailer"
+)
+
+// NewMailer builds the mailer selected in cfg.
+func NewMailer(cfg *Config) (mailer.Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		smtp := cfg.Mail.SMTP
+		if smtp.Host == "" || smtp.From == "" {
+			return nil, fmt.Errorf("mail.smtp needs a host and a from address")
+		}
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			From:     smtp.From,
+		}), nil
+	case "", "log":
+		if cfg.Mail.LogFile == "" {
+			return mailer.NewLogMailer(os.Stdout), nil
+		}
+		f, err := os.OpenFile(cfg.Mail.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open mail log: %w", err)
+		}
+		return mailer.NewLogMailer(f), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
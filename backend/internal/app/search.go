@@ -0,0 +1,19 @@
+package app
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"backend/internal/search"
+)
+
+// NewSearchEngine builds the message search engine selected in cfg.
+func NewSearchEngine(cfg *Config, db *gorm.DB) (search.Engine, error) {
+	switch cfg.Search.Engine {
+	case "", "sqlite":
+		return search.NewSQLiteEngine(db)
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", cfg.Search.Engine)
+	}
+}
@@ -0,0 +1,38 @@
+// Package blob stores uploaded files. Keys are slash separated paths such as
+// "attachments/12/original".
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectStore keeps objects by key.
+type ObjectStore interface {
+	// Put stores size bytes read from r under key, replacing any object already there.
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object at key. It returns ErrNotFound when there is none.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object at key. Deleting a missing object is not an error.
+	Delete(key string) error
+}
+
+// validateKey rejects keys that could escape the store, such as "../x" or "/etc/passwd".
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
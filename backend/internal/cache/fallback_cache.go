@@ -0,0 +1,38 @@
+package cache
+
+import "time"
+
+// FallbackCache writes to a primary cache and falls back to a secondary one while the
+// primary fails, e.g. an in-memory cache while Redis is unreachable.
+type FallbackCache[T any] struct {
+	primary   Cache[T]
+	secondary Cache[T]
+}
+
+func NewFallbackCache[T any](primary, secondary Cache[T]) *FallbackCache[T] {
+	return &FallbackCache[T]{primary: primary, secondary: secondary}
+}
+
+func (f *FallbackCache[T]) Set(key string, value T, ttl time.Duration) error {
+	if err := f.primary.Set(key, value, ttl); err != nil {
+		return f.secondary.Set(key, value, ttl)
+	}
+	// drop what was stored during an outage so it cannot shadow newer values later
+	_ = f.secondary.Delete(key)
+	return nil
+}
+
+func (f *FallbackCache[T]) Get(key string) (T, bool) {
+	if v, ok := f.primary.Get(key); ok {
+		return v, true
+	}
+	return f.secondary.Get(key)
+}
+
+func (f *FallbackCache[T]) Delete(key string) error {
+	err := f.primary.Delete(key)
+	if serr := f.secondary.Delete(key); err == nil {
+		err = serr
+	}
+	return err
+}
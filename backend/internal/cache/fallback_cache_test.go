@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingCache[T any] struct{}
+
+func (failingCache[T]) Set(string, T, time.Duration) error { return errors.New("down") }
+func (failingCache[T]) Get(string) (T, bool) {
+	var zero T
+	return zero, false
+}
+func (failingCache[T]) Delete(string) error { return errors.New("down") }
+
+func TestFallbackCache_UsesSecondaryWhilePrimaryFails(t *testing.T) {
+	secondary := NewTypedCache[int](time.Minute, time.Minute)
+	c := NewFallbackCache[int](failingCache[int]{}, secondary)
+
+	require.NoError(t, c.Set("k", 3, time.Minute))
+	got, ok := c.Get("k")
+	require.True(t, ok)
+	require.Equal(t, 3, got)
+
+	require.Error(t, c.Delete("k"))
+	_, ok = secondary.Get("k")
+	require.False(t, ok)
+}
+
+func TestFallbackCache_PrefersPrimary(t *testing.T) {
+	primary := NewTypedCache[int](time.Minute, time.Minute)
+	secondary := NewTypedCache[int](time.Minute, time.Minute)
+	require.NoError(t, secondary.Set("k", 1, time.Minute))
+	c := NewFallbackCache[int](primary, secondary)
+
+	require.NoError(t, c.Set("k", 2, time.Minute))
+	got, ok := c.Get("k")
+	require.True(t, ok)
+	require.Equal(t, 2, got)
+	_, ok = secondary.Get("k")
+	require.False(t, ok)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type AccountController struct {
+	Service   service.AccountService
+	Publisher service.EventPublisher
+}
+
+func NewAccountController(service service.AccountService) *AccountController {
+	return &AccountController{Service: service}
+}
+
+// POST /auth/verify-email/request
+func (c *AccountController) RequestEmailVerification(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.Service.SendEmailVerification(userID); err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// POST /auth/verify-email/confirm
+func (c *AccountController) ConfirmEmailVerification(ctx *gin.Context) {
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := c.Service.VerifyEmail(body.Token); err != nil {
+		ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// POST /auth/password-reset/request
+func (c *AccountController) RequestPasswordReset(ctx *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	// Respond the same way whether or not the address is registered.
+	if err := c.Service.RequestPasswordReset(body.Email); err != nil {
+		log.Println("failed to send password reset email:", err)
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "if the address is registered, a reset link is on its way"})
+}
+
+// POST /auth/password-reset/confirm
+func (c *AccountController) ConfirmPasswordReset(ctx *gin.Context) {
+	var body struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	userID, err := c.Service.ResetPassword(body.Token, body.Password)
+	if err != nil {
+		ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	publishSessionRevoked(c.Publisher, userID, "")
+	ctx.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+func accountErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidAccountToken) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func jsonContext(t *testing.T, method, path string, body any) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	jsonBody, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	return ctx, w
+}
+
+func TestAccountController_RequestPasswordReset_HidesErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountService)
+	controller := NewAccountController(mockService)
+
+	mockService.
+		On("RequestPasswordReset", "bob@test.com").
+		Return(errors.New("smtp down")).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/password-reset/request", map[string]string{"email": "bob@test.com"})
+	controller.RequestPasswordReset(ctx)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	mockService.AssertExpectations(t)
+
+	ctx, w = jsonContext(t, http.MethodPost, "/password-reset/request", map[string]string{"email": "not-an-email"})
+	controller.RequestPasswordReset(ctx)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAccountController_ConfirmPasswordReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewAccountController(mockService)
+	controller.Publisher = mockPublisher
+
+	mockService.
+		On("ResetPassword", "good", "new-password").
+		Return(uint(3), nil).
+		Once()
+	mockService.
+		On("ResetPassword", "bad", "new-password").
+		Return(uint(0), service.ErrInvalidAccountToken).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "session_revoked" && e.UserId == 3 && len(e.Content) == 0
+		})).
+		Return(nil).
+		Once()
+
+	for token, want := range map[string]int{"good": http.StatusOK, "bad": http.StatusBadRequest} {
+		ctx, w := jsonContext(t, http.MethodPost, "/password-reset/confirm", map[string]string{
+			"token":    token,
+			"password": "new-password",
+		})
+		controller.ConfirmPasswordReset(ctx)
+		require.Equal(t, want, w.Code)
+	}
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestAccountController_RequestEmailVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountService)
+	controller := NewAccountController(mockService)
+
+	mockService.
+		On("SendEmailVerification", uint(4)).
+		Return(service.ErrEmailAlreadyVerified).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/verify-email/request", nil)
+	ctx.Set("user_id", uint(4))
+	controller.RequestEmailVerification(ctx)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+type MockAccountService struct {
+	mock.Mock
+}
+
+func (m *MockAccountService) SendEmailVerification(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) RequestPasswordReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ResetPassword(token, newPassword string) (uint, error) {
+	args := m.Called(token, newPassword)
+	return args.Get(0).(uint), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type AdminController struct {
+	Guard *service.LoginGuard
+}
+
+func NewAdminController(guard *service.LoginGuard) *AdminController {
+	return &AdminController{Guard: guard}
+}
+
+// POST /admin/users/:username/unlock
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	username := ctx.Param("username")
+	if err := c.Guard.Unlock(username); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("admin %s unlocked logins for %s", ctx.GetString("uid"), username)
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked", "username": username})
+}
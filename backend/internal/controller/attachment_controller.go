@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type AttachmentController struct {
+	Service service.AttachmentService
+}
+
+func NewAttachmentController(service service.AttachmentService) *AttachmentController {
+	return &AttachmentController{Service: service}
+}
+
+// POST /attachments
+func (c *AttachmentController) CreateUpload(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	var input struct {
+		ChatRoomID  uint   `json:"chat_room_id" binding:"required"`
+		Filename    string `json:"filename" binding:"required"`
+		ContentType string `json:"content_type" binding:"required"`
+		Size        int64  `json:"size" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := c.Service.CreateUpload(userID, input.ChatRoomID, input.Filename, input.ContentType, input.Size)
+	if err != nil {
+		respondAttachmentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticket)
+}
+
+// PUT /uploads/:token
+func (c *AttachmentController) Upload(ctx *gin.Context) {
+	attachment, err := c.Service.Upload(ctx.Param("token"), ctx.GetHeader("Content-Type"), ctx.Request.Body)
+	if err != nil {
+		respondAttachmentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, attachment)
+}
+
+// GET /attachments/:id
+func (c *AttachmentController) GetAttachment(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return
+	}
+
+	links, err := c.Service.GetAttachment(userID, uint(id))
+	if err != nil {
+		respondAttachmentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, links)
+}
+
+// GET /files/:token
+func (c *AttachmentController) Download(ctx *gin.Context) {
+	attachment, file, contentType, err := c.Service.OpenFile(ctx.Param("token"))
+	if err != nil {
+		respondAttachmentError(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	// only images are shown inline; anything else is always downloaded
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	ctx.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", contentType)
+	if _, err := io.Copy(ctx.Writer, file); err != nil {
+		log.Println("Error sending attachment:", err)
+	}
+}
+
+func respondAttachmentError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAttachment):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrInvalidFileToken):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
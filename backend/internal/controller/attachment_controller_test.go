@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestAttachmentController_CreateUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAttachmentService)
+	controller := NewAttachmentController(mockService)
+
+	mockService.On("CreateUpload", uint(7), uint(3), "run.sh", "application/x-sh", int64(10)).
+		Return(nil, service.ErrInvalidAttachment).Once()
+	ctx, w := jsonContext(t, http.MethodPost, "/attachments", gin.H{"chat_room_id": 3, "filename": "run.sh", "content_type": "application/x-sh", "size": 10})
+	ctx.Set("user_id", uint(7))
+	controller.CreateUpload(ctx)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.On("CreateUpload", uint(7), uint(3), "cat.png", "image/png", int64(10)).
+		Return(&service.UploadTicket{Attachment: &model.Attachment{ID: 5}, UploadURL: "/api/uploads/tok", Method: http.MethodPut}, nil).Once()
+	ctx, w = jsonContext(t, http.MethodPost, "/attachments", gin.H{"chat_room_id": 3, "filename": "cat.png", "content_type": "image/png", "size": 10})
+	ctx.Set("user_id", uint(7))
+	controller.CreateUpload(ctx)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Contains(t, w.Body.String(), `"upload_url":"/api/uploads/tok"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttachmentController_Download(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAttachmentService)
+	controller := NewAttachmentController(mockService)
+
+	mockService.On("OpenFile", "good").
+		Return(&model.Attachment{Filename: "report \"final\".pdf"}, io.NopCloser(strings.NewReader("%PDF-1.4")), "application/pdf", nil).Once()
+	ctx, w := jsonContext(t, http.MethodGet, "/files/good", nil)
+	ctx.Params = gin.Params{{Key: "token", Value: "good"}}
+	controller.Download(ctx)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "%PDF-1.4", w.Body.String())
+	require.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	require.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;"))
+
+	mockService.On("OpenFile", "expired").Return(nil, nil, "", service.ErrInvalidFileToken).Once()
+	ctx, w = jsonContext(t, http.MethodGet, "/files/expired", nil)
+	ctx.Params = gin.Params{{Key: "token", Value: "expired"}}
+	controller.Download(ctx)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+type MockAttachmentService struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentService) CreateUpload(userID, chatRoomID uint, filename, contentType string, size int64) (*service.UploadTicket, error) {
+	args := m.Called(userID, chatRoomID, filename, contentType, size)
+	ticket, _ := args.Get(0).(*service.UploadTicket)
+	return ticket, args.Error(1)
+}
+
+func (m *MockAttachmentService) Upload(token, contentType string, body io.Reader) (*model.Attachment, error) {
+	args := m.Called(token, contentType)
+	attachment, _ := args.Get(0).(*model.Attachment)
+	return attachment, args.Error(1)
+}
+
+func (m *MockAttachmentService) GetAttachment(userID, id uint) (*service.AttachmentLinks, error) {
+	args := m.Called(userID, id)
+	links, _ := args.Get(0).(*service.AttachmentLinks)
+	return links, args.Error(1)
+}
+
+func (m *MockAttachmentService) OpenFile(token string) (*model.Attachment, io.ReadCloser, string, error) {
+	args := m.Called(token)
+	attachment, _ := args.Get(0).(*model.Attachment)
+	file, _ := args.Get(1).(io.ReadCloser)
+	return attachment, file, args.String(2), args.Error(3)
+}
+
+func (m *MockAttachmentService) PrepareForMessage(userID, chatRoomID uint, ids []uint) ([]model.Attachment, error) {
+	args := m.Called(userID, chatRoomID, ids)
+	attachments, _ := args.Get(0).([]model.Attachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockAttachmentService) LinkToMessage(msg *model.Message, attachments []model.Attachment) error {
+	return m.Called(msg, attachments).Error(0)
+}
+
+func (m *MockAttachmentService) DeleteMessageAttachments(messageID uint) error {
+	return m.Called(messageID).Error(0)
+}
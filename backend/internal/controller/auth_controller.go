@@ -1,19 +1,31 @@
 package controller
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"backend/internal/model"
 	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 	"backend/utils"
 )
 
 type AuthController struct {
-	Service service.AuthService
+	Service   service.AuthService
+	Publisher service.EventPublisher
+	// SingleSession logs a user out everywhere else when they log in.
+	SingleSession bool
+	// Accounts, when set, mails new users a link to verify their email.
+	Accounts service.AccountService
+	// Guard, when set, locks out usernames and IP addresses after repeated failed logins.
+	Guard *service.LoginGuard
 }
 
 func NewAuthController(service service.AuthService) *AuthController {
@@ -38,6 +50,11 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
 		return
 	}
+	if c.Accounts != nil {
+		if err := c.Accounts.SendEmailVerification(user.ID); err != nil {
+			log.Println("failed to send verification email:", err)
+		}
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Registered"})
 }
@@ -53,24 +70,91 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	id, err := c.Service.GetUserIDByUsername(body.Username)
-	if err != nil {
-		log.Println(err)
+	if c.Guard != nil {
+		if err := c.Guard.Check(body.Username, ctx.ClientIP()); err != nil {
+			respondLocked(ctx, err)
+			return
+		}
 	}
 
-	//force logout other account and stop their websocket
-	err = c.Service.ForceLogoutAll(id)
+	tokens, err := c.Service.Login(body.Username, body.Password, service.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	})
 	if err != nil {
-		log.Println(err)
+		if c.Guard != nil && errors.Is(err, service.ErrInvalidCredentials) {
+			if err := c.Guard.Fail(body.Username, ctx.ClientIP()); err != nil {
+				log.Println("failed to record login failure:", err)
+			}
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Guard != nil {
+		if err := c.Guard.Succeed(body.Username); err != nil {
+			log.Println("failed to reset login failures:", err)
+		}
 	}
+	if c.SingleSession {
+		c.logoutOtherSessions(tokens.UserID, tokens.SessionID)
+	}
+
+	ctx.JSON(http.StatusOK, tokenResponse(tokens))
+}
 
-	id, token, jti, sessionID, err := c.Service.Login(body.Username, body.Password)
+// respondLocked answers a login attempt from a locked out username or address.
+func respondLocked(ctx *gin.Context, err error) {
+	var locked *service.LoginLockedError
+	if errors.As(err, &locked) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	}
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+}
+
+// POST /auth/refresh
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	tokens, err := c.Service.Refresh(body.RefreshToken)
 	if err != nil {
+		var reused *service.RefreshReuseError
+		if errors.As(err, &reused) {
+			c.publishSessionRevoked(reused.UserID, reused.SessionID)
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"id": id, "token": token, "jti": jti, "sessionID": sessionID})
+	ctx.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// logoutOtherSessions revokes every session of userID but the one that just logged in and
+// stops their websockets.
+func (c *AuthController) logoutOtherSessions(userID uint, sessionID string) {
+	revoked, err := c.Service.ForceLogoutOthers(userID, sessionID)
+	if err != nil {
+		log.Println(err)
+	}
+	for _, id := range revoked {
+		c.publishSessionRevoked(userID, id)
+	}
+}
+
+func tokenResponse(tokens *service.AuthTokens) gin.H {
+	return gin.H{
+		"id":           tokens.UserID,
+		"token":        tokens.AccessToken,
+		"jti":          tokens.JTI,
+		"expiresAt":    tokens.ExpiresAt,
+		"sessionID":    tokens.SessionID,
+		"refreshToken": tokens.RefreshToken,
+	}
 }
 
 // TODO move that logout function into service
@@ -94,3 +178,81 @@ func (c *AuthController) Logout(ctx *gin.Context) {
 		"message": "logout successful",
 	})
 }
+
+// GET /auth/sessions
+func (c *AuthController) ListSessions(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	sessions, err := c.Service.ListSessions(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	current := ctx.GetString("session_id")
+	data := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, gin.H{
+			"session_id":   session.SessionID,
+			"device_info":  session.DeviceInfo,
+			"ip_address":   session.IPAddress,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.SessionID == current,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// DELETE /auth/sessions/:id
+func (c *AuthController) RevokeSession(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	sessionID := ctx.Param("id")
+	if err := c.Service.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.publishSessionRevoked(userID, sessionID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked", "session_id": sessionID})
+}
+
+func (c *AuthController) publishSessionRevoked(userID uint, sessionID string) {
+	publishSessionRevoked(c.Publisher, userID, sessionID)
+}
+
+// publishSessionRevoked tells the gateway to close the websockets of a session, or of every
+// session of the user when sessionID is empty.
+func publishSessionRevoked(publisher service.EventPublisher, userID uint, sessionID string) {
+	if publisher == nil {
+		return
+	}
+	event := &kafkapb.KafkaEvent{
+		UserId:  uint32(userID),
+		MsgType: "session_revoked",
+		Content: []byte(sessionID),
+	}
+	if err := publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
+
+// GET /.well-known/jwks.json
+func JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, utils.CurrentKeySet().JWKS(time.Now()))
+}
@@ -13,7 +13,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"backend/internal/cache"
 	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 )
 
 func TestAuthController_Register_Success(t *testing.T) {
@@ -70,7 +73,10 @@ func TestAuthController_Login_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockAuthService)
+	mockPublisher := new(MockEventPublisher)
 	controller := NewAuthController(mockService)
+	controller.Publisher = mockPublisher
+	controller.SingleSession = true
 
 	body := map[string]string{
 		"username": "john",
@@ -79,16 +85,20 @@ func TestAuthController_Login_Success(t *testing.T) {
 	jsonBody, _ := json.Marshal(body)
 
 	mockService.
-		On("GetUserIDByUsername", "john").
-		Return(uint(1), nil)
+		On("Login", "john", "secret", mock.Anything).
+		Return(&service.AuthTokens{UserID: 1, AccessToken: "token", JTI: "jti", SessionID: "sessionID", RefreshToken: "sessionID.refresh"}, nil)
 
+	// only the other sessions are ended, and only once the password checked out
 	mockService.
-		On("ForceLogoutAll", uint(1)).
-		Return(nil)
-
-	mockService.
-		On("Login", "john", "secret").
-		Return(uint(1), "token", "jti", "sessionID", nil)
+		On("ForceLogoutOthers", uint(1), "sessionID").
+		Return([]string{"laptop"}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "session_revoked" && e.UserId == 1 && string(e.Content) == "laptop"
+		})).
+		Return(nil).
+		Once()
 
 	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -104,6 +114,75 @@ func TestAuthController_Login_Success(t *testing.T) {
 	require.Contains(t, w.Body.String(), `"sessionID"`)
 
 	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestAuthController_Login_KeepsOtherSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAuthService)
+	controller := NewAuthController(mockService)
+
+	jsonBody, _ := json.Marshal(map[string]string{"username": "john", "password": "secret"})
+
+	mockService.
+		On("Login", "john", "secret", service.ClientInfo{UserAgent: "phone", IPAddress: "10.0.0.1"}).
+		Return(&service.AuthTokens{UserID: 1, AccessToken: "token", SessionID: "sessionID"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "phone")
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+
+	controller.Login(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "ForceLogoutAll", mock.Anything)
+}
+
+func TestAuthController_RevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAuthService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewAuthController(mockService)
+	controller.Publisher = mockPublisher
+
+	mockService.
+		On("RevokeSession", uint(1), "laptop").
+		Return(nil).
+		Once()
+	mockService.
+		On("RevokeSession", uint(1), "gone").
+		Return(service.ErrSessionNotFound).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "session_revoked" && e.UserId == 1 && string(e.Content) == "laptop"
+		})).
+		Return(nil).
+		Once()
+
+	for sessionID, want := range map[string]int{"laptop": http.StatusOK, "gone": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/sessions/"+sessionID, nil)
+		w := httptest.NewRecorder()
+
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Params = gin.Params{{Key: "id", Value: sessionID}}
+		ctx.Request = req
+		ctx.Set("user_id", uint(1))
+
+		controller.RevokeSession(ctx)
+
+		require.Equal(t, want, w.Code)
+	}
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
 }
 
 func TestAuthController_Login_Unauthorized(t *testing.T) {
@@ -111,6 +190,7 @@ func TestAuthController_Login_Unauthorized(t *testing.T) {
 
 	mockService := new(MockAuthService)
 	controller := NewAuthController(mockService)
+	controller.SingleSession = true
 
 	body := map[string]string{
 		"username": "john",
@@ -119,16 +199,8 @@ func TestAuthController_Login_Unauthorized(t *testing.T) {
 	jsonBody, _ := json.Marshal(body)
 
 	mockService.
-		On("GetUserIDByUsername", "john").
-		Return(uint(1), nil)
-
-	mockService.
-		On("ForceLogoutAll", uint(1)).
-		Return(nil)
-
-	mockService.
-		On("Login", "john", "wrong").
-		Return(uint(0), "", "", "", errors.New("invalid credentials"))
+		On("Login", "john", "wrong", mock.Anything).
+		Return(nil, errors.New("invalid credentials"))
 
 	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -140,6 +212,53 @@ func TestAuthController_Login_Unauthorized(t *testing.T) {
 	controller.Login(ctx)
 
 	require.Equal(t, http.StatusUnauthorized, w.Code)
+	// a wrong password must not log the account out of its other devices
+	mockService.AssertNotCalled(t, "ForceLogoutOthers", mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "ForceLogoutAll", mock.Anything)
+}
+
+func TestAuthController_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAuthService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewAuthController(mockService)
+	controller.Publisher = mockPublisher
+
+	mockService.
+		On("Refresh", "sess.old").
+		Return(&service.AuthTokens{UserID: 1, AccessToken: "token2", SessionID: "sess", RefreshToken: "sess.new"}, nil).
+		Once()
+	mockService.
+		On("Refresh", "sess.old").
+		Return(nil, &service.RefreshReuseError{UserID: 1, SessionID: "sess"}).
+		Once()
+	// the revoked session's websockets are closed, like after DELETE /auth/sessions/:id
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "session_revoked" && e.UserId == 1 && string(e.Content) == "sess"
+		})).
+		Return(nil).
+		Once()
+
+	for _, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		jsonBody, _ := json.Marshal(map[string]string{"refreshToken": "sess.old"})
+		req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = req
+
+		controller.Refresh(ctx)
+
+		require.Equal(t, want, w.Code)
+		if want == http.StatusOK {
+			require.Contains(t, w.Body.String(), `"refreshToken":"sess.new"`)
+		}
+	}
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
 }
 
 func TestAuthController_Logout_Success(t *testing.T) {
@@ -193,13 +312,27 @@ func (m *MockAuthService) Register(user *model.User) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Login(username, password string) (uint, string, string, string, error) {
-	args := m.Called(username, password)
-	return args.Get(0).(uint),
-		args.Get(1).(string),
-		args.Get(2).(string),
-		args.Get(3).(string),
-		args.Error(4)
+func (m *MockAuthService) Login(username, password string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(username, password, client)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) ListSessions(userID uint) ([]model.UserSession, error) {
+	args := m.Called(userID)
+	sessions, _ := args.Get(0).([]model.UserSession)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID uint, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (*service.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
 }
 
 func (m *MockAuthService) Logout(tokenStr string) error {
@@ -224,6 +357,12 @@ func (m *MockAuthService) ForceLogoutOne(userID uint, sessionID string) error {
 	return args.Error(0)
 }
 
+func (m *MockAuthService) ForceLogoutOthers(userID uint, keepSessionID string) ([]string, error) {
+	args := m.Called(userID, keepSessionID)
+	sessions, _ := args.Get(0).([]string)
+	return sessions, args.Error(1)
+}
+
 func (m *MockAuthService) GetUserIDByUsername(username string) (uint, error) {
 	args := m.Called(username)
 	return args.Get(0).(uint), args.Error(1)
@@ -242,3 +381,73 @@ func (m *MockAuthService) IsBlocked(jti string) bool {
 func (m *MockAuthService) Clean(jti string) {
 	m.Called(jti)
 }
+
+func TestJWKS_PublishesCurrentKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+	JWKS(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+		} `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotEmpty(t, body.Keys)
+	require.NotEmpty(t, body.Keys[0].Kid)
+}
+
+func TestAuthController_Login_LocksOutAfterFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAuthService)
+	controller := NewAuthController(mockService)
+	controller.Guard = service.NewLoginGuard(
+		cache.NewTypedCache[service.LoginAttempts](time.Hour, time.Minute),
+		service.LoginGuardOptions{UserThreshold: 2, BaseLockout: time.Minute},
+	)
+
+	mockService.
+		On("Login", "john", "wrong", mock.Anything).
+		Return(nil, service.ErrInvalidCredentials).
+		Twice()
+
+	login := func() *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(map[string]string{"username": "john", "password": "wrong"})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = req
+		controller.Login(ctx)
+		return w
+	}
+
+	require.Equal(t, http.StatusUnauthorized, login().Code)
+	require.Equal(t, http.StatusUnauthorized, login().Code)
+
+	w := login()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+
+	admin := NewAdminController(controller.Guard)
+	w = httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/users/john/unlock", nil)
+	ctx.Params = gin.Params{{Key: "username", Value: "john"}}
+	admin.UnlockUser(ctx)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	mockService.
+		On("Login", "john", "wrong", mock.Anything).
+		Return(nil, service.ErrInvalidCredentials).
+		Once()
+	require.Equal(t, http.StatusUnauthorized, login().Code)
+}
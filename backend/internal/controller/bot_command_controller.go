@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+// BotCommandController lets bots manage their slash commands. Every route needs an API token
+// with the commands:write scope.
+type BotCommandController struct {
+	Service service.BotCommandService
+}
+
+func NewBotCommandController(service service.BotCommandService) *BotCommandController {
+	return &BotCommandController{Service: service}
+}
+
+// POST /bot/commands
+func (c *BotCommandController) RegisterCommand(ctx *gin.Context) {
+	token, ok := commandsToken(ctx)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		CallbackURL string `json:"callback_url" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd, secret, err := c.Service.RegisterCommand(token.UserID, body.Name, body.Description, body.CallbackURL)
+	if err != nil {
+		ctx.JSON(botCommandErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// the secret is only ever shown here
+	ctx.JSON(http.StatusCreated, gin.H{"command": cmd, "secret": secret})
+}
+
+// GET /bot/commands
+func (c *BotCommandController) ListCommands(ctx *gin.Context) {
+	token, ok := commandsToken(ctx)
+	if !ok {
+		return
+	}
+
+	cmds, err := c.Service.ListCommands(token.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": cmds})
+}
+
+// DELETE /bot/commands/:name
+func (c *BotCommandController) DeleteCommand(ctx *gin.Context) {
+	token, ok := commandsToken(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.Service.DeleteCommand(token.UserID, ctx.Param("name")); err != nil {
+		ctx.JSON(botCommandErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "command deleted"})
+}
+
+// commandsToken returns the request's API token if it grants commands:write.
+func commandsToken(ctx *gin.Context) (*model.APIToken, bool) {
+	value, _ := ctx.Get("api_token")
+	token, _ := value.(*model.APIToken)
+	if token == nil || !token.Allows(model.ScopeCommandsWrite, 0) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": service.ErrScopeDenied.Error()})
+		return nil, false
+	}
+	return token, true
+}
+
+func botCommandErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidCommand):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrCommandTaken):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrBotCommandNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestBotCommandController_RegisterCommand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockBotCommandService)
+	controller := NewBotCommandController(mockService)
+	body := map[string]any{"name": "deploy", "description": "Ship it", "callback_url": "https://bot.test/deploy"}
+
+	t.Run("needs commands:write", func(t *testing.T) {
+		for _, token := range []*model.APIToken{nil, {UserID: 9, Scopes: model.ScopeMessagesWrite}} {
+			ctx, w := jsonContext(t, http.MethodPost, "/bot/commands", body)
+			if token != nil {
+				ctx.Set("api_token", token)
+			}
+			controller.RegisterCommand(ctx)
+			require.Equal(t, http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("returns the secret once", func(t *testing.T) {
+		mockService.
+			On("RegisterCommand", uint(9), "deploy", "Ship it", "https://bot.test/deploy").
+			Return(&model.BotCommand{ID: 1, BotID: 9, Name: "deploy", Secret: "s3cret"}, "s3cret", nil).
+			Once()
+
+		ctx, w := jsonContext(t, http.MethodPost, "/bot/commands", body)
+		ctx.Set("api_token", &model.APIToken{UserID: 9, Scopes: model.ScopeCommandsWrite})
+		controller.RegisterCommand(ctx)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, 1, strings.Count(w.Body.String(), "s3cret"))
+	})
+
+	t.Run("maps errors", func(t *testing.T) {
+		for err, want := range map[error]int{
+			service.ErrInvalidCommand: http.StatusBadRequest,
+			service.ErrCommandTaken:   http.StatusConflict,
+		} {
+			mockService.On("RegisterCommand", uint(9), "deploy", "Ship it", "https://bot.test/deploy").Return(nil, "", err).Once()
+
+			ctx, w := jsonContext(t, http.MethodPost, "/bot/commands", body)
+			ctx.Set("api_token", &model.APIToken{UserID: 9, Scopes: model.ScopeCommandsWrite})
+			controller.RegisterCommand(ctx)
+
+			require.Equal(t, want, w.Code, err.Error())
+		}
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestBotCommandController_DeleteCommand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockBotCommandService)
+	controller := NewBotCommandController(mockService)
+	mockService.On("DeleteCommand", uint(9), "deploy").Return(service.ErrBotCommandNotFound).Once()
+
+	ctx, w := jsonContext(t, http.MethodDelete, "/bot/commands/deploy", nil)
+	ctx.Params = gin.Params{{Key: "name", Value: "deploy"}}
+	ctx.Set("api_token", &model.APIToken{UserID: 9, Scopes: model.ScopeCommandsWrite})
+	controller.DeleteCommand(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+type MockBotCommandService struct {
+	mock.Mock
+}
+
+func (m *MockBotCommandService) RegisterCommand(botID uint, name, description, callbackURL string) (*model.BotCommand, string, error) {
+	args := m.Called(botID, name, description, callbackURL)
+	cmd, _ := args.Get(0).(*model.BotCommand)
+	return cmd, args.String(1), args.Error(2)
+}
+
+func (m *MockBotCommandService) ListCommands(botID uint) ([]model.BotCommand, error) {
+	args := m.Called(botID)
+	cmds, _ := args.Get(0).([]model.BotCommand)
+	return cmds, args.Error(1)
+}
+
+func (m *MockBotCommandService) DeleteCommand(botID uint, name string) error {
+	args := m.Called(botID, name)
+	return args.Error(0)
+}
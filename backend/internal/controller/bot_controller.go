@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+type BotController struct {
+	Service service.BotService
+}
+
+func NewBotController(service service.BotService) *BotController {
+	return &BotController{Service: service}
+}
+
+// POST /admin/bots
+func (c *BotController) CreateBot(ctx *gin.Context) {
+	var body struct {
+		Username string `json:"username" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	bot, err := c.Service.CreateBot(body.Username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, bot)
+}
+
+// GET /admin/bots
+func (c *BotController) ListBots(ctx *gin.Context) {
+	bots, err := c.Service.ListBots()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": bots})
+}
+
+// POST /admin/bots/:id/tokens
+func (c *BotController) CreateToken(ctx *gin.Context) {
+	botID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid bot id"})
+		return
+	}
+	var body struct {
+		Name      string   `json:"name" binding:"required"`
+		Scopes    []string `json:"scopes" binding:"required"`
+		ExpiresIn int64    `json:"expires_in"` // seconds, 0 for no expiry
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.ExpiresIn < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	token, info, err := c.Service.CreateToken(uint(botID), body.Name, body.Scopes, time.Duration(body.ExpiresIn)*time.Second)
+	if err != nil {
+		ctx.JSON(botErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// the token is only ever shown here
+	ctx.JSON(http.StatusCreated, gin.H{"token": token, "info": info})
+}
+
+// GET /admin/bots/:id/tokens
+func (c *BotController) ListTokens(ctx *gin.Context) {
+	botID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid bot id"})
+		return
+	}
+
+	tokens, err := c.Service.ListTokens(uint(botID))
+	if err != nil {
+		ctx.JSON(botErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// DELETE /admin/tokens/:id
+func (c *BotController) RevokeToken(ctx *gin.Context) {
+	tokenID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+
+	if err := c.Service.RevokeToken(uint(tokenID)); err != nil {
+		ctx.JSON(botErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
+
+func botErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAPITokenNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrNotBot), errors.Is(err, service.ErrInvalidScope):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// authorizeAPIToken lets requests made with a bot API token through only if the token grants
+// scope in roomID. Requests made with a session pass unchecked.
+func authorizeAPIToken(ctx *gin.Context, bots service.BotService, scope string, roomID uint) bool {
+	value, ok := ctx.Get("api_token")
+	if !ok {
+		return true
+	}
+	token, _ := value.(*model.APIToken)
+	if bots == nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": service.ErrScopeDenied.Error()})
+		return false
+	}
+	if err := bots.Authorize(token, scope, roomID); err != nil {
+		if errors.Is(err, service.ErrScopeDenied) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
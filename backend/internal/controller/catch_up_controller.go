@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type CatchUpController struct {
+	Service service.CatchUpService
+}
+
+func NewCatchUpController(service service.CatchUpService) *CatchUpController {
+	return &CatchUpController{Service: service}
+}
+
+// GET /chatrooms/:id/catch-up
+func (c *CatchUpController) CatchUp(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	catchUp, err := c.Service.CatchUp(userID, uint(chatRoomID))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrSummaryUnavailable):
+			log.Println("catch-up summary failed:", err)
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": service.ErrSummaryUnavailable.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, catchUp)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/service"
+)
+
+func TestCatchUpController_CatchUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockCatchUpService)
+	controller := NewCatchUpController(mockService)
+
+	mockService.On("CatchUp", uint(7), uint(3)).
+		Return(&service.CatchUp{ChatRoomID: 3, MessageCount: 2, Summary: "deploy moved", SourceMessageIDs: []uint{11}}, nil).Once()
+
+	ctx, w := jsonContext(t, http.MethodGet, "/chatrooms/3/catch-up", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+	controller.CatchUp(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"chat_room_id":3,"from_message_id":0,"to_message_id":0,"message_count":2,"truncated":false,"summary":"deploy moved","source_message_ids":[11]}`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestCatchUpController_CatchUp_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockCatchUpService)
+	controller := NewCatchUpController(mockService)
+
+	for err, code := range map[error]int{
+		service.ErrForbidden: http.StatusForbidden,
+		fmt.Errorf("%w: dial tcp 10.0.0.5:8010: connection refused", service.ErrSummaryUnavailable): http.StatusBadGateway,
+	} {
+		mockService.On("CatchUp", uint(7), uint(3)).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodGet, "/chatrooms/3/catch-up", nil)
+		ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+		ctx.Set("user_id", uint(7))
+		controller.CatchUp(ctx)
+
+		require.Equal(t, code, w.Code, err.Error())
+		require.NotContains(t, w.Body.String(), "10.0.0.5")
+	}
+}
+
+type MockCatchUpService struct {
+	mock.Mock
+}
+
+func (m *MockCatchUpService) CatchUp(userID, chatRoomID uint) (*service.CatchUp, error) {
+	args := m.Called(userID, chatRoomID)
+	catchUp, _ := args.Get(0).(*service.CatchUp)
+	return catchUp, args.Error(1)
+}
@@ -1,15 +1,21 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
+	"backend/internal/model"
 	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 	"github.com/gin-gonic/gin"
 )
 
 type ChatRoomController struct {
 	chatRoomService service.ChatRoomService
+	Publisher       service.EventPublisher
 }
 
 func NewChatRoomController(chatRoomService service.ChatRoomService) *ChatRoomController {
@@ -20,6 +26,7 @@ func NewChatRoomController(chatRoomService service.ChatRoomService) *ChatRoomCon
 func (c *ChatRoomController) CreateChatRoom(ctx *gin.Context) {
 	var req struct {
 		Name string `json:"name" binding:"required"`
+		Kind string `json:"kind"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -27,7 +34,24 @@ func (c *ChatRoomController) CreateChatRoom(ctx *gin.Context) {
 		return
 	}
 
-	chatRoom, err := c.chatRoomService.CreateChatRoom(req.Name)
+	// The creator becomes the room owner
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	var chatRoom *model.ChatRoom
+	var err error
+	switch req.Kind {
+	case "", model.ChatRoomPublic:
+		chatRoom, err = c.chatRoomService.CreateChatRoom(req.Name, userID)
+	case model.ChatRoomPrivate:
+		chatRoom, err = c.chatRoomService.CreatePrivateChatRoom(req.Name, userID)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "kind must be public or private"})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -38,7 +62,9 @@ func (c *ChatRoomController) CreateChatRoom(ctx *gin.Context) {
 
 // GET /chatrooms
 func (c *ChatRoomController) GetAllChatRooms(ctx *gin.Context) {
-	chatRooms, err := c.chatRoomService.GetAllChatRooms()
+	// Without a user only public rooms are visible
+	userID, _ := currentUserID(ctx)
+	chatRooms, err := c.chatRoomService.GetAllChatRooms(userID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -55,12 +81,70 @@ func (c *ChatRoomController) GetChatRoomByID(ctx *gin.Context) {
 		return
 	}
 
-	chatRoom, err := c.chatRoomService.GetChatRoomByID(uint(id))
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	chatRoom, err := c.chatRoomService.GetVisibleChatRoom(userID, uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrChatRoomNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": chatRoom})
+}
+
+// PATCH /chatrooms/:id
+func (c *ChatRoomController) UpdateChatRoom(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	// fields left out of the body are not changed
+	var req struct {
+		Topic       *string `json:"topic"`
+		Description *string `json:"description"`
+		AvatarURL   *string `json:"avatar_url"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chatRoom, err := c.chatRoomService.UpdateRoom(userID, uint(id), service.RoomUpdate{
+		Topic:       req.Topic,
+		Description: req.Description,
+		AvatarURL:   req.AvatarURL,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChatRoomNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTopicTooLong), errors.Is(err, service.ErrDescriptionTooLong), errors.Is(err, service.ErrInvalidAvatarURL):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	c.publishRoomUpdated(userID, chatRoom)
 	ctx.JSON(http.StatusOK, gin.H{"data": chatRoom})
 }
 
@@ -73,7 +157,17 @@ func (c *ChatRoomController) DeleteChatRoom(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.chatRoomService.DeleteChatRoom(uint(id)); err != nil {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.chatRoomService.DeleteChatRoom(uint(id), userID); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -89,7 +183,8 @@ func (c *ChatRoomController) SearchChatRooms(ctx *gin.Context) {
 		return
 	}
 
-	chatRooms, err := c.chatRoomService.SearchChatRoomsByName(keyword)
+	userID, _ := currentUserID(ctx)
+	chatRooms, err := c.chatRoomService.SearchChatRoomsByName(userID, keyword)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -97,3 +192,62 @@ func (c *ChatRoomController) SearchChatRooms(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": chatRooms})
 }
+
+// POST /dms
+func (c *ChatRoomController) CreateDirectRoom(ctx *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	chatRoom, created, err := c.chatRoomService.GetOrCreateDirectRoom(userID, req.Username)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrDirectWithSelf):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{"data": chatRoom})
+}
+
+// publishRoomUpdated tells the members of chatRoom that its details changed, so open
+// clients can refresh the room header.
+func (c *ChatRoomController) publishRoomUpdated(userID uint, chatRoom *model.ChatRoom) {
+	if c.Publisher == nil {
+		return
+	}
+	content, err := json.Marshal(chatRoom)
+	if err != nil {
+		log.Println("Error encoding room:", err)
+		return
+	}
+	event := &kafkapb.KafkaEvent{
+		UserId:  uint32(userID),
+		RoomId:  uint32(chatRoom.ID),
+		MsgType: service.RoomUpdatedEventType,
+		Content: content,
+	}
+	if err := c.Publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
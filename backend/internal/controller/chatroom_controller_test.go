@@ -13,6 +13,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 )
 
 func TestChatRoomController_CreateChatRoom_Success(t *testing.T) {
@@ -24,7 +26,7 @@ func TestChatRoomController_CreateChatRoom_Success(t *testing.T) {
 	chatRoom := &model.ChatRoom{ID: 1, Name: "general"}
 
 	mockService.
-		On("CreateChatRoom", "general").
+		On("CreateChatRoom", "general", uint(7)).
 		Return(chatRoom, nil).
 		Once()
 
@@ -37,6 +39,7 @@ func TestChatRoomController_CreateChatRoom_Success(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.CreateChatRoom(ctx)
 
@@ -56,6 +59,7 @@ func TestChatRoomController_CreateChatRoom_InvalidInput(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.CreateChatRoom(ctx)
 
@@ -74,7 +78,7 @@ func TestChatRoomController_GetAllChatRooms_Success(t *testing.T) {
 	}
 
 	mockService.
-		On("GetAllChatRooms").
+		On("GetAllChatRooms", uint(0)).
 		Return(rooms, nil).
 		Once()
 
@@ -100,7 +104,7 @@ func TestChatRoomController_GetChatRoomByID_Success(t *testing.T) {
 	room := &model.ChatRoom{ID: 1, Name: "general"}
 
 	mockService.
-		On("GetChatRoomByID", uint(1)).
+		On("GetVisibleChatRoom", uint(3), uint(1)).
 		Return(room, nil).
 		Once()
 
@@ -110,6 +114,7 @@ func TestChatRoomController_GetChatRoomByID_Success(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(3))
 
 	controller.GetChatRoomByID(ctx)
 
@@ -118,6 +123,31 @@ func TestChatRoomController_GetChatRoomByID_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestChatRoomController_GetChatRoomByID_Hidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockChatRoomService)
+	controller := NewChatRoomController(mockService)
+
+	mockService.
+		On("GetVisibleChatRoom", uint(3), uint(1)).
+		Return(nil, service.ErrChatRoomNotFound).
+		Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/chatrooms/1", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(3))
+
+	controller.GetChatRoomByID(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestChatRoomController_GetChatRoomByID_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -142,7 +172,7 @@ func TestChatRoomController_DeleteChatRoom_Success(t *testing.T) {
 	controller := NewChatRoomController(mockService)
 
 	mockService.
-		On("DeleteChatRoom", uint(1)).
+		On("DeleteChatRoom", uint(1), uint(7)).
 		Return(nil).
 		Once()
 
@@ -152,6 +182,7 @@ func TestChatRoomController_DeleteChatRoom_Success(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.DeleteChatRoom(ctx)
 
@@ -171,7 +202,7 @@ func TestChatRoomController_SearchChatRooms_Success(t *testing.T) {
 	}
 
 	mockService.
-		On("SearchChatRoomsByName", "gen").
+		On("SearchChatRoomsByName", uint(0), "gen").
 		Return(rooms, nil).
 		Once()
 
@@ -204,17 +235,194 @@ func TestChatRoomController_SearchChatRooms_MissingQuery(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestChatRoomController_DeleteChatRoom_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockChatRoomService)
+	controller := NewChatRoomController(mockService)
+
+	mockService.
+		On("DeleteChatRoom", uint(1), uint(7)).
+		Return(service.ErrForbidden).
+		Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/chatrooms/1", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.DeleteChatRoom(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestChatRoomController_CreateChatRoom_Private(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockChatRoomService)
+	controller := NewChatRoomController(mockService)
+
+	mockService.
+		On("CreatePrivateChatRoom", "secret", uint(7)).
+		Return(&model.ChatRoom{ID: 1, Name: "secret", Kind: model.ChatRoomPrivate}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms", bytes.NewBufferString(`{"name":"secret","kind":"private"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.CreateChatRoom(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Contains(t, w.Body.String(), `"private"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestChatRoomController_CreateChatRoom_InvalidKind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewChatRoomController(new(MockChatRoomService))
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms", bytes.NewBufferString(`{"name":"secret","kind":"direct"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.CreateChatRoom(ctx)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestChatRoomController_CreateDirectRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		created bool
+		err     error
+		status  int
+	}{
+		{name: "created", created: true, status: http.StatusCreated},
+		{name: "existing", created: false, status: http.StatusOK},
+		{name: "unknown peer", err: service.ErrUserNotFound, status: http.StatusNotFound},
+		{name: "self", err: service.ErrDirectWithSelf, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockChatRoomService)
+			controller := NewChatRoomController(mockService)
+
+			mockService.
+				On("GetOrCreateDirectRoom", uint(7), "bob").
+				Return(&model.ChatRoom{ID: 4, Name: "dm:2:7", Kind: model.ChatRoomDirect}, tt.created, tt.err).
+				Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/dms", bytes.NewBufferString(`{"username":"bob"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = req
+			ctx.Set("user_id", uint(7))
+
+			controller.CreateDirectRoom(ctx)
+
+			require.Equal(t, tt.status, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChatRoomController_UpdateChatRoom_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockChatRoomService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewChatRoomController(mockService)
+	controller.Publisher = mockPublisher
+
+	chatRoom := &model.ChatRoom{ID: 1, Name: "general", Topic: "Releases", Description: "Ship it"}
+	mockService.
+		On("UpdateRoom", uint(7), uint(1), mock.MatchedBy(func(u service.RoomUpdate) bool {
+			return *u.Topic == "Releases" && *u.Description == "Ship it" && u.AvatarURL == nil
+		})).
+		Return(chatRoom, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == service.RoomUpdatedEventType && e.RoomId == 1 && e.UserId == 7 && bytes.Contains(e.Content, []byte(`"Ship it"`))
+		})).
+		Return(nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPatch, "/chatrooms/1", map[string]string{"topic": "Releases", "description": "Ship it"})
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Set("user_id", uint(7))
+
+	controller.UpdateChatRoom(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"Releases"`)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestChatRoomController_UpdateChatRoom_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, status := range map[error]int{
+		service.ErrForbidden:        http.StatusForbidden,
+		service.ErrChatRoomNotFound: http.StatusNotFound,
+		service.ErrInvalidAvatarURL: http.StatusBadRequest,
+	} {
+		mockService := new(MockChatRoomService)
+		controller := NewChatRoomController(mockService)
+		mockService.On("UpdateRoom", uint(7), uint(1), mock.Anything).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodPatch, "/chatrooms/1", map[string]string{"avatar_url": "ftp://x"})
+		ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+		ctx.Set("user_id", uint(7))
+
+		controller.UpdateChatRoom(ctx)
+
+		require.Equal(t, status, w.Code, err.Error())
+		mockService.AssertExpectations(t)
+	}
+}
+
 type MockChatRoomService struct {
 	mock.Mock
 }
 
-func (m *MockChatRoomService) CreateChatRoom(name string) (*model.ChatRoom, error) {
-	args := m.Called(name)
+func (m *MockChatRoomService) CreateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	args := m.Called(name, creatorID)
+	return args.Get(0).(*model.ChatRoom), args.Error(1)
+}
+
+func (m *MockChatRoomService) CreatePrivateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	args := m.Called(name, creatorID)
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) GetAllChatRooms() ([]model.ChatRoom, error) {
-	args := m.Called()
+func (m *MockChatRoomService) GetOrCreateDirectRoom(userID uint, peerUsername string) (*model.ChatRoom, bool, error) {
+	args := m.Called(userID, peerUsername)
+	return args.Get(0).(*model.ChatRoom), args.Bool(1), args.Error(2)
+}
+
+func (m *MockChatRoomService) GetAllChatRooms(userID uint) ([]model.ChatRoom, error) {
+	args := m.Called(userID)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
 
@@ -223,8 +431,14 @@ func (m *MockChatRoomService) GetChatRoomByID(id uint) (*model.ChatRoom, error)
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) DeleteChatRoom(id uint) error {
-	args := m.Called(id)
+func (m *MockChatRoomService) GetVisibleChatRoom(userID, id uint) (*model.ChatRoom, error) {
+	args := m.Called(userID, id)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockChatRoomService) DeleteChatRoom(id, userID uint) error {
+	args := m.Called(id, userID)
 	return args.Error(0)
 }
 
@@ -233,7 +447,19 @@ func (m *MockChatRoomService) GetChatRoomByName(name string) (*model.ChatRoom, e
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) SearchChatRoomsByName(keyword string) ([]model.ChatRoom, error) {
-	args := m.Called(keyword)
+func (m *MockChatRoomService) SearchChatRoomsByName(userID uint, keyword string) ([]model.ChatRoom, error) {
+	args := m.Called(userID, keyword)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
+
+func (m *MockChatRoomService) SetTopic(actorID, id uint, topic string) (*model.ChatRoom, error) {
+	args := m.Called(actorID, id, topic)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockChatRoomService) UpdateRoom(actorID, id uint, update service.RoomUpdate) (*model.ChatRoom, error) {
+	args := m.Called(actorID, id, update)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the ID of the authenticated user set by the jwtauth middleware.
+func currentUserID(ctx *gin.Context) (uint, bool) {
+	userID := ctx.GetUint("user_id")
+	return userID, userID != 0
+}
+
+// currentUsername returns the username of the authenticated user set by the jwtauth middleware.
+func currentUsername(ctx *gin.Context) (string, bool) {
+	username := ctx.GetString("uid")
+	return username, username != ""
+}
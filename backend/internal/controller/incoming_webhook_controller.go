@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+// maxIncomingWebhookBody caps the request body accepted by POST /hooks/:token.
+const maxIncomingWebhookBody = 64 << 10
+
+type IncomingWebhookController struct {
+	Service   service.IncomingWebhookService
+	Publisher service.EventPublisher
+}
+
+func NewIncomingWebhookController(service service.IncomingWebhookService) *IncomingWebhookController {
+	return &IncomingWebhookController{Service: service}
+}
+
+// POST /chatrooms/:id/incoming-webhooks
+func (c *IncomingWebhookController) CreateIncomingWebhook(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	var body struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, token, err := c.Service.CreateIncomingWebhook(userID, uint(chatRoomID), body.Name)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// the token is only ever shown here
+	ctx.JSON(http.StatusCreated, gin.H{"webhook": hook, "token": token, "path": "/api/hooks/" + token})
+}
+
+// GET /chatrooms/:id/incoming-webhooks
+func (c *IncomingWebhookController) ListIncomingWebhooks(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	hooks, err := c.Service.ListIncomingWebhooks(userID, uint(chatRoomID))
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": hooks})
+}
+
+// DELETE /incoming-webhooks/:id
+func (c *IncomingWebhookController) DeleteIncomingWebhook(ctx *gin.Context) {
+	hookID, userID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.Service.DeleteIncomingWebhook(userID, hookID); err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// POST /hooks/:token
+func (c *IncomingWebhookController) Post(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIncomingWebhookBody)
+	// "text" is what most alerting tools send; "content" matches POST /messages
+	var body struct {
+		Text    string `json:"text"`
+		Content string `json:"content"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	content := body.Text
+	if content == "" {
+		content = body.Content
+	}
+
+	msg, err := c.Service.Post(ctx.Param("token"), content)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookToken):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmptyContent), errors.Is(err, service.ErrMessageTooLong):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	publishMessage(c.Publisher, msg)
+	ctx.JSON(http.StatusCreated, gin.H{"id": msg.ID})
+}
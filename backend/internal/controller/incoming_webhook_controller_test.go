@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func TestIncomingWebhookController_Post(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockIncomingWebhookService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewIncomingWebhookController(mockService)
+	controller.Publisher = mockPublisher
+
+	mockService.
+		On("Post", "tok", "disk full").
+		Return(&model.Message{ID: 8, UserID: 5, RoomID: 3, Content: "disk full"}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "message" && e.Id == 8 && e.UserId == 5 && e.RoomId == 3 && string(e.Content) == "disk full"
+		})).
+		Return(nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/hooks/tok", map[string]string{"text": "disk full"})
+	ctx.Params = gin.Params{{Key: "token", Value: "tok"}}
+	controller.Post(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestIncomingWebhookController_Post_UnknownToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockIncomingWebhookService)
+	mockPublisher := new(MockEventPublisher)
+	controller := NewIncomingWebhookController(mockService)
+	controller.Publisher = mockPublisher
+
+	mockService.On("Post", "nope", "hi").Return(nil, service.ErrInvalidWebhookToken).Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/hooks/nope", map[string]string{"content": "hi"})
+	ctx.Params = gin.Params{{Key: "token", Value: "nope"}}
+	controller.Post(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockPublisher.AssertNotCalled(t, "HandleOutgoingMessage", mock.Anything)
+}
+
+type MockIncomingWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockIncomingWebhookService) CreateIncomingWebhook(userID, chatRoomID uint, name string) (*model.IncomingWebhook, string, error) {
+	args := m.Called(userID, chatRoomID, name)
+	hook, _ := args.Get(0).(*model.IncomingWebhook)
+	return hook, args.String(1), args.Error(2)
+}
+
+func (m *MockIncomingWebhookService) ListIncomingWebhooks(userID, chatRoomID uint) ([]model.IncomingWebhook, error) {
+	args := m.Called(userID, chatRoomID)
+	hooks, _ := args.Get(0).([]model.IncomingWebhook)
+	return hooks, args.Error(1)
+}
+
+func (m *MockIncomingWebhookService) DeleteIncomingWebhook(userID, hookID uint) error {
+	return m.Called(userID, hookID).Error(0)
+}
+
+func (m *MockIncomingWebhookService) Post(token, content string) (*model.Message, error) {
+	args := m.Called(token, content)
+	msg, _ := args.Get(0).(*model.Message)
+	return msg, args.Error(1)
+}
@@ -1,18 +1,25 @@
 package controller
 
 import (
+	"backend/internal/model"
 	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MembershipController struct {
 	membershipService service.MembershipService
+	Publisher         service.EventPublisher
 }
 
 func NewMembershipController(m service.MembershipService) *MembershipController {
-	return &MembershipController{m}
+	return &MembershipController{membershipService: m}
 }
 
 // Request body now uses "username"
@@ -28,8 +35,18 @@ func (c *MembershipController) AddUser(ctx *gin.Context) {
 		return
 	}
 
-	err := c.membershipService.AddUserToChatRoom(req.Username, req.ChatRoomID)
+	actorID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	err := c.membershipService.AddUserToChatRoom(actorID, req.Username, req.ChatRoomID)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) || errors.Is(err, service.ErrBanned) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -41,7 +58,18 @@ func (c *MembershipController) AddUser(ctx *gin.Context) {
 	})
 }
 
+// GET /memberships/:username/chatrooms
+// Users can only list their own rooms; other users' private and direct rooms stay hidden.
 func (c *MembershipController) GetUserChatRooms(ctx *gin.Context, username string) {
+	caller, ok := currentUsername(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	if caller != username {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": service.ErrForbidden.Error()})
+		return
+	}
 
 	rooms, err := c.membershipService.GetUserSubscribedChatRooms(username)
 	if err != nil {
@@ -49,6 +77,329 @@ func (c *MembershipController) GetUserChatRooms(ctx *gin.Context, username strin
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": rooms})
+	unread, err := c.membershipService.GetUnreadCounts(username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscribed := make([]model.SubscribedChatRoom, 0, len(rooms))
+	for _, room := range rooms {
+		subscribed = append(subscribed, model.SubscribedChatRoom{ChatRoom: room, UnreadCount: unread[room.ID]})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": subscribed})
+
+}
+
+// POST /chatrooms/:id/read
+func (c *MembershipController) MarkRead(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	var req struct {
+		MessageID uint `json:"message_id"`
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	lastRead, err := c.membershipService.MarkRead(userID, uint(chatRoomID), req.MessageID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotMember) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Let the user's other devices clear their unread badge
+	c.publish(&kafkapb.KafkaEvent{
+		Id:      uint64(lastRead),
+		UserId:  uint32(userID),
+		RoomId:  uint32(chatRoomID),
+		MsgType: "read",
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"chatroom_id":          chatRoomID,
+		"last_read_message_id": lastRead,
+	})
+}
+
+// POST /chatrooms/:id/members/:user_id/promote
+func (c *MembershipController) PromoteMember(ctx *gin.Context) {
+	c.setMemberRole(ctx, model.RoleAdmin)
+}
+
+// POST /chatrooms/:id/members/:user_id/demote
+func (c *MembershipController) DemoteMember(ctx *gin.Context) {
+	c.setMemberRole(ctx, model.RoleMember)
+}
+
+func (c *MembershipController) setMemberRole(ctx *gin.Context, role string) {
+	chatRoomID, targetID, ok := parseMemberParams(ctx)
+	if !ok {
+		return
+	}
+
+	actorID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.membershipService.SetMemberRole(actorID, chatRoomID, targetID, role); err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"chatroom_id": chatRoomID,
+		"user_id":     targetID,
+		"role":        role,
+	})
+}
+
+// DELETE /memberships
+func (c *MembershipController) LeaveChatRoom(ctx *gin.Context) {
+	var req struct {
+		ChatRoomID uint `json:"chatroomid" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.membershipService.LeaveChatRoom(userID, req.ChatRoomID); err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.publish(&kafkapb.KafkaEvent{
+		UserId:  uint32(userID),
+		RoomId:  uint32(req.ChatRoomID),
+		MsgType: "leave",
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "left chatroom",
+		"chatroom_id": req.ChatRoomID,
+	})
+}
+
+// POST /chatrooms/:id/members/:user_id/kick
+func (c *MembershipController) KickMember(ctx *gin.Context) {
+	c.removeMember(ctx, false)
+}
 
+// POST /chatrooms/:id/members/:user_id/ban
+func (c *MembershipController) BanMember(ctx *gin.Context) {
+	c.removeMember(ctx, true)
+}
+
+func (c *MembershipController) removeMember(ctx *gin.Context, ban bool) {
+	chatRoomID, targetID, ok := parseMemberParams(ctx)
+	if !ok {
+		return
+	}
+
+	actorID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	var err error
+	if ban {
+		err = c.membershipService.BanMember(actorID, chatRoomID, targetID)
+	} else {
+		err = c.membershipService.KickMember(actorID, chatRoomID, targetID)
+	}
+	if err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The gateway drops the user's connections from the room on this event
+	event := &kafkapb.KafkaEvent{
+		UserId:  uint32(targetID),
+		RoomId:  uint32(chatRoomID),
+		MsgType: "kicked",
+	}
+	if ban {
+		event.Content = []byte("banned")
+	}
+	c.publish(event)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"chatroom_id": chatRoomID,
+		"user_id":     targetID,
+		"banned":      ban,
+	})
+}
+
+// defaultInviteTTL is how long an invite link stays valid when the request doesn't say.
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+// maxInviteTTL caps how long an invite link can stay valid.
+const maxInviteTTL = 30 * 24 * time.Hour
+
+// POST /chatrooms/:id/invites
+func (c *MembershipController) CreateInvite(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+
+	var req struct {
+		ExpiresIn *int64 `json:"expires_in"` // seconds
+		MaxUses   int    `json:"max_uses"`
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.MaxUses < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "max_uses must not be negative"})
+		return
+	}
+	ttl := defaultInviteTTL
+	if req.ExpiresIn != nil {
+		if *req.ExpiresIn <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be positive"})
+			return
+		}
+		// compared in seconds so a huge value cannot overflow the duration
+		ttl = maxInviteTTL
+		if *req.ExpiresIn < int64(maxInviteTTL/time.Second) {
+			ttl = time.Duration(*req.ExpiresIn) * time.Second
+		}
+	}
+
+	actorID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	invite, err := c.membershipService.CreateInvite(actorID, uint(chatRoomID), ttl, req.MaxUses)
+	if err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"token":       invite.Token,
+		"chatroom_id": invite.ChatRoomID,
+		"expires_at":  invite.ExpiresAt,
+		"max_uses":    invite.MaxUses,
+	})
+}
+
+// GET /invites/:token
+func (c *MembershipController) PreviewInvite(ctx *gin.Context) {
+	preview, err := c.membershipService.PreviewInvite(ctx.Param("token"))
+	if err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// POST /invites/:token/accept
+func (c *MembershipController) AcceptInvite(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	room, err := c.membershipService.AcceptInvite(userID, ctx.Param("token"))
+	if err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, room)
+}
+
+// DELETE /invites/:token
+func (c *MembershipController) RevokeInvite(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.membershipService.RevokeInvite(userID, ctx.Param("token")); err != nil {
+		ctx.JSON(membershipErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "invite revoked"})
+}
+
+func (c *MembershipController) publish(event *kafkapb.KafkaEvent) {
+	if c.Publisher == nil {
+		return
+	}
+	if err := c.Publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
+
+// parseMemberParams reads the :id and :user_id path params, writing a 400 response when invalid.
+func parseMemberParams(ctx *gin.Context) (chatRoomID, userID uint, ok bool) {
+	roomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return 0, 0, false
+	}
+	targetID, err := strconv.ParseUint(ctx.Param("user_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return uint(roomID), uint(targetID), true
+}
+
+func membershipErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrForbidden),
+		errors.Is(err, service.ErrBanned),
+		errors.Is(err, service.ErrDirectRoomMembers),
+		errors.Is(err, service.ErrOwnerRole),
+		errors.Is(err, service.ErrOwnerCannotLeave):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNotMember),
+		errors.Is(err, service.ErrInviteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInviteInvalid):
+		return http.StatusGone
+	case errors.Is(err, service.ErrInvalidRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 )
 
 func TestMembershipController_AddUser_Success(t *testing.T) {
@@ -27,7 +31,7 @@ func TestMembershipController_AddUser_Success(t *testing.T) {
 	}
 
 	mockService.
-		On("AddUserToChatRoom", "john", uint(1)).
+		On("AddUserToChatRoom", uint(7), "john", uint(1)).
 		Return(nil).
 		Once()
 
@@ -39,6 +43,7 @@ func TestMembershipController_AddUser_Success(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.AddUser(ctx)
 
@@ -60,6 +65,7 @@ func TestMembershipController_AddUser_InvalidInput(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.AddUser(ctx)
 
@@ -78,7 +84,7 @@ func TestMembershipController_AddUser_ServiceError(t *testing.T) {
 	}
 
 	mockService.
-		On("AddUserToChatRoom", "john", uint(99)).
+		On("AddUserToChatRoom", uint(7), "john", uint(99)).
 		Return(errors.New("chatroom not found")).
 		Once()
 
@@ -90,6 +96,7 @@ func TestMembershipController_AddUser_ServiceError(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.AddUser(ctx)
 
@@ -114,15 +121,21 @@ func TestMembershipController_GetUserChatRooms_Success(t *testing.T) {
 		On("GetUserSubscribedChatRooms", "john").
 		Return(rooms, nil).
 		Once()
+	mockService.
+		On("GetUnreadCounts", "john").
+		Return(map[uint]int64{2: 5}, nil).
+		Once()
 
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
+	ctx.Set("uid", "john")
 
 	controller.GetUserChatRooms(ctx, "john")
 
 	require.Equal(t, http.StatusOK, w.Code)
 	require.Contains(t, w.Body.String(), "general")
 	require.Contains(t, w.Body.String(), "random")
+	require.Contains(t, w.Body.String(), `"UnreadCount":5`)
 
 	mockService.AssertExpectations(t)
 }
@@ -140,6 +153,7 @@ func TestMembershipController_GetUserChatRooms_ServiceError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
+	ctx.Set("uid", "john")
 
 	controller.GetUserChatRooms(ctx, "john")
 
@@ -149,12 +163,470 @@ func TestMembershipController_GetUserChatRooms_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestMembershipController_GetUserChatRooms_OtherUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Set("uid", "mallory")
+
+	controller.GetUserChatRooms(ctx, "john")
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertNotCalled(t, "GetUserSubscribedChatRooms", mock.Anything)
+	mockService.AssertNotCalled(t, "GetUnreadCounts", mock.Anything)
+}
+
+func TestMembershipController_MarkRead_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &MembershipController{membershipService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("MarkRead", uint(7), uint(3), uint(42)).
+		Return(uint(42), nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "read" && e.Id == 42 && e.UserId == 7 && e.RoomId == 3
+		})).
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/read", bytes.NewBufferString(`{"message_id":42}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.MarkRead(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"last_read_message_id":42`)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestMembershipController_MarkRead_NoBodyMarksLatest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("MarkRead", uint(7), uint(3), uint(0)).
+		Return(uint(10), nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/read", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.MarkRead(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"last_read_message_id":10`)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_MarkRead_NotMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("MarkRead", uint(7), uint(3), uint(0)).
+		Return(uint(0), service.ErrNotMember).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/read", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.MarkRead(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_PromoteMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "success", status: http.StatusOK},
+		{name: "not owner", err: service.ErrForbidden, status: http.StatusForbidden},
+		{name: "target is owner", err: service.ErrOwnerRole, status: http.StatusForbidden},
+		{name: "not a member", err: service.ErrNotMember, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMembershipService)
+			controller := NewMembershipController(mockService)
+
+			mockService.
+				On("SetMemberRole", uint(7), uint(3), uint(9), model.RoleAdmin).
+				Return(tt.err).
+				Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/members/9/promote", nil)
+			w := httptest.NewRecorder()
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "9"}}
+			ctx.Request = req
+			ctx.Set("user_id", uint(7))
+
+			controller.PromoteMember(ctx)
+
+			require.Equal(t, tt.status, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipController_DemoteMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("SetMemberRole", uint(7), uint(3), uint(9), model.RoleMember).
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/members/9/demote", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "9"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.DemoteMember(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"role":"member"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_LeaveChatRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &MembershipController{membershipService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("LeaveChatRoom", uint(7), uint(3)).
+		Return(nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "leave" && e.UserId == 7 && e.RoomId == 3
+		})).
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/memberships", bytes.NewBufferString(`{"chatroomid":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.LeaveChatRoom(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestMembershipController_LeaveChatRoom_Owner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("LeaveChatRoom", uint(7), uint(3)).
+		Return(service.ErrOwnerCannotLeave).
+		Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/memberships", bytes.NewBufferString(`{"chatroomid":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.LeaveChatRoom(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_KickAndBan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		method  string
+		handler func(*MembershipController, *gin.Context)
+		content string
+	}{
+		{name: "kick", method: "KickMember", handler: (*MembershipController).KickMember},
+		{name: "ban", method: "BanMember", handler: (*MembershipController).BanMember, content: "banned"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMembershipService)
+			mockPublisher := new(MockEventPublisher)
+			controller := &MembershipController{membershipService: mockService, Publisher: mockPublisher}
+
+			mockService.
+				On(tt.method, uint(7), uint(3), uint(9)).
+				Return(nil).
+				Once()
+			mockPublisher.
+				On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+					return e.MsgType == "kicked" && e.UserId == 9 && e.RoomId == 3 && string(e.Content) == tt.content
+				})).
+				Return(nil).
+				Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/members/9/"+tt.name, nil)
+			w := httptest.NewRecorder()
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "9"}}
+			ctx.Request = req
+			ctx.Set("user_id", uint(7))
+
+			tt.handler(controller, ctx)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			mockService.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipController_KickMember_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("KickMember", uint(7), uint(3), uint(9)).
+		Return(service.ErrForbidden).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/members/9/kick", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "9"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.KickMember(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_CreateInvite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("CreateInvite", uint(7), uint(3), time.Hour, 5).
+		Return(&model.RoomInvite{Token: "abc", ChatRoomID: 3, MaxUses: 5}, nil).
+		Once()
+
+	jsonBody, _ := json.Marshal(map[string]int{"expires_in": 3600, "max_uses": 5})
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/invites", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.CreateInvite(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Contains(t, w.Body.String(), `"token":"abc"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_CreateInvite_Defaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	mockService.
+		On("CreateInvite", uint(7), uint(3), defaultInviteTTL, 0).
+		Return(&model.RoomInvite{Token: "abc", ChatRoomID: 3}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/invites", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.CreateInvite(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMembershipController_CreateInvite_NegativeLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMembershipService)
+	controller := NewMembershipController(mockService)
+
+	jsonBody, _ := json.Marshal(map[string]int{"max_uses": -1})
+	req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/invites", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.CreateInvite(ctx)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "CreateInvite", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMembershipController_CreateInvite_ExpiresIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		expiresIn int64
+		status    int
+		ttl       time.Duration
+	}{
+		{0, http.StatusBadRequest, 0},
+		{-60, http.StatusBadRequest, 0},
+		{int64(maxInviteTTL / time.Second), http.StatusCreated, maxInviteTTL},
+		// would overflow time.Duration if multiplied out
+		{math.MaxInt64 / 2, http.StatusCreated, maxInviteTTL},
+	} {
+		mockService := new(MockMembershipService)
+		controller := NewMembershipController(mockService)
+		if tc.status == http.StatusCreated {
+			mockService.
+				On("CreateInvite", uint(7), uint(3), tc.ttl, 0).
+				Return(&model.RoomInvite{Token: "abc", ChatRoomID: 3}, nil).
+				Once()
+		}
+
+		jsonBody, _ := json.Marshal(map[string]int64{"expires_in": tc.expiresIn})
+		req := httptest.NewRequest(http.MethodPost, "/chatrooms/3/invites", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+		ctx.Request = req
+		ctx.Set("user_id", uint(7))
+
+		controller.CreateInvite(ctx)
+
+		require.Equal(t, tc.status, w.Code, tc.expiresIn)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestMembershipController_AcceptInvite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "accepted", wantCode: http.StatusOK},
+		{name: "unknown token", err: service.ErrInviteNotFound, wantCode: http.StatusNotFound},
+		{name: "used up", err: service.ErrInviteInvalid, wantCode: http.StatusGone},
+		{name: "banned", err: service.ErrBanned, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMembershipService)
+			controller := NewMembershipController(mockService)
+
+			var room *model.ChatRoom
+			if tt.err == nil {
+				room = &model.ChatRoom{ID: 3, Name: "secret"}
+			}
+			mockService.
+				On("AcceptInvite", uint(7), "abc").
+				Return(room, tt.err).
+				Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/invites/abc/accept", nil)
+			w := httptest.NewRecorder()
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "token", Value: "abc"}}
+			ctx.Request = req
+			ctx.Set("user_id", uint(7))
+
+			controller.AcceptInvite(ctx)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 type MockMembershipService struct {
 	mock.Mock
 }
 
-func (m *MockMembershipService) AddUserToChatRoom(username string, chatRoomID uint) error {
-	args := m.Called(username, chatRoomID)
+func (m *MockMembershipService) AddUserToChatRoom(actorID uint, username string, chatRoomID uint) error {
+	args := m.Called(actorID, username, chatRoomID)
 	return args.Error(0)
 }
 
@@ -167,3 +639,56 @@ func (m *MockMembershipService) GetUserChatRoomsFromDB(username string) ([]model
 	args := m.Called(username)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
+
+func (m *MockMembershipService) GetUnreadCounts(username string) (map[uint]int64, error) {
+	args := m.Called(username)
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
+func (m *MockMembershipService) MarkRead(userID, chatRoomID, messageID uint) (uint, error) {
+	args := m.Called(userID, chatRoomID, messageID)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockMembershipService) SetMemberRole(actorID, chatRoomID, targetUserID uint, role string) error {
+	args := m.Called(actorID, chatRoomID, targetUserID, role)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) LeaveChatRoom(userID, chatRoomID uint) error {
+	args := m.Called(userID, chatRoomID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) KickMember(actorID, chatRoomID, targetUserID uint) error {
+	args := m.Called(actorID, chatRoomID, targetUserID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) BanMember(actorID, chatRoomID, targetUserID uint) error {
+	args := m.Called(actorID, chatRoomID, targetUserID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) CreateInvite(actorID, chatRoomID uint, ttl time.Duration, maxUses int) (*model.RoomInvite, error) {
+	args := m.Called(actorID, chatRoomID, ttl, maxUses)
+	invite, _ := args.Get(0).(*model.RoomInvite)
+	return invite, args.Error(1)
+}
+
+func (m *MockMembershipService) PreviewInvite(token string) (*model.InvitePreview, error) {
+	args := m.Called(token)
+	preview, _ := args.Get(0).(*model.InvitePreview)
+	return preview, args.Error(1)
+}
+
+func (m *MockMembershipService) AcceptInvite(userID uint, token string) (*model.ChatRoom, error) {
+	args := m.Called(userID, token)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockMembershipService) RevokeInvite(actorID uint, token string) error {
+	args := m.Called(actorID, token)
+	return args.Error(0)
+}
@@ -1,7 +1,11 @@
 package controller
 
 import (
+	"backend/internal/model"
 	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -10,6 +14,11 @@ import (
 
 type MessageController struct {
 	MessageService service.MessageService
+	Publisher      service.EventPublisher
+	// Bots checks the scopes of requests made with bot API tokens.
+	Bots service.BotService
+	// Attachments links uploaded files to new messages. attachment_ids are rejected without it.
+	Attachments service.AttachmentService
 }
 
 // NewMessageController creates a new controller
@@ -20,25 +29,115 @@ func NewMessageController(ms service.MessageService) *MessageController {
 // POST /messages
 func (mc *MessageController) CreateMessage(c *gin.Context) {
 	var input struct {
-		Content    string `json:"content" binding:"required"`
-		UserID     uint   `json:"user_id" binding:"required"`
-		ChatRoomID uint   `json:"chat_room_id" binding:"required"`
+		Content       string `json:"content"`
+		UserID        uint   `json:"user_id"`
+		ChatRoomID    uint   `json:"chat_room_id" binding:"required"`
+		ParentID      uint   `json:"parent_id"`
+		AttachmentIDs []uint `json:"attachment_ids"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// An authenticated sender always posts as themselves
+	if userID, ok := currentUserID(c); ok {
+		input.UserID = userID
+	}
+	if input.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+	if !authorizeAPIToken(c, mc.Bots, model.ScopeMessagesWrite, input.ChatRoomID) {
+		return
+	}
+	if input.Content == "" && len(input.AttachmentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content or attachment_ids is required"})
+		return
+	}
+
+	var attachments []model.Attachment
+	if len(input.AttachmentIDs) > 0 {
+		if mc.Attachments == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "attachments are disabled"})
+			return
+		}
+		var err error
+		attachments, err = mc.Attachments.PrepareForMessage(input.UserID, input.ChatRoomID, input.AttachmentIDs)
+		if err != nil {
+			respondAttachmentError(c, err)
+			return
+		}
+	}
+
+	if input.ParentID != 0 {
+		msg, err := mc.MessageService.CreateReply(input.UserID, input.ChatRoomID, input.ParentID, input.Content)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrMessageNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrThreadRoomMismatch):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrNotMember), errors.Is(err, service.ErrBanned):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		mc.linkAttachments(msg, attachments)
+
+		publishMessage(mc.Publisher, msg)
+		c.JSON(http.StatusCreated, msg)
+		return
+	}
 
 	msg, err := mc.MessageService.CreateMessage(input.UserID, input.ChatRoomID, input.Content)
 	if err != nil {
+		if errors.Is(err, service.ErrNotMember) || errors.Is(err, service.ErrBanned) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	mc.linkAttachments(msg, attachments)
 
+	publishMessage(mc.Publisher, msg)
 	c.JSON(http.StatusCreated, msg)
 }
 
+func (mc *MessageController) linkAttachments(msg *model.Message, attachments []model.Attachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	if err := mc.Attachments.LinkToMessage(msg, attachments); err != nil {
+		log.Println("Error linking attachments:", err)
+	}
+}
+
+// publishMessage sends a message created over REST to live clients, the same way the
+// Kafka consumer does for messages sent over the websocket.
+func publishMessage(publisher service.EventPublisher, msg *model.Message) {
+	if publisher == nil {
+		return
+	}
+	event := &kafkapb.KafkaEvent{
+		Id:          uint64(msg.ID),
+		UserId:      uint32(msg.UserID),
+		RoomId:      uint32(msg.RoomID),
+		MsgType:     "message",
+		Content:     []byte(msg.Content),
+		Attachments: service.AttachmentRefs(msg.Attachments),
+	}
+	if msg.ParentID != nil {
+		event.ParentId = uint64(*msg.ParentID)
+	}
+	if err := publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
+
 // GET /chatrooms/:id/messages
 func (mc *MessageController) GetMessagesByChatRoom(c *gin.Context) {
 	chatRoomIDParam := c.Param("id")
@@ -47,14 +146,22 @@ func (mc *MessageController) GetMessagesByChatRoom(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
 		return
 	}
+	if !authorizeAPIToken(c, mc.Bots, model.ScopeMessagesRead, uint(chatRoomID)) {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
 
 	limitParam := c.Query("limit")
 	beforeParam := c.Query("before_id")
 
 	if limitParam == "" && beforeParam == "" {
-		messages, err := mc.MessageService.GetMessagesByChatRoom(uint(chatRoomID))
+		messages, err := mc.MessageService.GetMessagesByChatRoom(userID, uint(chatRoomID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondRoomReadError(c, err)
 			return
 		}
 
@@ -62,33 +169,84 @@ func (mc *MessageController) GetMessagesByChatRoom(c *gin.Context) {
 		return
 	}
 
+	beforeID, limit, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+
+	messages, err := mc.MessageService.GetMessagesPage(userID, uint(chatRoomID), beforeID, limit)
+	if err != nil {
+		respondRoomReadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// respondRoomReadError answers a failed read of a room's messages.
+func respondRoomReadError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrChatRoomNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GET /messages/:id/thread
+func (mc *MessageController) GetThread(c *gin.Context) {
+	idParam := c.Param("id")
+	msgID, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+
+	beforeID, limit, ok := parsePageParams(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	messages, err := mc.MessageService.GetThreadPage(userID, uint(msgID), beforeID, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// parsePageParams reads the before_id/limit paging query; limit defaults to 30.
+func parsePageParams(c *gin.Context) (uint, int, bool) {
 	limit := 30
-	if limitParam != "" {
+	if limitParam := c.Query("limit"); limitParam != "" {
 		parsedLimit, err := strconv.Atoi(limitParam)
 		if err != nil || parsedLimit <= 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
-			return
+			return 0, 0, false
 		}
 		limit = parsedLimit
 	}
 
 	var beforeID uint64
-	if beforeParam != "" {
+	if beforeParam := c.Query("before_id"); beforeParam != "" {
 		parsedBefore, err := strconv.ParseUint(beforeParam, 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before_id"})
-			return
+			return 0, 0, false
 		}
 		beforeID = parsedBefore
 	}
 
-	messages, err := mc.MessageService.GetMessagesPage(uint(chatRoomID), uint(beforeID), limit)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, messages)
+	return uint(beforeID), limit, true
 }
 
 // DELETE /messages/:id
@@ -100,10 +258,107 @@ func (mc *MessageController) DeleteMessage(c *gin.Context) {
 		return
 	}
 
-	if err := mc.MessageService.DeleteMessage(uint(msgID)); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := mc.MessageService.DeleteMessage(uint(msgID), userID); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.AbortWithStatus(http.StatusNoContent)
 }
+
+// PATCH /messages/:id
+func (mc *MessageController) EditMessage(c *gin.Context) {
+	idParam := c.Param("id")
+	msgID, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg, err := mc.MessageService.EditMessage(uint(msgID), userID, input.Content)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotMessageAuthor):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmptyContent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	mc.publish(&kafkapb.KafkaEvent{
+		Id:      uint64(msg.ID),
+		UserId:  uint32(msg.UserID),
+		RoomId:  uint32(msg.RoomID),
+		MsgType: "edit",
+		Content: []byte(msg.Content),
+	})
+
+	c.JSON(http.StatusOK, msg)
+}
+
+// GET /messages/:id/revisions
+func (mc *MessageController) GetMessageRevisions(c *gin.Context) {
+	idParam := c.Param("id")
+	msgID, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	revisions, err := mc.MessageService.GetMessageRevisions(userID, uint(msgID))
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// publish forwards an event to connected clients; failures are logged since the change is already persisted.
+func (mc *MessageController) publish(event *kafkapb.KafkaEvent) {
+	if mc.Publisher == nil {
+		return
+	}
+	if err := mc.Publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
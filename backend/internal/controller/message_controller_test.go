@@ -16,6 +16,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
 )
 
 func TestMessageController_CreateMessage_Success(t *testing.T) {
@@ -111,7 +113,7 @@ func TestMessageController_GetMessagesByChatRoom_Success(t *testing.T) {
 	}
 
 	mockService.
-		On("GetMessagesByChatRoom", uint(2)).
+		On("GetMessagesByChatRoom", uint(1), uint(2)).
 		Return(messages, nil).
 		Once()
 
@@ -121,6 +123,7 @@ func TestMessageController_GetMessagesByChatRoom_Success(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "2"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(1))
 
 	controller.GetMessagesByChatRoom(ctx)
 
@@ -130,6 +133,56 @@ func TestMessageController_GetMessagesByChatRoom_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestMessageController_GetMessagesByChatRoom_Hidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	mockService.
+		On("GetMessagesPage", uint(1), uint(2), uint(0), 30).
+		Return([]model.Message(nil), service.ErrChatRoomNotFound).
+		Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/chatrooms/2/messages?limit=30", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "2"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(1))
+
+	controller.GetMessagesByChatRoom(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_GetMessageRevisions_Hidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	mockService.
+		On("GetMessageRevisions", uint(1), uint(5)).
+		Return([]model.MessageRevision(nil), service.ErrMessageNotFound).
+		Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/5/revisions", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "5"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(1))
+
+	controller.GetMessageRevisions(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestMessageController_GetMessagesByChatRoom_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -153,7 +206,7 @@ func TestMessageController_DeleteMessage_Success(t *testing.T) {
 	mockService := new(MockMessageService)
 	controller := NewMessageController(mockService)
 
-	mockService.On("DeleteMessage", uint(1)).Return(nil).Once()
+	mockService.On("DeleteMessage", uint(1), uint(7)).Return(nil).Once()
 
 	req := httptest.NewRequest(http.MethodDelete, "/messages/1", nil)
 	w := httptest.NewRecorder()
@@ -161,6 +214,7 @@ func TestMessageController_DeleteMessage_Success(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.DeleteMessage(ctx)
 
@@ -179,6 +233,7 @@ func TestMessageController_DeleteMessage_InvalidID(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "abc"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.DeleteMessage(ctx)
 
@@ -191,7 +246,7 @@ func TestMessageController_DeleteMessage_ServiceError(t *testing.T) {
 	mockService := new(MockMessageService)
 	controller := NewMessageController(mockService)
 
-	mockService.On("DeleteMessage", uint(99)).Return(errors.New("not found")).Once()
+	mockService.On("DeleteMessage", uint(99), uint(7)).Return(errors.New("not found")).Once()
 
 	req := httptest.NewRequest(http.MethodDelete, "/messages/99", nil)
 	w := httptest.NewRecorder()
@@ -199,6 +254,7 @@ func TestMessageController_DeleteMessage_ServiceError(t *testing.T) {
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Params = gin.Params{{Key: "id", Value: "99"}}
 	ctx.Request = req
+	ctx.Set("user_id", uint(7))
 
 	controller.DeleteMessage(ctx)
 
@@ -207,6 +263,286 @@ func TestMessageController_DeleteMessage_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestMessageController_DeleteMessage_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	mockService.On("DeleteMessage", uint(1), uint(7)).Return(service.ErrForbidden).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/messages/1", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.DeleteMessage(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_EditMessage_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &MessageController{MessageService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("EditMessage", uint(1), uint(7), "fixed typo").
+		Return(&model.Message{ID: 1, Content: "fixed typo", UserID: 7, RoomID: 2, Edited: true}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "edit" && e.Id == 1 && e.RoomId == 2 && string(e.Content) == "fixed typo"
+		})).
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/messages/1", bytes.NewBufferString(`{"content":"fixed typo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.EditMessage(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "fixed typo")
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestMessageController_EditMessage_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewMessageController(new(MockMessageService))
+
+	req := httptest.NewRequest(http.MethodPatch, "/messages/1", bytes.NewBufferString(`{"content":"fixed typo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+
+	controller.EditMessage(ctx)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMessageController_EditMessage_NotAuthor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	mockService.
+		On("EditMessage", uint(1), uint(8), "not mine").
+		Return((*model.Message)(nil), service.ErrNotMessageAuthor).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/messages/1", bytes.NewBufferString(`{"content":"not mine"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(8))
+
+	controller.EditMessage(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_EditMessage_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	mockService.
+		On("EditMessage", uint(99), uint(7), "hello").
+		Return((*model.Message)(nil), service.ErrMessageNotFound).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/messages/99", bytes.NewBufferString(`{"content":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "99"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.EditMessage(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_GetThread_Paged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	parentID := uint(1)
+	replies := []model.Message{{ID: 5, Content: "reply", UserID: 2, RoomID: 2, ParentID: &parentID}}
+	mockService.On("GetThreadPage", uint(2), uint(1), uint(10), 5).Return(replies, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/1/thread?before_id=10&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(2))
+
+	controller.GetThread(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "reply")
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_GetThread_InvalidLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewMessageController(new(MockMessageService))
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/1/thread?limit=0", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	ctx.Request = req
+
+	controller.GetThread(ctx)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMessageController_CreateMessage_Reply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	controller := NewMessageController(mockService)
+
+	parentID := uint(4)
+	mockService.
+		On("CreateReply", uint(1), uint(2), uint(4), "in thread").
+		Return(&model.Message{ID: 9, Content: "in thread", UserID: 1, RoomID: 2, ParentID: &parentID}, nil).
+		Once()
+
+	body := `{"content":"in thread","user_id":1,"chat_room_id":2,"parent_id":4}`
+	req := httptest.NewRequest(http.MethodPost, "/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+
+	controller.CreateMessage(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessageController_CreateMessage_BotPublishes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	mockPublisher := new(MockEventPublisher)
+	bots := new(MockBotService)
+	controller := &MessageController{MessageService: mockService, Publisher: mockPublisher, Bots: bots}
+
+	token := &model.APIToken{ID: 3, UserID: 9, Scopes: model.ScopeMessagesWrite}
+	bots.On("Authorize", token, model.ScopeMessagesWrite, uint(2)).Return(nil).Once()
+	// the sender comes from the token, not from the body
+	mockService.
+		On("CreateMessage", uint(9), uint(2), "build passed").
+		Return(&model.Message{ID: 5, Content: "build passed", UserID: 9, RoomID: 2}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "message" && e.Id == 5 && e.UserId == 9 && e.RoomId == 2 && string(e.Content) == "build passed"
+		})).
+		Return(nil).
+		Once()
+
+	body := `{"content":"build passed","user_id":1,"chat_room_id":2}`
+	req := httptest.NewRequest(http.MethodPost, "/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(9))
+	ctx.Set("api_token", token)
+
+	controller.CreateMessage(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+	bots.AssertExpectations(t)
+}
+
+func TestMessageController_CreateMessage_BotScopeDenied(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockMessageService)
+	bots := new(MockBotService)
+	controller := &MessageController{MessageService: mockService, Bots: bots}
+
+	token := &model.APIToken{ID: 3, UserID: 9, Scopes: model.ScopeMessagesRead}
+	bots.On("Authorize", token, model.ScopeMessagesWrite, uint(2)).Return(service.ErrScopeDenied).Once()
+
+	body := `{"content":"build passed","chat_room_id":2}`
+	req := httptest.NewRequest(http.MethodPost, "/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Set("user_id", uint(9))
+	ctx.Set("api_token", token)
+
+	controller.CreateMessage(ctx)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+type MockBotService struct {
+	service.BotService
+	mock.Mock
+}
+
+func (m *MockBotService) Authorize(token *model.APIToken, scope string, roomID uint) error {
+	args := m.Called(token, scope, roomID)
+	return args.Error(0)
+}
+
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) HandleOutgoingMessage(event *kafkapb.KafkaEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
 type MockMessageService struct {
 	mock.Mock
 }
@@ -219,13 +555,13 @@ func (m *MockMessageService) CreateMessage(userID, roomID uint, content string)
 	return nil, args.Error(1)
 }
 
-func (m *MockMessageService) GetMessagesByChatRoom(chatRoomID uint) ([]model.Message, error) {
-	args := m.Called(chatRoomID)
+func (m *MockMessageService) GetMessagesByChatRoom(userID, chatRoomID uint) ([]model.Message, error) {
+	args := m.Called(userID, chatRoomID)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
-func (m *MockMessageService) DeleteMessage(id uint) error {
-	args := m.Called(id)
+func (m *MockMessageService) DeleteMessage(id, userID uint) error {
+	args := m.Called(id, userID)
 	return args.Error(0)
 }
 
@@ -234,7 +570,33 @@ func (m *MockMessageService) GetMessagesWithLimit(roomID uint, limit int) ([]mod
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
-func (m *MockMessageService) GetMessagesPage(roomID uint, beforeID uint, limit int) ([]model.Message, error) {
-	args := m.Called(roomID, beforeID, limit)
+func (m *MockMessageService) GetMessagesPage(userID, roomID uint, beforeID uint, limit int) ([]model.Message, error) {
+	args := m.Called(userID, roomID, beforeID, limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func (m *MockMessageService) EditMessage(id, userID uint, content string) (*model.Message, error) {
+	args := m.Called(id, userID, content)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetMessageRevisions(userID, id uint) ([]model.MessageRevision, error) {
+	args := m.Called(userID, id)
+	return args.Get(0).([]model.MessageRevision), args.Error(1)
+}
+
+func (m *MockMessageService) CreateReply(userID, roomID, parentID uint, content string) (*model.Message, error) {
+	args := m.Called(userID, roomID, parentID, content)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetThreadPage(userID, parentID uint, beforeID uint, limit int) ([]model.Message, error) {
+	args := m.Called(userID, parentID, beforeID, limit)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
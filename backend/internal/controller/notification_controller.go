@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+// maxNotificationPage caps the limit of GET /notifications.
+const maxNotificationPage = 100
+
+type NotificationController struct {
+	Service service.NotificationService
+}
+
+func NewNotificationController(service service.NotificationService) *NotificationController {
+	return &NotificationController{Service: service}
+}
+
+// GET /notifications
+func (c *NotificationController) ListNotifications(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	beforeID, limit, ok := parsePageParams(ctx)
+	if !ok {
+		return
+	}
+	limit = min(limit, maxNotificationPage)
+	unreadOnly := ctx.Query("unread") == "true"
+
+	notifications, err := c.Service.ListNotifications(userID, beforeID, limit, unreadOnly)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unread, err := c.Service.CountUnread(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": notifications, "unread": unread})
+}
+
+// POST /notifications/:id/read
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	if err := c.Service.MarkRead(userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "notification marked read"})
+}
+
+// POST /notifications/read-all
+func (c *NotificationController) MarkAllRead(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	n, err := c.Service.MarkAllRead(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"marked": n})
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestNotificationController_ListNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	controller := NewNotificationController(mockService)
+
+	mockService.On("ListNotifications", uint(7), uint(40), maxNotificationPage, true).
+		Return([]model.Mention{{ID: 3, UserID: 7, Kind: model.MentionUser}}, nil).Once()
+	mockService.On("CountUnread", uint(7)).Return(int64(4), nil).Once()
+
+	ctx, w := jsonContext(t, http.MethodGet, "/notifications?before_id=40&limit=500&unread=true", nil)
+	ctx.Set("user_id", uint(7))
+	controller.ListNotifications(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data   []model.Mention `json:"data"`
+		Unread int64           `json:"unread"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	require.EqualValues(t, 4, resp.Unread)
+	mockService.AssertExpectations(t)
+}
+
+func TestNotificationController_MarkRead_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	controller := NewNotificationController(mockService)
+	mockService.On("MarkRead", uint(7), uint(3)).Return(service.ErrNotificationNotFound).Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/notifications/3/read", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+	controller.MarkRead(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationService) ListNotifications(userID, beforeID uint, limit int, unreadOnly bool) ([]model.Mention, error) {
+	args := m.Called(userID, beforeID, limit, unreadOnly)
+	mentions, _ := args.Get(0).([]model.Mention)
+	return mentions, args.Error(1)
+}
+
+func (m *MockNotificationService) CountUnread(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkRead(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) MarkAllRead(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
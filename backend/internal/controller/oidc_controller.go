@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type OIDCController struct {
+	Service service.OIDCService
+}
+
+func NewOIDCController(service service.OIDCService) *OIDCController {
+	return &OIDCController{Service: service}
+}
+
+// GET /auth/oidc/login
+func (c *OIDCController) Login(ctx *gin.Context) {
+	url, err := c.Service.LoginURL()
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// GET /auth/oidc/callback
+func (c *OIDCController) Callback(ctx *gin.Context) {
+	if reason := ctx.Query("error"); reason != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": reason, "error_description": ctx.Query("error_description")})
+		return
+	}
+
+	tokens, err := c.Service.Callback(ctx.Query("state"), ctx.Query("code"), service.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	})
+	if err != nil {
+		ctx.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrOIDCEmailInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
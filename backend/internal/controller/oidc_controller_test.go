@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/service"
+)
+
+func TestOIDCController_Login_Redirects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockOIDCService)
+	controller := NewOIDCController(mockService)
+	mockService.On("LoginURL").Return("https://idp.test/authorize?state=abc", nil).Once()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil)
+
+	controller.Login(ctx)
+
+	require.Equal(t, http.StatusFound, w.Code)
+	require.Equal(t, "https://idp.test/authorize?state=abc", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCController_Callback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockOIDCService)
+	controller := NewOIDCController(mockService)
+	mockService.
+		On("Callback", "good", "code", mock.Anything).
+		Return(&service.AuthTokens{UserID: 9, AccessToken: "jwt", SessionID: "s1", RefreshToken: "s1.r"}, nil).
+		Once()
+	mockService.
+		On("Callback", "stale", "code", mock.Anything).
+		Return(nil, service.ErrInvalidOIDCState).
+		Once()
+	mockService.
+		On("Callback", "denied", "code", mock.Anything).
+		Return(nil, fmt.Errorf("%w: nonce mismatch", service.ErrOIDCLoginFailed)).
+		Once()
+
+	cases := map[string]int{
+		"/auth/oidc/callback?state=good&code=code":   http.StatusOK,
+		"/auth/oidc/callback?state=stale&code=code":  http.StatusBadRequest,
+		"/auth/oidc/callback?state=denied&code=code": http.StatusUnauthorized,
+		"/auth/oidc/callback?error=access_denied":    http.StatusUnauthorized,
+	}
+	for target, want := range cases {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+		controller.Callback(ctx)
+
+		require.Equal(t, want, w.Code, target)
+		if want == http.StatusOK {
+			require.Contains(t, w.Body.String(), `"refreshToken":"s1.r"`)
+		}
+	}
+	mockService.AssertExpectations(t)
+}
+
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) LoginURL() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCService) Callback(state, code string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(state, code, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AuthTokens), args.Error(1)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+type PinController struct {
+	PinService service.PinService
+	Publisher  service.EventPublisher
+}
+
+func NewPinController(pinService service.PinService) *PinController {
+	return &PinController{PinService: pinService}
+}
+
+// GET /chatrooms/:id/pins
+func (c *PinController) ListPins(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	roomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+
+	pins, err := c.PinService.ListPins(userID, uint(roomID))
+	if err != nil {
+		respondPinError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": pins})
+}
+
+// POST /messages/:id/pin
+func (c *PinController) PinMessage(ctx *gin.Context) {
+	msgID, userID, ok := c.parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	pin, err := c.PinService.PinMessage(userID, msgID)
+	if err != nil {
+		respondPinError(ctx, err)
+		return
+	}
+
+	c.publish(pin.Message, userID, true)
+	ctx.JSON(http.StatusCreated, gin.H{"data": pin})
+}
+
+// DELETE /messages/:id/pin
+func (c *PinController) UnpinMessage(ctx *gin.Context) {
+	msgID, userID, ok := c.parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	msg, err := c.PinService.UnpinMessage(userID, msgID)
+	if err != nil {
+		respondPinError(ctx, err)
+		return
+	}
+
+	c.publish(msg, userID, false)
+	ctx.AbortWithStatus(http.StatusNoContent)
+}
+
+func (c *PinController) parseRequest(ctx *gin.Context) (uint, uint, bool) {
+	msgID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return 0, 0, false
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return 0, 0, false
+	}
+	return uint(msgID), userID, true
+}
+
+func (c *PinController) publish(msg *model.Message, userID uint, pinned bool) {
+	if c.Publisher == nil {
+		return
+	}
+	content, err := json.Marshal(service.PinEvent{MessageID: msg.ID, Pinned: pinned, PinnedBy: userID})
+	if err != nil {
+		log.Println("Error encoding pin event:", err)
+		return
+	}
+	event := &kafkapb.KafkaEvent{
+		Id:      uint64(msg.ID),
+		UserId:  uint32(userID),
+		RoomId:  uint32(msg.RoomID),
+		MsgType: service.PinEventType,
+		Content: content,
+	}
+	if err := c.Publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
+
+func respondPinError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMessageNotFound), errors.Is(err, service.ErrPinNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyPinned), errors.Is(err, service.ErrTooManyPins):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
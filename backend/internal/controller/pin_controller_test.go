@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func TestPinController_PinMessage_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPinService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &PinController{PinService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("PinMessage", uint(7), uint(3)).
+		Return(&model.PinnedMessage{ID: 1, ChatRoomID: 2, MessageID: 3, PinnedByID: 7, Message: &model.Message{ID: 3, RoomID: 2}}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			var content service.PinEvent
+			return e.MsgType == service.PinEventType && e.Id == 3 && e.RoomId == 2 &&
+				json.Unmarshal(e.Content, &content) == nil && content == service.PinEvent{MessageID: 3, Pinned: true, PinnedBy: 7}
+		})).
+		Return(nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/messages/3/pin", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+
+	controller.PinMessage(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var body struct {
+		Data model.PinnedMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, uint(3), body.Data.MessageID)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestPinController_PinMessage_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, status := range map[error]int{
+		service.ErrForbidden:       http.StatusForbidden,
+		service.ErrAlreadyPinned:   http.StatusConflict,
+		service.ErrMessageNotFound: http.StatusNotFound,
+	} {
+		mockService := new(MockPinService)
+		controller := NewPinController(mockService)
+		mockService.On("PinMessage", uint(7), uint(3)).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodPost, "/messages/3/pin", nil)
+		ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+		ctx.Set("user_id", uint(7))
+
+		controller.PinMessage(ctx)
+
+		require.Equal(t, status, w.Code, err.Error())
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestPinController_UnpinMessage_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPinService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &PinController{PinService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("UnpinMessage", uint(7), uint(3)).
+		Return(&model.Message{ID: 3, RoomID: 2}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			var content service.PinEvent
+			return e.MsgType == service.PinEventType && e.RoomId == 2 &&
+				json.Unmarshal(e.Content, &content) == nil && !content.Pinned
+		})).
+		Return(nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodDelete, "/messages/3/pin", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+
+	controller.UnpinMessage(ctx)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestPinController_ListPins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPinService)
+	controller := NewPinController(mockService)
+
+	mockService.
+		On("ListPins", uint(7), uint(2)).
+		Return([]model.PinnedMessage{{ID: 1, ChatRoomID: 2, MessageID: 3, Message: &model.Message{ID: 3, Content: "release at 5pm"}}}, nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodGet, "/chatrooms/2/pins", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "2"}}
+	ctx.Set("user_id", uint(7))
+
+	controller.ListPins(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"release at 5pm"`)
+	mockService.AssertExpectations(t)
+}
+
+type MockPinService struct {
+	mock.Mock
+}
+
+func (m *MockPinService) PinMessage(userID, messageID uint) (*model.PinnedMessage, error) {
+	args := m.Called(userID, messageID)
+	pin, _ := args.Get(0).(*model.PinnedMessage)
+	return pin, args.Error(1)
+}
+
+func (m *MockPinService) UnpinMessage(userID, messageID uint) (*model.Message, error) {
+	args := m.Called(userID, messageID)
+	msg, _ := args.Get(0).(*model.Message)
+	return msg, args.Error(1)
+}
+
+func (m *MockPinService) ListPins(userID, chatRoomID uint) ([]model.PinnedMessage, error) {
+	args := m.Called(userID, chatRoomID)
+	pins, _ := args.Get(0).([]model.PinnedMessage)
+	return pins, args.Error(1)
+}
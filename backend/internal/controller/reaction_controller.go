@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionController struct {
+	ReactionService service.ReactionService
+	Publisher       service.EventPublisher
+}
+
+// NewReactionController creates a new controller
+func NewReactionController(rs service.ReactionService) *ReactionController {
+	return &ReactionController{ReactionService: rs}
+}
+
+// POST /messages/:id/reactions
+func (rc *ReactionController) AddReaction(c *gin.Context) {
+	msgID, userID, ok := rc.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg, err := rc.ReactionService.AddReaction(msgID, userID, input.Emoji)
+	if err != nil {
+		rc.writeError(c, err)
+		return
+	}
+
+	rc.publish("reaction_add", msg, userID, input.Emoji)
+	c.JSON(http.StatusCreated, gin.H{"message_id": msgID, "emoji": input.Emoji})
+}
+
+// DELETE /messages/:id/reactions/:emoji
+func (rc *ReactionController) RemoveReaction(c *gin.Context) {
+	msgID, userID, ok := rc.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	emoji := c.Param("emoji")
+	msg, err := rc.ReactionService.RemoveReaction(msgID, userID, emoji)
+	if err != nil {
+		rc.writeError(c, err)
+		return
+	}
+
+	rc.publish("reaction_remove", msg, userID, emoji)
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// GET /messages/:id/reactions
+func (rc *ReactionController) GetReactions(c *gin.Context) {
+	msgID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+
+	reactions, err := rc.ReactionService.GetReactions(uint(msgID))
+	if err != nil {
+		rc.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reactions)
+}
+
+func (rc *ReactionController) parseRequest(c *gin.Context) (uint, uint, bool) {
+	msgID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return 0, 0, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return 0, 0, false
+	}
+	return uint(msgID), userID, true
+}
+
+func (rc *ReactionController) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMessageNotFound), errors.Is(err, service.ErrReactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrReactionExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidEmoji):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (rc *ReactionController) publish(msgType string, msg *model.Message, userID uint, emoji string) {
+	if rc.Publisher == nil {
+		return
+	}
+	event := &kafkapb.KafkaEvent{
+		Id:      uint64(msg.ID),
+		UserId:  uint32(userID),
+		RoomId:  uint32(msg.RoomID),
+		MsgType: msgType,
+		Content: []byte(emoji),
+	}
+	if err := rc.Publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("Error publishing event:", err)
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func TestReactionController_AddReaction_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReactionService)
+	mockPublisher := new(MockEventPublisher)
+	controller := &ReactionController{ReactionService: mockService, Publisher: mockPublisher}
+
+	mockService.
+		On("AddReaction", uint(3), uint(7), "👍").
+		Return(&model.Message{ID: 3, RoomID: 2}, nil).
+		Once()
+	mockPublisher.
+		On("HandleOutgoingMessage", mock.MatchedBy(func(e *kafkapb.KafkaEvent) bool {
+			return e.MsgType == "reaction_add" && e.Id == 3 && e.RoomId == 2 && e.UserId == 7 && string(e.Content) == "👍"
+		})).
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/3/reactions", bytes.NewBufferString(`{"emoji":"👍"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.AddReaction(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestReactionController_AddReaction_Duplicate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReactionService)
+	controller := NewReactionController(mockService)
+
+	mockService.
+		On("AddReaction", uint(3), uint(7), "👍").
+		Return((*model.Message)(nil), service.ErrReactionExists).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/3/reactions", bytes.NewBufferString(`{"emoji":"👍"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.AddReaction(ctx)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReactionController_RemoveReaction_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReactionService)
+	controller := NewReactionController(mockService)
+
+	mockService.
+		On("RemoveReaction", uint(3), uint(7), "🎉").
+		Return(&model.Message{ID: 3, RoomID: 2}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/messages/3/reactions/🎉", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "emoji", Value: "🎉"}}
+	ctx.Request = req
+	ctx.Set("user_id", uint(7))
+
+	controller.RemoveReaction(ctx)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReactionController_GetReactions_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReactionService)
+	controller := NewReactionController(mockService)
+
+	mockService.
+		On("GetReactions", uint(99)).
+		Return([]model.MessageReaction(nil), service.ErrMessageNotFound).
+		Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/99/reactions", nil)
+	w := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "99"}}
+	ctx.Request = req
+
+	controller.GetReactions(ctx)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+type MockReactionService struct {
+	mock.Mock
+}
+
+func (m *MockReactionService) AddReaction(messageID, userID uint, emoji string) (*model.Message, error) {
+	args := m.Called(messageID, userID, emoji)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReactionService) RemoveReaction(messageID, userID uint, emoji string) (*model.Message, error) {
+	args := m.Called(messageID, userID, emoji)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReactionService) GetReactions(messageID uint) ([]model.MessageReaction, error) {
+	args := m.Called(messageID)
+	return args.Get(0).([]model.MessageReaction), args.Error(1)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type SearchController struct {
+	Service service.SearchService
+}
+
+func NewSearchController(service service.SearchService) *SearchController {
+	return &SearchController{Service: service}
+}
+
+// GET /search/messages
+func (c *SearchController) SearchMessages(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	req := service.MessageSearch{
+		Text:   ctx.Query("q"),
+		Author: ctx.Query("author"),
+		Cursor: ctx.Query("cursor"),
+	}
+	if roomParam := ctx.Query("room_id"); roomParam != "" {
+		roomID, err := strconv.ParseUint(roomParam, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid room_id"})
+			return
+		}
+		req.ChatRoomID = uint(roomID)
+	}
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		req.Limit = limit
+	}
+	var err error
+	if req.From, err = parseSearchTime(ctx.Query("from"), false); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	if req.To, err = parseSearchTime(ctx.Query("to"), true); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+
+	result, err := c.Service.SearchMessages(userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSearch):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// parseSearchTime accepts an RFC 3339 time or a date. A date given as the end of a range
+// includes that whole day.
+func parseSearchTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
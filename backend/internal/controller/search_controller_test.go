@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/search"
+	"backend/internal/service"
+)
+
+func TestSearchController_SearchMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSearchService)
+	controller := NewSearchController(mockService)
+
+	want := service.MessageSearch{
+		Text:       "deploy",
+		ChatRoomID: 3,
+		Author:     "bob",
+		From:       time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		To:         time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC), // the whole of Jan 5
+		Cursor:     "40",
+		Limit:      10,
+	}
+	mockService.On("SearchMessages", uint(7), want).Return(&search.Result{Hits: []search.Hit{{MessageID: 39}}}, nil).Once()
+
+	ctx, w := jsonContext(t, http.MethodGet, "/search/messages?q=deploy&room_id=3&author=bob&from=2026-01-02&to=2026-01-05&cursor=40&limit=10", nil)
+	ctx.Set("user_id", uint(7))
+	controller.SearchMessages(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"message_id":39`)
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchController_SearchMessages_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSearchService)
+	controller := NewSearchController(mockService)
+
+	ctx, w := jsonContext(t, http.MethodGet, "/search/messages?q=deploy&from=yesterday", nil)
+	ctx.Set("user_id", uint(7))
+	controller.SearchMessages(ctx)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	for err, code := range map[error]int{
+		service.ErrInvalidSearch: http.StatusBadRequest,
+		service.ErrForbidden:     http.StatusForbidden,
+	} {
+		mockService.On("SearchMessages", uint(7), mock.Anything).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodGet, "/search/messages?q=deploy&room_id=3", nil)
+		ctx.Set("user_id", uint(7))
+		controller.SearchMessages(ctx)
+		require.Equal(t, code, w.Code, err.Error())
+	}
+}
+
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) SearchMessages(userID uint, req service.MessageSearch) (*search.Result, error) {
+	args := m.Called(userID, req)
+	result, _ := args.Get(0).(*search.Result)
+	return result, args.Error(1)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type SemanticSearchController struct {
+	Service service.SemanticSearchService
+}
+
+func NewSemanticSearchController(service service.SemanticSearchService) *SemanticSearchController {
+	return &SemanticSearchController{Service: service}
+}
+
+// GET /chatrooms/:id/semantic-search
+func (c *SemanticSearchController) Search(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	var k int
+	if kParam := ctx.Query("k"); kParam != "" {
+		if k, err = strconv.Atoi(kParam); err != nil || k <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid k"})
+			return
+		}
+	}
+
+	hits, err := c.Service.Search(userID, uint(chatRoomID), ctx.Query("q"), k)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSearch):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrSemanticSearchUnavailable):
+			// the cause can name internal addresses, so it only goes to the log
+			log.Println("semantic search failed:", err)
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": service.ErrSemanticSearchUnavailable.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": hits})
+}
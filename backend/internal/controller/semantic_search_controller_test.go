@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestSemanticSearchController_Search(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSemanticSearchService)
+	controller := NewSemanticSearchController(mockService)
+
+	mockService.On("Search", uint(7), uint(3), "how do we deploy", 5).
+		Return([]service.SemanticHit{{Message: model.Message{ID: 11, Content: "we use docker"}, Score: 0.8}}, nil).Once()
+
+	ctx, w := jsonContext(t, http.MethodGet, "/chatrooms/3/semantic-search?q=how+do+we+deploy&k=5", nil)
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+	controller.Search(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "we use docker")
+	mockService.AssertExpectations(t)
+}
+
+func TestSemanticSearchController_Search_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSemanticSearchService)
+	controller := NewSemanticSearchController(mockService)
+
+	for err, code := range map[error]int{
+		service.ErrForbidden: http.StatusForbidden,
+		fmt.Errorf("%w: dial tcp 10.0.0.5:8010: connection refused", service.ErrSemanticSearchUnavailable): http.StatusBadGateway,
+	} {
+		mockService.On("Search", uint(7), uint(3), "deploy", 0).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodGet, "/chatrooms/3/semantic-search?q=deploy", nil)
+		ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+		ctx.Set("user_id", uint(7))
+		controller.Search(ctx)
+
+		require.Equal(t, code, w.Code, err.Error())
+		require.NotContains(t, w.Body.String(), "10.0.0.5")
+	}
+}
+
+type MockSemanticSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSemanticSearchService) Search(userID, chatRoomID uint, query string, k int) ([]service.SemanticHit, error) {
+	args := m.Called(userID, chatRoomID, query, k)
+	hits, _ := args.Get(0).([]service.SemanticHit)
+	return hits, args.Error(1)
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/service"
+)
+
+type WebhookController struct {
+	Service service.WebhookService
+}
+
+func NewWebhookController(service service.WebhookService) *WebhookController {
+	return &WebhookController{Service: service}
+}
+
+// POST /chatrooms/:id/webhooks
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	var body struct {
+		URL    string   `json:"url" binding:"required"`
+		Events []string `json:"events" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, secret, err := c.Service.CreateWebhook(userID, uint(chatRoomID), body.URL, body.Events)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// the secret is only ever shown here
+	ctx.JSON(http.StatusCreated, gin.H{"webhook": hook, "secret": secret})
+}
+
+// GET /chatrooms/:id/webhooks
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	chatRoomID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat room id"})
+		return
+	}
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+
+	hooks, err := c.Service.ListWebhooks(userID, uint(chatRoomID))
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": hooks})
+}
+
+// DELETE /webhooks/:id
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	webhookID, userID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.Service.DeleteWebhook(userID, webhookID); err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// POST /webhooks/:id/enable
+func (c *WebhookController) EnableWebhook(ctx *gin.Context) {
+	webhookID, userID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.Service.EnableWebhook(userID, webhookID); err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook enabled"})
+}
+
+// GET /webhooks/:id/deliveries
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	webhookID, userID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+	limit := 50
+	if v := ctx.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > 200 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = l
+	}
+
+	deliveries, err := c.Service.ListDeliveries(userID, webhookID, limit)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// POST /webhooks/:id/deliveries/:delivery_id/replay
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	webhookID, userID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(ctx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	delivery, err := c.Service.ReplayDelivery(userID, webhookID, uint(deliveryID))
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+func parseWebhookParams(ctx *gin.Context) (webhookID, userID uint, ok bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return 0, 0, false
+	}
+	userID, ok = currentUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return 0, 0, false
+	}
+	return uint(id), userID, true
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrWebhookDisabled):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestWebhookController_CreateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockWebhookService)
+	controller := NewWebhookController(mockService)
+
+	mockService.
+		On("CreateWebhook", uint(7), uint(3), "https://hooks.test/a", []string{"message"}).
+		Return(&model.Webhook{ID: 1, ChatRoomID: 3, URL: "https://hooks.test/a", Secret: "s3cret", Events: "message", Enabled: true}, "s3cret", nil).
+		Once()
+
+	ctx, w := jsonContext(t, http.MethodPost, "/chatrooms/3/webhooks", map[string]any{"url": "https://hooks.test/a", "events": []string{"message"}})
+	ctx.Params = gin.Params{{Key: "id", Value: "3"}}
+	ctx.Set("user_id", uint(7))
+	controller.CreateWebhook(ctx)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	// the secret is returned next to the webhook, never as part of it
+	require.Equal(t, 1, strings.Count(w.Body.String(), "s3cret"))
+	mockService.AssertExpectations(t)
+}
+
+func TestWebhookController_ReplayDelivery_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockWebhookService)
+	controller := NewWebhookController(mockService)
+
+	for err, want := range map[error]int{
+		service.ErrForbidden:        http.StatusForbidden,
+		service.ErrDeliveryNotFound: http.StatusNotFound,
+		service.ErrWebhookDisabled:  http.StatusConflict,
+	} {
+		mockService.On("ReplayDelivery", uint(7), uint(1), uint(5)).Return(nil, err).Once()
+
+		ctx, w := jsonContext(t, http.MethodPost, "/webhooks/1/deliveries/5/replay", nil)
+		ctx.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "delivery_id", Value: "5"}}
+		ctx.Set("user_id", uint(7))
+		controller.ReplayDelivery(ctx)
+
+		require.Equal(t, want, w.Code, err.Error())
+	}
+}
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(userID, chatRoomID uint, rawURL string, events []string) (*model.Webhook, string, error) {
+	args := m.Called(userID, chatRoomID, rawURL, events)
+	hook, _ := args.Get(0).(*model.Webhook)
+	return hook, args.String(1), args.Error(2)
+}
+
+func (m *MockWebhookService) ListWebhooks(userID, chatRoomID uint) ([]model.Webhook, error) {
+	args := m.Called(userID, chatRoomID)
+	hooks, _ := args.Get(0).([]model.Webhook)
+	return hooks, args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(userID, webhookID uint) error {
+	return m.Called(userID, webhookID).Error(0)
+}
+
+func (m *MockWebhookService) EnableWebhook(userID, webhookID uint) error {
+	return m.Called(userID, webhookID).Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(userID, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	args := m.Called(userID, webhookID, limit)
+	deliveries, _ := args.Get(0).([]model.WebhookDelivery)
+	return deliveries, args.Error(1)
+}
+
+func (m *MockWebhookService) ReplayDelivery(userID, webhookID, deliveryID uint) (*model.WebhookDelivery, error) {
+	args := m.Called(userID, webhookID, deliveryID)
+	delivery, _ := args.Get(0).(*model.WebhookDelivery)
+	return delivery, args.Error(1)
+}
@@ -14,6 +14,8 @@ import (
 
 type AuthMiddleware struct {
 	AuthSvc service.AuthService
+	// Bots resolves API tokens for routes guarded by AuthWithAPITokens.
+	Bots service.BotService
 }
 
 func NewAuthMiddleware(authSvc service.AuthService) *AuthMiddleware {
@@ -22,6 +24,16 @@ func NewAuthMiddleware(authSvc service.AuthService) *AuthMiddleware {
 
 // Middleware function
 func (m *AuthMiddleware) Auth() gin.HandlerFunc {
+	return m.auth(false)
+}
+
+// AuthWithAPITokens also accepts bot API tokens. Handlers behind it must check the token's
+// scopes; the token is stored in the context under "api_token".
+func (m *AuthMiddleware) AuthWithAPITokens() gin.HandlerFunc {
+	return m.auth(true)
+}
+
+func (m *AuthMiddleware) auth(allowAPITokens bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -31,12 +43,50 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		_, _, err := m.AuthSvc.ValidateJWT(tokenString)
+		if strings.HasPrefix(tokenString, service.APITokenPrefix) {
+			if !allowAPITokens || m.Bots == nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api tokens are not accepted here"})
+				return
+			}
+			bot, token, err := m.Bots.Authenticate(tokenString)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.Set("uid", bot.Username)
+			c.Set("user_id", bot.ID)
+			c.Set("api_token", token)
+			c.Next()
+			return
+		}
+
+		user, session, err := m.AuthSvc.ValidateJWT(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
+		// 2. Attach the authenticated identity to the context
+		if user != nil {
+			c.Set("uid", user.Username)
+			c.Set("user_id", user.ID)
+			c.Set("is_admin", user.IsAdmin)
+		}
+		if session != nil {
+			c.Set("session_id", session.SessionID)
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests from users without the admin flag. It must run after Auth.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("is_admin") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			return
+		}
 		c.Next()
 	}
 }
@@ -12,9 +12,9 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
-	//	"backend/internal/service"
 	"backend/internal/middleware/jwtauth"
 	"backend/internal/model"
+	"backend/internal/service"
 )
 
 func setupRouter(mw gin.HandlerFunc) *gin.Engine {
@@ -86,6 +86,99 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	require.Contains(t, w.Body.String(), `"ok":true`)
 }
 
+func TestAuthMiddleware_SetsIdentity(t *testing.T) {
+	authSvc := &MockAuthService{}
+	mw := &jwtauth.AuthMiddleware{AuthSvc: authSvc}
+	authSvc.On("ValidateJWT", "good-token").Return(
+		&model.User{ID: 7, Username: "alice"},
+		&model.UserSession{SessionID: "s-1"},
+		nil,
+	)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(mw.Auth())
+	r.GET("/protected", func(c *gin.Context) {
+		require.Equal(t, uint(7), c.GetUint("user_id"))
+		require.Equal(t, "alice", c.GetString("uid"))
+		require.Equal(t, "s-1", c.GetString("session_id"))
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer good-token")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	authSvc := &MockAuthService{}
+	mw := &jwtauth.AuthMiddleware{AuthSvc: authSvc}
+	authSvc.On("ValidateJWT", "admin-token").Return(&model.User{ID: 1, Username: "root", IsAdmin: true}, nil, nil)
+	authSvc.On("ValidateJWT", "user-token").Return(&model.User{ID: 2, Username: "alice"}, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(mw.Auth(), jwtauth.RequireAdmin())
+	r.GET("/admin", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for token, want := range map[string]int{"admin-token": http.StatusOK, "user-token": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, want, w.Code, token)
+	}
+}
+
+func TestAuthMiddleware_APITokens(t *testing.T) {
+	authSvc := &MockAuthService{}
+	bots := &MockBotService{}
+	token := &model.APIToken{ID: 3, UserID: 9, Scopes: model.ScopeMessagesWrite}
+	bots.On("Authenticate", "bot_secret").Return(&model.User{ID: 9, Username: "helper", IsBot: true}, token, nil)
+	mw := &jwtauth.AuthMiddleware{AuthSvc: authSvc, Bots: bots}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/session-only", mw.Auth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/bots-allowed", mw.AuthWithAPITokens(), func(c *gin.Context) {
+		require.Equal(t, uint(9), c.GetUint("user_id"))
+		require.Equal(t, "helper", c.GetString("uid"))
+		got, _ := c.Get("api_token")
+		require.Same(t, token, got)
+		c.Status(http.StatusOK)
+	})
+
+	for path, want := range map[string]int{"/session-only": http.StatusUnauthorized, "/bots-allowed": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer bot_secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, want, w.Code, path)
+	}
+	// API tokens never reach the JWT validation
+	authSvc.AssertNotCalled(t, "ValidateJWT", mock.Anything)
+}
+
+type MockBotService struct {
+	service.BotService
+	mock.Mock
+}
+
+func (m *MockBotService) Authenticate(token string) (*model.User, *model.APIToken, error) {
+	args := m.Called(token)
+	user, _ := args.Get(0).(*model.User)
+	info, _ := args.Get(1).(*model.APIToken)
+	return user, info, args.Error(2)
+}
+
 type MockAuthService struct {
 	mock.Mock
 	validateFn func(token string) error
@@ -96,13 +189,27 @@ func (m *MockAuthService) Register(user *model.User) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Login(username, password string) (uint, string, string, string, error) {
-	args := m.Called(username, password)
-	return args.Get(0).(uint),
-		args.Get(1).(string),
-		args.Get(2).(string),
-		args.Get(3).(string),
-		args.Error(4)
+func (m *MockAuthService) Login(username, password string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(username, password, client)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) ListSessions(userID uint) ([]model.UserSession, error) {
+	args := m.Called(userID)
+	sessions, _ := args.Get(0).([]model.UserSession)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID uint, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (*service.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
 }
 
 func (m *MockAuthService) Logout(tokenStr string) error {
@@ -136,6 +243,12 @@ func (m *MockAuthService) ForceLogoutOne(userID uint, sessionID string) error {
 	return args.Error(0)
 }
 
+func (m *MockAuthService) ForceLogoutOthers(userID uint, keepSessionID string) ([]string, error) {
+	args := m.Called(userID, keepSessionID)
+	sessions, _ := args.Get(0).([]string)
+	return sessions, args.Error(1)
+}
+
 func (m *MockAuthService) GetUserIDByUsername(username string) (uint, error) {
 	args := m.Called(username)
 	return args.Get(0).(uint), args.Error(1)
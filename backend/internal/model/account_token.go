@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Purposes of an AccountToken
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// AccountToken is a single-use secret mailed to a user, e.g. to verify their email or reset their password.
+// Only the SHA-256 of the token is stored.
+type AccountToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Purpose   string `gorm:"not null;size:32"`
+	TokenHash string `gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Usable reports whether the token can still be redeemed at now.
+func (t *AccountToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scopes an APIToken can be granted. The message scopes may be narrowed to one room by
+// appending ":<room id>", e.g. "messages:write:12".
+const (
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesWrite = "messages:write"
+	ScopeCommandsWrite = "commands:write"
+)
+
+// APIToken is a long-lived credential for a bot. Only the SHA-256 of the token is stored.
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `gorm:"uniqueIndex;not null;size:64" json:"-"`
+	Scopes     string     `json:"scopes"` // space separated
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Revoked    bool       `gorm:"default:false" json:"revoked"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the token is neither revoked nor expired at now.
+func (t *APIToken) Usable(now time.Time) bool {
+	return !t.Revoked && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// Allows reports whether the token grants scope in roomID.
+func (t *APIToken) Allows(scope string, roomID uint) bool {
+	roomScope := scope + ":" + strconv.FormatUint(uint64(roomID), 10)
+	for _, granted := range strings.Fields(t.Scopes) {
+		if granted == scope || granted == roomScope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Attachment is a file uploaded into a chat room. It is pending until its bytes are uploaded,
+// and belongs to a message once it is sent with one.
+type Attachment struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	UploaderID  uint   `gorm:"not null;index" json:"uploader_id"`
+	ChatRoomID  uint   `gorm:"not null" json:"chat_room_id"`
+	MessageID   *uint  `gorm:"index" json:"message_id"`
+	Filename    string `gorm:"not null;size:255" json:"filename"`
+	ContentType string `gorm:"not null;size:127" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+	// Width and Height are set for images.
+	Width        int        `json:"width,omitempty"`
+	Height       int        `json:"height,omitempty"`
+	StorageKey   string     `gorm:"not null" json:"-"`
+	ThumbnailKey string     `json:"-"`
+	UploadedAt   *time.Time `json:"uploaded_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// URL is where members fetch the attachment's details and download links.
+	URL string `gorm:"-" json:"url"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// BotCommand is a slash command served by a bot. When a member of a room the bot is in runs
+// it, the backend POSTs the command to CallbackURL, signed with Secret.
+type BotCommand struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	BotID       uint      `gorm:"not null;index" json:"bot_id"`
+	Name        string    `gorm:"uniqueIndex;not null;size:32" json:"name"`
+	Description string    `json:"description"`
+	CallbackURL string    `gorm:"not null" json:"callback_url"`
+	Secret      string    `gorm:"not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
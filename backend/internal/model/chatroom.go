@@ -4,8 +4,22 @@ import (
 	"time"
 )
 
+// Conversation kinds of a ChatRoom
+const (
+	ChatRoomPublic  = "public"
+	ChatRoomPrivate = "private"
+	ChatRoomDirect  = "direct"
+)
+
 type ChatRoom struct {
-	ID        uint   `gorm:"primaryKey"`
-	Name      string `gorm:"unique;not null"`
-	CreatedAt time.Time
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"unique;not null"`
+	Kind        string `gorm:"not null;default:public;index"`
+	Topic       string
+	Description string `gorm:"type:text"`
+	AvatarURL   string
+	CreatedAt   time.Time
+	// DirectKey identifies a direct room by its two members, "<lower id>:<higher id>".
+	// It is nil for every other kind of room.
+	DirectKey *string `gorm:"uniqueIndex" json:"-"`
 }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// IncomingWebhook lets external systems post messages into a room through a secret URL.
+// Messages are posted by a bot user that belongs to the webhook. Only the SHA-256 of the
+// token in the URL is stored.
+type IncomingWebhook struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ChatRoomID uint       `gorm:"not null;index" json:"chat_room_id"`
+	CreatedBy  uint       `gorm:"not null" json:"created_by"`
+	BotID      uint       `gorm:"not null" json:"bot_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"uniqueIndex;not null;size:64" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
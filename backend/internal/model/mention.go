@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Mention kinds: a user named with @username, or every member through @room.
+const (
+	MentionUser = "user"
+	MentionRoom = "room"
+)
+
+// Mention is an entry in a user's notification inbox: a message that mentioned them.
+type Mention struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	ChatRoomID uint       `gorm:"not null" json:"chat_room_id"`
+	MessageID  uint       `gorm:"not null;index" json:"message_id"`
+	AuthorID   uint       `gorm:"not null" json:"author_id"`
+	Kind       string     `gorm:"not null;size:8" json:"kind"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Message *Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"message,omitempty"`
+}
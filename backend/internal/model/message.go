@@ -10,5 +10,14 @@ type Message struct {
 	Content   string `gorm:"type:text;not null"`
 	UserID    uint   `gorm:"not null"` // Foreign key to User
 	RoomID    uint   `gorm:"not null"` // Foreign key to ChatRoom
+	ParentID  *uint  `gorm:"index"`    // Root message of the thread this message replies to
+	Edited    bool   `gorm:"default:false"`
+	EditedAt  *time.Time
 	CreatedAt time.Time
+
+	ReplyCount  int `gorm:"default:0"`
+	LastReplyAt *time.Time
+
+	Reactions   []ReactionCount `gorm:"-" json:",omitempty"`
+	Attachments []Attachment    `gorm:"-" json:",omitempty"`
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// MessageReaction is an emoji reaction a user left on a message
+type MessageReaction struct {
+	ID        uint   `gorm:"primaryKey"`
+	MessageID uint   `gorm:"not null;uniqueIndex:idx_reaction"` // Foreign key to Message
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_reaction"` // Foreign key to User
+	Emoji     string `gorm:"not null;size:64;uniqueIndex:idx_reaction"`
+	CreatedAt time.Time
+}
+
+// ReactionCount aggregates reactions of a message by emoji
+type ReactionCount struct {
+	MessageID uint `json:"-"`
+	Emoji     string
+	Count     int64
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// MessageRevision keeps the content a message had before an edit
+type MessageRevision struct {
+	ID        uint   `gorm:"primaryKey"`
+	MessageID uint   `gorm:"not null;index"` // Foreign key to Message
+	Content   string `gorm:"type:text;not null"`
+	EditedBy  uint   `gorm:"not null"` // Foreign key to User
+	CreatedAt time.Time
+}
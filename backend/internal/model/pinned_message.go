@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// PinnedMessage is a message pinned to the top of its room by a room admin.
+type PinnedMessage struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ChatRoomID uint      `gorm:"not null;index" json:"chat_room_id"`
+	MessageID  uint      `gorm:"not null;uniqueIndex" json:"message_id"`
+	PinnedByID uint      `gorm:"not null" json:"pinned_by"`
+	CreatedAt  time.Time `json:"pinned_at"`
+
+	Message *Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"message,omitempty"`
+}
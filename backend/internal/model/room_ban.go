@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// RoomBan keeps a user from being added back to a chat room
+type RoomBan struct {
+	ID         uint `gorm:"primaryKey"`
+	ChatRoomID uint `gorm:"not null;uniqueIndex:idx_room_ban"`
+	UserID     uint `gorm:"not null;uniqueIndex:idx_room_ban"`
+	BannedBy   uint `gorm:"not null"`
+	CreatedAt  time.Time
+}
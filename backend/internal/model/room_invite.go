@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// RoomInvite is a shareable token that lets users join a chat room
+type RoomInvite struct {
+	ID         uint   `gorm:"primaryKey"`
+	Token      string `gorm:"uniqueIndex;not null;size:64"`
+	ChatRoomID uint   `gorm:"not null;index"`
+	CreatedBy  uint   `gorm:"not null"`
+	ExpiresAt  time.Time
+	MaxUses    int  `gorm:"default:0"` // 0 means unlimited
+	Uses       int  `gorm:"default:0"`
+	Revoked    bool `gorm:"default:false"`
+	CreatedAt  time.Time
+}
+
+// Usable reports whether the invite can still be accepted at now.
+func (i *RoomInvite) Usable(now time.Time) bool {
+	if i.Revoked || !now.Before(i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// InvitePreview is what a user sees of an invite before accepting it
+type InvitePreview struct {
+	ChatRoom  ChatRoom
+	ExpiresAt time.Time
+	UsesLeft  int `json:",omitempty"` // 0 when the invite has no usage limit
+}
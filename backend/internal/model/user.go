@@ -12,5 +12,9 @@ type User struct {
 	Password  string    `json:"password,omitempty" binding:"required"`
 	CreatedAt time.Time `json:"created_at"`
 
+	EmailVerified bool `gorm:"default:false" json:"email_verified"`
+	IsAdmin       bool `gorm:"default:false" json:"is_admin"`
+	IsBot         bool `gorm:"default:false" json:"is_bot"`
+
 	Sessions []UserSession `gorm:"foreignKey:UserID" json:"sessions,omitempty"`
 }
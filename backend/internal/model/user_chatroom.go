@@ -4,9 +4,25 @@ import (
 	"time"
 )
 
+// Membership roles, from most to least privileged
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
 type UserChatRoom struct {
-	ID         uint `gorm:"primaryKey"`
-	UserID     uint `gorm:"not null;index"`
-	ChatRoomID uint `gorm:"not null;index"`
-	JoinedAt   time.Time
+	ID                uint `gorm:"primaryKey"`
+	UserID            uint `gorm:"not null;index"`
+	ChatRoomID        uint `gorm:"not null;index"`
+	JoinedAt          time.Time
+	LastReadMessageID uint   `gorm:"default:0"`
+	Role              string `gorm:"not null;default:member"`
+	Muted             bool   `gorm:"not null;default:false"`
+}
+
+// SubscribedChatRoom is a chat room as seen by one of its members
+type SubscribedChatRoom struct {
+	ChatRoom
+	UnreadCount int64
 }
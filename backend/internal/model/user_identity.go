@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Issuer    string `gorm:"not null;uniqueIndex:idx_identity_subject"`
+	Subject   string `gorm:"not null;uniqueIndex:idx_identity_subject"`
+	Email     string
+	CreatedAt time.Time
+}
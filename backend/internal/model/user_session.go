@@ -11,7 +11,8 @@ type UserSession struct {
 	UserID         uint   `gorm:"index;not null"`       // FK to User
 	SessionID      string `gorm:"uniqueIndex;not null"` // JWT session ID
 	SessionVersion int    `gorm:"default:1"`
-	DeviceInfo     string
+	DeviceInfo     string // user agent of the client that logged in
+	IPAddress      string
 	RefreshToken   string
 	Revoked        bool `gorm:"default:false"`
 	CreatedAt      time.Time
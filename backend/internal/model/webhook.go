@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Room events a Webhook can subscribe to. They match the msg_type of events on the notification topic.
+var WebhookEvents = []string{"message", "edit", "join", "leave", "kicked", "reaction_add", "reaction_remove"}
+
+// Statuses of a WebhookDelivery
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook posts the events of a room to an external URL.
+type Webhook struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ChatRoomID uint       `gorm:"not null;index" json:"chat_room_id"`
+	CreatedBy  uint       `gorm:"not null" json:"created_by"`
+	URL        string     `gorm:"not null" json:"url"`
+	Secret     string     `gorm:"not null" json:"-"`
+	Events     string     `gorm:"not null" json:"events"` // space separated
+	Enabled    bool       `gorm:"not null;default:true" json:"enabled"`
+	Failures   int        `gorm:"not null;default:0" json:"failures"` // failed deliveries in a row
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants events of type eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range strings.Fields(w.Events) {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a Webhook.
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	WebhookID     uint       `gorm:"not null;index" json:"webhook_id"`
+	Event         string     `gorm:"not null" json:"event"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"not null;default:pending;index:idx_delivery_due,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `gorm:"index:idx_delivery_due,priority:2" json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,67 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/oidc"
+	"backend/internal/oidc/oidctest"
+)
+
+func codeFor(t *testing.T, p *oidc.Provider, verifier string) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), "state", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "state", location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	idp, err := oidctest.NewIdP("client")
+	require.NoError(t, err)
+	defer idp.Close()
+
+	p := oidc.NewProvider(oidc.Config{Issuer: idp.Issuer(), ClientID: "client", RedirectURL: "http://rp.test/cb"})
+
+	t.Run("valid code and verifier", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+		token, err := p.Exchange(context.Background(), codeFor(t, p, verifier), verifier)
+		require.NoError(t, err)
+		require.Equal(t, "user-1", token.Subject)
+		require.Equal(t, "nonce-1", token.Nonce)
+		require.Equal(t, idp.Issuer(), token.Issuer)
+	})
+
+	t.Run("wrong verifier is rejected", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+		_, err = p.Exchange(context.Background(), codeFor(t, p, verifier), "not-the-verifier")
+		require.ErrorContains(t, err, "PKCE")
+	})
+
+	t.Run("tokens for another client are rejected", func(t *testing.T) {
+		other := oidc.NewProvider(oidc.Config{Issuer: idp.Issuer(), ClientID: "client", RedirectURL: "http://rp.test/cb"})
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+		code := codeFor(t, other, verifier)
+
+		wrongAudience := oidc.NewProvider(oidc.Config{Issuer: idp.Issuer(), ClientID: "someone-else", RedirectURL: "http://rp.test/cb"})
+		_, err = wrongAudience.Exchange(context.Background(), code, verifier)
+		require.Error(t, err)
+	})
+}
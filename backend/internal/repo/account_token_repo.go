@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+// AccountTokenRepo defines persistence for email verification and password reset tokens.
+type AccountTokenRepo interface {
+	Create(token *model.AccountToken) error
+	GetByHash(purpose, hash string) (*model.AccountToken, error)
+	Consume(id uint) (rowsAffected int64, err error)
+	InvalidateForUser(userID uint, purpose string) error
+}
+
+type accountTokenRepo struct {
+	db gormDB
+}
+
+// NewAccountTokenRepo returns a GORM-backed AccountTokenRepo.
+func NewAccountTokenRepo(db gormDB) AccountTokenRepo {
+	return &accountTokenRepo{db: db}
+}
+
+func (r *accountTokenRepo) Create(token *model.AccountToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *accountTokenRepo) GetByHash(purpose, hash string) (*model.AccountToken, error) {
+	var token model.AccountToken
+	if err := r.db.Where("purpose = ? AND token_hash = ?", purpose, hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume marks the token used unless it already was, so a token can only be redeemed once.
+func (r *accountTokenRepo) Consume(id uint) (int64, error) {
+	res := r.db.Model(&model.AccountToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// InvalidateForUser marks every unused token of userID for purpose as used.
+func (r *accountTokenRepo) InvalidateForUser(userID uint, purpose string) error {
+	return r.db.Model(&model.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
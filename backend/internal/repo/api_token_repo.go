@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+// APITokenRepo defines persistence for bot API tokens.
+type APITokenRepo interface {
+	Create(token *model.APIToken) error
+	GetByID(id uint) (*model.APIToken, error)
+	GetByHash(hash string) (*model.APIToken, error)
+	ListByUserID(userID uint) ([]model.APIToken, error)
+	Revoke(id uint) error
+	Touch(id uint, at time.Time) error
+}
+
+type apiTokenRepo struct {
+	db gormDB
+}
+
+// NewAPITokenRepo returns a GORM-backed APITokenRepo.
+func NewAPITokenRepo(db gormDB) APITokenRepo {
+	return &apiTokenRepo{db: db}
+}
+
+func (r *apiTokenRepo) Create(token *model.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *apiTokenRepo) GetByID(id uint) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := r.db.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepo) GetByHash(hash string) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepo) ListByUserID(userID uint) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *apiTokenRepo) Revoke(id uint) error {
+	return r.db.Model(&model.APIToken{}).Where("id = ?", id).Update("revoked", true).Error
+}
+
+func (r *apiTokenRepo) Touch(id uint, at time.Time) error {
+	return r.db.Model(&model.APIToken{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"backend/internal/model"
+)
+
+// AttachmentRepo defines persistence for message attachments.
+type AttachmentRepo interface {
+	Create(attachment *model.Attachment) error
+	Update(attachment *model.Attachment) error
+	GetByID(id uint) (*model.Attachment, error)
+	ListByIDs(ids []uint) ([]model.Attachment, error)
+	ListByMessageIDs(messageIDs []uint) ([]model.Attachment, error)
+	// LinkToMessage sets the message of the attachments in ids that do not have one yet.
+	LinkToMessage(ids []uint, messageID uint) (rowsAffected int64, err error)
+	DeleteByMessageID(messageID uint) error
+}
+
+type attachmentRepo struct {
+	db gormDB
+}
+
+// NewAttachmentRepo returns a GORM-backed AttachmentRepo.
+func NewAttachmentRepo(db gormDB) AttachmentRepo {
+	return &attachmentRepo{db: db}
+}
+
+func (r *attachmentRepo) Create(attachment *model.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *attachmentRepo) Update(attachment *model.Attachment) error {
+	return r.db.Save(attachment).Error
+}
+
+func (r *attachmentRepo) GetByID(id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := r.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepo) ListByIDs(ids []uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if len(ids) == 0 {
+		return attachments, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepo) ListByMessageIDs(messageIDs []uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+	err := r.db.Where("message_id IN ?", messageIDs).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepo) LinkToMessage(ids []uint, messageID uint) (int64, error) {
+	res := r.db.Model(&model.Attachment{}).Where("id IN ? AND message_id IS NULL", ids).Update("message_id", messageID)
+	return res.RowsAffected, res.Error
+}
+
+func (r *attachmentRepo) DeleteByMessageID(messageID uint) error {
+	return r.db.Where("message_id = ?", messageID).Delete(&model.Attachment{}).Error
+}
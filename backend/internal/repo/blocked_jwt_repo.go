@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+// BlockedJWTRepo defines persistence for revoked access tokens.
+type BlockedJWTRepo interface {
+	Create(blocked *model.BlockedJWT) error
+	GetActive(jti string) (*model.BlockedJWT, error)
+	ListActive() ([]model.BlockedJWT, error)
+	DeleteExpired() error
+}
+
+type blockedJWTRepo struct {
+	db gormDB
+}
+
+// NewBlockedJWTRepo returns a GORM-backed BlockedJWTRepo.
+func NewBlockedJWTRepo(db gormDB) BlockedJWTRepo {
+	return &blockedJWTRepo{db: db}
+}
+
+// Create records a revoked token; revoking the same JTI twice is a no-op.
+func (r *blockedJWTRepo) Create(blocked *model.BlockedJWT) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(blocked).Error
+}
+
+// GetActive returns the revocation of jti unless the token has expired anyway.
+func (r *blockedJWTRepo) GetActive(jti string) (*model.BlockedJWT, error) {
+	var blocked model.BlockedJWT
+	if err := r.db.Where("jti = ? AND expires_at > ?", jti, time.Now()).First(&blocked).Error; err != nil {
+		return nil, err
+	}
+	return &blocked, nil
+}
+
+func (r *blockedJWTRepo) ListActive() ([]model.BlockedJWT, error) {
+	var blocked []model.BlockedJWT
+	err := r.db.Where("expires_at > ?", time.Now()).Find(&blocked).Error
+	return blocked, err
+}
+
+func (r *blockedJWTRepo) DeleteExpired() error {
+	return r.db.Where("expires_at <= ?", time.Now()).Delete(&model.BlockedJWT{}).Error
+}
@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"backend/internal/model"
+)
+
+// BotCommandRepo defines persistence for slash commands served by bots.
+type BotCommandRepo interface {
+	Create(cmd *model.BotCommand) error
+	GetByName(name string) (*model.BotCommand, error)
+	ListByBotID(botID uint) ([]model.BotCommand, error)
+	ListForChatRoom(chatRoomID uint) ([]model.BotCommand, error)
+	Delete(botID uint, name string) (rowsAffected int64, err error)
+}
+
+type botCommandRepo struct {
+	db gormDB
+}
+
+// NewBotCommandRepo returns a GORM-backed BotCommandRepo.
+func NewBotCommandRepo(db gormDB) BotCommandRepo {
+	return &botCommandRepo{db: db}
+}
+
+func (r *botCommandRepo) Create(cmd *model.BotCommand) error {
+	return r.db.Create(cmd).Error
+}
+
+func (r *botCommandRepo) GetByName(name string) (*model.BotCommand, error) {
+	var cmd model.BotCommand
+	if err := r.db.Where("name = ?", name).First(&cmd).Error; err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+func (r *botCommandRepo) ListByBotID(botID uint) ([]model.BotCommand, error) {
+	var cmds []model.BotCommand
+	if err := r.db.Where("bot_id = ?", botID).Order("name").Find(&cmds).Error; err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+// ListForChatRoom returns the commands of the bots that are members of chatRoomID.
+func (r *botCommandRepo) ListForChatRoom(chatRoomID uint) ([]model.BotCommand, error) {
+	var cmds []model.BotCommand
+	err := r.db.Joins("JOIN user_chat_rooms ON user_chat_rooms.user_id = bot_commands.bot_id").
+		Where("user_chat_rooms.chat_room_id = ?", chatRoomID).
+		Order("bot_commands.name").
+		Find(&cmds).Error
+	if err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+func (r *botCommandRepo) Delete(botID uint, name string) (int64, error) {
+	res := r.db.Where("bot_id = ? AND name = ?", botID, name).Delete(&model.BotCommand{})
+	return res.RowsAffected, res.Error
+}
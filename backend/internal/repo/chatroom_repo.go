@@ -1,7 +1,10 @@
 package repo
 
 import (
+	"time"
+
 	"backend/internal/model"
+	"gorm.io/gorm"
 )
 
 // ChatRoomRepo defines persistence for chat rooms.
@@ -9,10 +12,14 @@ type ChatRoomRepo interface {
 	Create(room *model.ChatRoom) error
 	GetByID(id uint) (*model.ChatRoom, error)
 	GetByName(name string) (*model.ChatRoom, error)
-	GetAll() ([]model.ChatRoom, error)
+	GetDirect(key string) (*model.ChatRoom, error)
+	GetAllVisibleTo(userID uint) ([]model.ChatRoom, error)
+	GetVisibleTo(id, userID uint) (*model.ChatRoom, error)
 	Delete(id uint) error
-	SearchByName(keyword string) ([]model.ChatRoom, error)
+	SearchVisibleByName(userID uint, keyword string) ([]model.ChatRoom, error)
 	ExistsByID(id uint) (bool, error)
+	CreateWithMembers(room *model.ChatRoom, members []model.UserChatRoom) error
+	UpdateDetails(id uint, fields map[string]interface{}) error
 }
 
 type chatRoomRepo struct {
@@ -44,22 +51,40 @@ func (r *chatRoomRepo) GetByName(name string) (*model.ChatRoom, error) {
 	return &room, nil
 }
 
-func (r *chatRoomRepo) GetAll() ([]model.ChatRoom, error) {
+// GetDirect returns the direct room with the given DirectKey.
+func (r *chatRoomRepo) GetDirect(key string) (*model.ChatRoom, error) {
+	var room model.ChatRoom
+	if err := r.db.Where("kind = ? AND direct_key = ?", model.ChatRoomDirect, key).First(&room).Error; err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *chatRoomRepo) GetAllVisibleTo(userID uint) ([]model.ChatRoom, error) {
 	var rooms []model.ChatRoom
-	if err := r.db.Find(&rooms).Error; err != nil {
+	if err := r.visibleTo(userID).Find(&rooms).Error; err != nil {
 		return nil, err
 	}
 	return rooms, nil
 }
 
+// GetVisibleTo returns room id if it is public or userID is a member of it.
+func (r *chatRoomRepo) GetVisibleTo(id, userID uint) (*model.ChatRoom, error) {
+	var room model.ChatRoom
+	if err := r.visibleTo(userID).Where("id = ?", id).First(&room).Error; err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
 func (r *chatRoomRepo) Delete(id uint) error {
 	return r.db.Delete(&model.ChatRoom{}, id).Error
 }
 
-func (r *chatRoomRepo) SearchByName(keyword string) ([]model.ChatRoom, error) {
+func (r *chatRoomRepo) SearchVisibleByName(userID uint, keyword string) ([]model.ChatRoom, error) {
 	var rooms []model.ChatRoom
 	pattern := "%" + keyword + "%"
-	if err := r.db.Where("name LIKE ?", pattern).Find(&rooms).Error; err != nil {
+	if err := r.visibleTo(userID).Where("name LIKE ?", pattern).Find(&rooms).Error; err != nil {
 		return nil, err
 	}
 	return rooms, nil
@@ -73,3 +98,35 @@ func (r *chatRoomRepo) ExistsByID(id uint) (bool, error) {
 		Scan(&exists).Error
 	return exists, err
 }
+
+// CreateWithMembers stores room and its initial members in one transaction.
+func (r *chatRoomRepo) CreateWithMembers(room *model.ChatRoom, members []model.UserChatRoom) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range members {
+			members[i].ChatRoomID = room.ID
+			members[i].JoinedAt = now
+			if err := tx.Create(&members[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// visibleTo limits a query to public rooms and rooms userID is a member of.
+func (r *chatRoomRepo) visibleTo(userID uint) *gorm.DB {
+	memberOf := r.db.Model(&model.UserChatRoom{}).Select("chat_room_id").Where("user_id = ?", userID)
+	return r.db.Where("(kind = ? OR id IN (?))", model.ChatRoomPublic, memberOf)
+}
+
+// UpdateDetails sets the given columns of a room; columns left out are unchanged.
+func (r *chatRoomRepo) UpdateDetails(id uint, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.ChatRoom{}).Where("id = ?", id).Updates(fields).Error
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// IncomingWebhookRepo defines persistence for incoming webhooks.
+type IncomingWebhookRepo interface {
+	CreateWithBot(bot *model.User, hook *model.IncomingWebhook) error
+	GetByID(id uint) (*model.IncomingWebhook, error)
+	GetByHash(hash string) (*model.IncomingWebhook, error)
+	ListByChatRoomID(chatRoomID uint) ([]model.IncomingWebhook, error)
+	Delete(hook *model.IncomingWebhook) error
+	Touch(id uint, at time.Time) error
+}
+
+type incomingWebhookRepo struct {
+	db gormDB
+}
+
+// NewIncomingWebhookRepo returns a GORM-backed IncomingWebhookRepo.
+func NewIncomingWebhookRepo(db gormDB) IncomingWebhookRepo {
+	return &incomingWebhookRepo{db: db}
+}
+
+// CreateWithBot creates the webhook together with its bot user and adds the bot to the room.
+func (r *incomingWebhookRepo) CreateWithBot(bot *model.User, hook *model.IncomingWebhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bot).Error; err != nil {
+			return err
+		}
+		membership := &model.UserChatRoom{
+			UserID:     bot.ID,
+			ChatRoomID: hook.ChatRoomID,
+			JoinedAt:   time.Now(),
+			Role:       model.RoleMember,
+		}
+		if err := tx.Create(membership).Error; err != nil {
+			return err
+		}
+		hook.BotID = bot.ID
+		return tx.Create(hook).Error
+	})
+}
+
+func (r *incomingWebhookRepo) GetByID(id uint) (*model.IncomingWebhook, error) {
+	var hook model.IncomingWebhook
+	if err := r.db.First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *incomingWebhookRepo) GetByHash(hash string) (*model.IncomingWebhook, error) {
+	var hook model.IncomingWebhook
+	if err := r.db.Where("token_hash = ?", hash).First(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *incomingWebhookRepo) ListByChatRoomID(chatRoomID uint) ([]model.IncomingWebhook, error) {
+	var hooks []model.IncomingWebhook
+	if err := r.db.Where("chat_room_id = ?", chatRoomID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Delete removes the webhook and its bot's membership. The bot user stays, so the messages it
+// posted keep their author.
+func (r *incomingWebhookRepo) Delete(hook *model.IncomingWebhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND chat_room_id = ?", hook.BotID, hook.ChatRoomID).
+			Delete(&model.UserChatRoom{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.IncomingWebhook{}, hook.ID).Error
+	})
+}
+
+func (r *incomingWebhookRepo) Touch(id uint, at time.Time) error {
+	return r.db.Model(&model.IncomingWebhook{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
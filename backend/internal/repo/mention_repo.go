@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+// MentionRepo defines persistence for the mentions in users' notification inboxes.
+type MentionRepo interface {
+	CreateBatch(mentions []model.Mention) error
+	GetByID(id uint) (*model.Mention, error)
+	ListByUserID(userID, beforeID uint, limit int, unreadOnly bool) ([]model.Mention, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID, id uint, at time.Time) (rowsAffected int64, err error)
+	MarkAllRead(userID uint, at time.Time) (rowsAffected int64, err error)
+}
+
+type mentionRepo struct {
+	db gormDB
+}
+
+// NewMentionRepo returns a GORM-backed MentionRepo.
+func NewMentionRepo(db gormDB) MentionRepo {
+	return &mentionRepo{db: db}
+}
+
+func (r *mentionRepo) CreateBatch(mentions []model.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	return r.db.Create(&mentions).Error
+}
+
+func (r *mentionRepo) GetByID(id uint) (*model.Mention, error) {
+	var mention model.Mention
+	if err := r.db.First(&mention, id).Error; err != nil {
+		return nil, err
+	}
+	return &mention, nil
+}
+
+// ListByUserID returns userID's mentions newest first, with their messages. beforeID of 0
+// starts from the newest.
+func (r *mentionRepo) ListByUserID(userID, beforeID uint, limit int, unreadOnly bool) ([]model.Mention, error) {
+	query := r.db.Preload("Message").Where("user_id = ?", userID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var mentions []model.Mention
+	if err := query.Order("id DESC").Limit(limit).Find(&mentions).Error; err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
+
+func (r *mentionRepo) CountUnread(userID uint) (int64, error) {
+	var n int64
+	err := r.db.Model(&model.Mention{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *mentionRepo) MarkRead(userID, id uint, at time.Time) (int64, error) {
+	res := r.db.Model(&model.Mention{}).Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r *mentionRepo) MarkAllRead(userID uint, at time.Time) (int64, error) {
+	res := r.db.Model(&model.Mention{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
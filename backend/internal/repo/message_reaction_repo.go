@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"backend/internal/model"
+)
+
+// MessageReactionRepo defines persistence for message reactions.
+type MessageReactionRepo interface {
+	Create(reaction *model.MessageReaction) error
+	Exists(messageID, userID uint, emoji string) (bool, error)
+	Delete(messageID, userID uint, emoji string) (rowsAffected int64, err error)
+	GetByMessageID(messageID uint) ([]model.MessageReaction, error)
+	CountByMessageIDs(messageIDs []uint) ([]model.ReactionCount, error)
+}
+
+type messageReactionRepo struct {
+	db gormDB
+}
+
+// NewMessageReactionRepo returns a GORM-backed MessageReactionRepo.
+func NewMessageReactionRepo(db gormDB) MessageReactionRepo {
+	return &messageReactionRepo{db: db}
+}
+
+func (r *messageReactionRepo) Create(reaction *model.MessageReaction) error {
+	return r.db.Create(reaction).Error
+}
+
+func (r *messageReactionRepo) Exists(messageID, userID uint, emoji string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.MessageReaction{}).
+		Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *messageReactionRepo) Delete(messageID, userID uint, emoji string) (int64, error) {
+	res := r.db.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Delete(&model.MessageReaction{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *messageReactionRepo) GetByMessageID(messageID uint) ([]model.MessageReaction, error) {
+	var reactions []model.MessageReaction
+	err := r.db.Where("message_id = ?", messageID).Order("id asc").Find(&reactions).Error
+	return reactions, err
+}
+
+func (r *messageReactionRepo) CountByMessageIDs(messageIDs []uint) ([]model.ReactionCount, error) {
+	var counts []model.ReactionCount
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+	err := r.db.Model(&model.MessageReaction{}).
+		Select("message_id, emoji, count(*) AS count").
+		Where("message_id IN ?", messageIDs).
+		Group("message_id, emoji").
+		Order("message_id asc, min(id) asc").
+		Scan(&counts).Error
+	return counts, err
+}
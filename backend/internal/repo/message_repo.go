@@ -1,6 +1,9 @@
 package repo
 
 import (
+	"errors"
+	"time"
+
 	"backend/internal/model"
 	"gorm.io/gorm"
 )
@@ -8,10 +11,14 @@ import (
 // MessageRepo defines persistence for messages.
 type MessageRepo interface {
 	Create(msg *model.Message) error
+	GetByID(id uint) (*model.Message, error)
 	GetByRoomID(roomID uint) ([]model.Message, error)
 	GetByRoomIDWithLimit(roomID uint, limit int) ([]model.Message, error)
 	GetByRoomIDBeforeWithLimit(roomID uint, beforeID uint, limit int) ([]model.Message, error)
-	Delete(id uint) (rowsAffected int64, err error)
+	GetRepliesBeforeWithLimit(parentID uint, beforeID uint, limit int) ([]model.Message, error)
+	GetLatestIDByRoomID(roomID uint) (uint, error)
+	Delete(id uint) (deletedIDs []uint, err error)
+	Edit(msg *model.Message, content string, editedBy uint) error
 }
 
 type messageRepo struct {
@@ -23,13 +30,36 @@ func NewMessageRepo(db *gorm.DB) MessageRepo {
 	return &messageRepo{db: db}
 }
 
+// Create stores msg. Replies also bump the reply count and last-reply time of their root message.
 func (r *messageRepo) Create(msg *model.Message) error {
-	return r.db.Create(msg).Error
+	if msg.ParentID == nil {
+		return r.db.Create(msg).Error
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Message{}).
+			Where("id = ?", *msg.ParentID).
+			Updates(map[string]interface{}{
+				"reply_count":   gorm.Expr("reply_count + 1"),
+				"last_reply_at": msg.CreatedAt,
+			}).Error
+	})
+}
+
+func (r *messageRepo) GetByID(id uint) (*model.Message, error) {
+	var msg model.Message
+	if err := r.db.First(&msg, id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
 }
 
 func (r *messageRepo) GetByRoomID(roomID uint) ([]model.Message, error) {
 	var messages []model.Message
-	if err := r.db.Where("room_id = ?", roomID).Order("created_at asc").Find(&messages).Error; err != nil {
+	if err := r.db.Where("room_id = ? AND parent_id IS NULL", roomID).Order("created_at asc").Find(&messages).Error; err != nil {
 		return nil, err
 	}
 	return messages, nil
@@ -37,13 +67,30 @@ func (r *messageRepo) GetByRoomID(roomID uint) ([]model.Message, error) {
 
 func (r *messageRepo) GetByRoomIDWithLimit(roomID uint, limit int) ([]model.Message, error) {
 	var messages []model.Message
-	err := r.db.Where("room_id = ?", roomID).Order("created_at desc").Limit(limit).Find(&messages).Error
+	err := r.db.Where("room_id = ? AND parent_id IS NULL", roomID).Order("created_at desc").Limit(limit).Find(&messages).Error
 	return messages, err
 }
 
 func (r *messageRepo) GetByRoomIDBeforeWithLimit(roomID uint, beforeID uint, limit int) ([]model.Message, error) {
+	return r.pageBefore(r.db.Where("room_id = ? AND parent_id IS NULL", roomID), beforeID, limit)
+}
+
+func (r *messageRepo) GetRepliesBeforeWithLimit(parentID uint, beforeID uint, limit int) ([]model.Message, error) {
+	return r.pageBefore(r.db.Where("parent_id = ?", parentID), beforeID, limit)
+}
+
+func (r *messageRepo) GetLatestIDByRoomID(roomID uint) (uint, error) {
+	var latestID uint
+	err := r.db.Model(&model.Message{}).
+		Select("COALESCE(MAX(id), 0)").
+		Where("room_id = ?", roomID).
+		Scan(&latestID).Error
+	return latestID, err
+}
+
+// pageBefore returns up to limit messages of query older than beforeID, oldest first.
+func (r *messageRepo) pageBefore(query *gorm.DB, beforeID uint, limit int) ([]model.Message, error) {
 	var messages []model.Message
-	query := r.db.Where("room_id = ?", roomID)
 	if beforeID > 0 {
 		query = query.Where("id < ?", beforeID)
 	}
@@ -57,7 +104,73 @@ func (r *messageRepo) GetByRoomIDBeforeWithLimit(roomID uint, beforeID uint, lim
 	return messages, nil
 }
 
-func (r *messageRepo) Delete(id uint) (int64, error) {
-	res := r.db.Delete(&model.Message{}, id)
-	return res.RowsAffected, res.Error
+// Delete removes message id and returns the ids of the messages it removed, none when id does
+// not exist. Deleting a thread root deletes its replies with it; deleting a reply takes it off
+// its root's reply count.
+func (r *messageRepo) Delete(id uint) ([]uint, error) {
+	var deleted []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var msg model.Message
+		if err := tx.First(&msg, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if msg.ParentID == nil {
+			var replyIDs []uint
+			if err := tx.Model(&model.Message{}).Where("parent_id = ?", id).Order("id").Pluck("id", &replyIDs).Error; err != nil {
+				return err
+			}
+			if len(replyIDs) > 0 {
+				if err := tx.Delete(&model.Message{}, replyIDs).Error; err != nil {
+					return err
+				}
+			}
+			deleted = append(replyIDs, id)
+			return tx.Delete(&model.Message{}, id).Error
+		}
+
+		if err := tx.Delete(&model.Message{}, id).Error; err != nil {
+			return err
+		}
+		deleted = []uint{id}
+		lastReply := tx.Model(&model.Message{}).Select("MAX(created_at)").Where("parent_id = ?", *msg.ParentID)
+		return tx.Model(&model.Message{}).
+			Where("id = ? AND reply_count > 0", *msg.ParentID).
+			Updates(map[string]interface{}{
+				"reply_count":   gorm.Expr("reply_count - 1"),
+				"last_reply_at": lastReply,
+			}).Error
+	})
+	return deleted, err
+}
+
+// Edit stores the current content of msg as a revision and replaces it with content.
+func (r *messageRepo) Edit(msg *model.Message, content string, editedBy uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		revision := model.MessageRevision{
+			MessageID: msg.ID,
+			Content:   msg.Content,
+			EditedBy:  editedBy,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(msg).Updates(map[string]interface{}{
+			"content":   content,
+			"edited":    true,
+			"edited_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		msg.Content = content
+		msg.Edited = true
+		msg.EditedAt = &now
+		return nil
+	})
 }
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"backend/internal/model"
+)
+
+// MessageRevisionRepo defines persistence for message revisions.
+type MessageRevisionRepo interface {
+	GetByMessageID(messageID uint) ([]model.MessageRevision, error)
+}
+
+type messageRevisionRepo struct {
+	db gormDB
+}
+
+// NewMessageRevisionRepo returns a GORM-backed MessageRevisionRepo.
+func NewMessageRevisionRepo(db gormDB) MessageRevisionRepo {
+	return &messageRevisionRepo{db: db}
+}
+
+func (r *messageRevisionRepo) GetByMessageID(messageID uint) ([]model.MessageRevision, error) {
+	var revisions []model.MessageRevision
+	err := r.db.Where("message_id = ?", messageID).Order("id asc").Find(&revisions).Error
+	return revisions, err
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"backend/internal/model"
+
+	"gorm.io/gorm/clause"
+)
+
+// PinnedMessageRepo defines persistence for the pinned messages of rooms.
+type PinnedMessageRepo interface {
+	Create(pin *model.PinnedMessage) (rowsAffected int64, err error)
+	Exists(messageID uint) (bool, error)
+	Delete(messageID uint) (rowsAffected int64, err error)
+	CountByChatRoomID(chatRoomID uint) (int64, error)
+	ListByChatRoomID(chatRoomID uint) ([]model.PinnedMessage, error)
+}
+
+type pinnedMessageRepo struct {
+	db gormDB
+}
+
+// NewPinnedMessageRepo returns a GORM-backed PinnedMessageRepo.
+func NewPinnedMessageRepo(db gormDB) PinnedMessageRepo {
+	return &pinnedMessageRepo{db: db}
+}
+
+// Create inserts pin unless its message is already pinned, in which case no row is affected.
+func (r *pinnedMessageRepo) Create(pin *model.PinnedMessage) (int64, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+	return res.RowsAffected, res.Error
+}
+
+func (r *pinnedMessageRepo) Exists(messageID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.PinnedMessage{}).Where("message_id = ?", messageID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *pinnedMessageRepo) Delete(messageID uint) (int64, error) {
+	res := r.db.Where("message_id = ?", messageID).Delete(&model.PinnedMessage{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *pinnedMessageRepo) CountByChatRoomID(chatRoomID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.PinnedMessage{}).Where("chat_room_id = ?", chatRoomID).Count(&count).Error
+	return count, err
+}
+
+// ListByChatRoomID returns the pins of a room newest first, with their messages.
+func (r *pinnedMessageRepo) ListByChatRoomID(chatRoomID uint) ([]model.PinnedMessage, error) {
+	var pins []model.PinnedMessage
+	err := r.db.Preload("Message").Where("chat_room_id = ?", chatRoomID).Order("id DESC").Find(&pins).Error
+	return pins, err
+}
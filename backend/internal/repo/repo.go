@@ -1,6 +1,11 @@
 package repo
 
-import "gorm.io/gorm"
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 // gormDB abstracts *gorm.DB for repo implementations.
 type gormDB interface {
@@ -16,9 +21,13 @@ type gormDB interface {
 	Joins(query string, args ...interface{}) *gorm.DB
 	Table(name string, args ...interface{}) *gorm.DB
 	Order(value interface{}) *gorm.DB
+	Preload(query string, args ...interface{}) *gorm.DB
+	Group(name string) *gorm.DB
 	Limit(limit int) *gorm.DB
 	Count(count *int64) *gorm.DB
 	Update(column string, value interface{}) *gorm.DB
+	Clauses(conds ...clause.Expression) *gorm.DB
+	Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error
 }
 
 // Ensure *gorm.DB implements gormDB.
@@ -9,6 +9,20 @@ type RepoContainer struct {
 	ChatRoom     ChatRoomRepo
 	UserChatRoom UserChatRoomRepo
 	Message      MessageRepo
+	Revision     MessageRevisionRepo
+	Reaction     MessageReactionRepo
+	RoomBan      RoomBanRepo
+	RoomInvite   RoomInviteRepo
+	BlockedJWT   BlockedJWTRepo
+	AccountToken AccountTokenRepo
+	UserIdentity UserIdentityRepo
+	APIToken     APITokenRepo
+	Webhook      WebhookRepo
+	IncomingHook IncomingWebhookRepo
+	BotCommand   BotCommandRepo
+	Mention      MentionRepo
+	Attachment   AttachmentRepo
+	Pin          PinnedMessageRepo
 }
 
 // NewRepoContainer creates a repo container with all repos backed by db.
@@ -19,5 +33,19 @@ func NewRepoContainer(db *gorm.DB) *RepoContainer {
 		ChatRoom:     NewChatRoomRepo(db),
 		UserChatRoom: NewUserChatRoomRepo(db),
 		Message:      NewMessageRepo(db),
+		Revision:     NewMessageRevisionRepo(db),
+		Reaction:     NewMessageReactionRepo(db),
+		RoomBan:      NewRoomBanRepo(db),
+		RoomInvite:   NewRoomInviteRepo(db),
+		BlockedJWT:   NewBlockedJWTRepo(db),
+		AccountToken: NewAccountTokenRepo(db),
+		UserIdentity: NewUserIdentityRepo(db),
+		APIToken:     NewAPITokenRepo(db),
+		Webhook:      NewWebhookRepo(db),
+		IncomingHook: NewIncomingWebhookRepo(db),
+		BotCommand:   NewBotCommandRepo(db),
+		Mention:      NewMentionRepo(db),
+		Attachment:   NewAttachmentRepo(db),
+		Pin:          NewPinnedMessageRepo(db),
 	}
 }
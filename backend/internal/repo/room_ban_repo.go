@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"backend/internal/model"
+)
+
+// RoomBanRepo defines persistence for chat room bans.
+type RoomBanRepo interface {
+	Create(ban *model.RoomBan) error
+	Exists(userID, chatRoomID uint) (bool, error)
+}
+
+type roomBanRepo struct {
+	db gormDB
+}
+
+// NewRoomBanRepo returns a GORM-backed RoomBanRepo.
+func NewRoomBanRepo(db gormDB) RoomBanRepo {
+	return &roomBanRepo{db: db}
+}
+
+func (r *roomBanRepo) Create(ban *model.RoomBan) error {
+	return r.db.Create(ban).Error
+}
+
+func (r *roomBanRepo) Exists(userID, chatRoomID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RoomBan{}).
+		Where("user_id = ? AND chat_room_id = ?", userID, chatRoomID).
+		Count(&count).Error
+	return count > 0, err
+}
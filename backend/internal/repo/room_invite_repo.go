@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// RoomInviteRepo defines persistence for chat room invites.
+type RoomInviteRepo interface {
+	Create(invite *model.RoomInvite) error
+	GetByToken(token string) (*model.RoomInvite, error)
+	Accept(id uint, membership *model.UserChatRoom) (rowsAffected int64, err error)
+	Revoke(id uint) error
+}
+
+type roomInviteRepo struct {
+	db gormDB
+}
+
+// NewRoomInviteRepo returns a GORM-backed RoomInviteRepo.
+func NewRoomInviteRepo(db gormDB) RoomInviteRepo {
+	return &roomInviteRepo{db: db}
+}
+
+func (r *roomInviteRepo) Create(invite *model.RoomInvite) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *roomInviteRepo) GetByToken(token string) (*model.RoomInvite, error) {
+	var invite model.RoomInvite
+	if err := r.db.Where("token = ?", token).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// Accept counts one use of the invite and stores membership in one transaction, so a failed join
+// does not use up the invite. Revoked or used up invites affect no rows, so concurrent accepts
+// cannot exceed MaxUses.
+func (r *roomInviteRepo) Accept(id uint, membership *model.UserChatRoom) (int64, error) {
+	var used int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.RoomInvite{}).
+			Where("id = ? AND revoked = ? AND (max_uses = 0 OR uses < max_uses)", id, false).
+			Update("uses", gorm.Expr("uses + 1"))
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		used = res.RowsAffected
+		return tx.Create(membership).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return used, nil
+}
+
+func (r *roomInviteRepo) Revoke(id uint) error {
+	return r.db.Model(&model.RoomInvite{}).Where("id = ?", id).Update("revoked", true).Error
+}
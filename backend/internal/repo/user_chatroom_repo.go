@@ -8,7 +8,15 @@ import (
 type UserChatRoomRepo interface {
 	Create(m *model.UserChatRoom) error
 	Exists(userID, chatRoomID uint) (bool, error)
+	Get(userID, chatRoomID uint) (*model.UserChatRoom, error)
+	UpdateRole(userID, chatRoomID uint, role string) error
+	Delete(userID, chatRoomID uint) (rowsAffected int64, err error)
 	GetChatRoomsByUserID(userID uint) ([]model.ChatRoom, error)
+	UpdateLastRead(userID, chatRoomID, messageID uint) error
+	CountUnreadByUserID(userID uint) (map[uint]int64, error)
+	ListUserIDs(chatRoomID uint) ([]uint, error)
+	ListMembers(chatRoomID uint) ([]model.UserChatRoom, error)
+	SetMuted(userID, chatRoomID uint, muted bool) (rowsAffected int64, err error)
 }
 
 type userChatRoomRepo struct {
@@ -32,6 +40,25 @@ func (r *userChatRoomRepo) Exists(userID, chatRoomID uint) (bool, error) {
 	return count > 0, err
 }
 
+func (r *userChatRoomRepo) Get(userID, chatRoomID uint) (*model.UserChatRoom, error) {
+	var m model.UserChatRoom
+	if err := r.db.Where("user_id = ? AND chat_room_id = ?", userID, chatRoomID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *userChatRoomRepo) UpdateRole(userID, chatRoomID uint, role string) error {
+	return r.db.Model(&model.UserChatRoom{}).
+		Where("user_id = ? AND chat_room_id = ?", userID, chatRoomID).
+		Update("role", role).Error
+}
+
+func (r *userChatRoomRepo) Delete(userID, chatRoomID uint) (int64, error) {
+	res := r.db.Where("user_id = ? AND chat_room_id = ?", userID, chatRoomID).Delete(&model.UserChatRoom{})
+	return res.RowsAffected, res.Error
+}
+
 func (r *userChatRoomRepo) GetChatRoomsByUserID(userID uint) ([]model.ChatRoom, error) {
 	var rooms []model.ChatRoom
 	err := r.db.Table("chat_rooms").
@@ -41,3 +68,63 @@ func (r *userChatRoomRepo) GetChatRoomsByUserID(userID uint) ([]model.ChatRoom,
 		Find(&rooms).Error
 	return rooms, err
 }
+
+// UpdateLastRead moves the read marker forward; an older messageID leaves it unchanged.
+func (r *userChatRoomRepo) UpdateLastRead(userID, chatRoomID, messageID uint) error {
+	return r.db.Model(&model.UserChatRoom{}).
+		Where("user_id = ? AND chat_room_id = ? AND last_read_message_id < ?", userID, chatRoomID, messageID).
+		Update("last_read_message_id", messageID).Error
+}
+
+// CountUnreadByUserID counts room messages from other users after the read marker, keyed by chat room ID.
+// Thread replies are left out; they are read from their thread, not the room timeline.
+func (r *userChatRoomRepo) CountUnreadByUserID(userID uint) (map[uint]int64, error) {
+	var rows []struct {
+		ChatRoomID uint
+		Unread     int64
+	}
+	err := r.db.Table("user_chat_rooms").
+		Select("user_chat_rooms.chat_room_id, count(messages.id) AS unread").
+		Joins("JOIN messages ON messages.room_id = user_chat_rooms.chat_room_id"+
+			" AND messages.id > user_chat_rooms.last_read_message_id"+
+			" AND messages.user_id <> user_chat_rooms.user_id"+
+			" AND messages.parent_id IS NULL").
+		Where("user_chat_rooms.user_id = ?", userID).
+		Group("user_chat_rooms.chat_room_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ChatRoomID] = row.Unread
+	}
+	return counts, nil
+}
+
+// ListUserIDs returns the ids of the members of chatRoomID.
+func (r *userChatRoomRepo) ListUserIDs(chatRoomID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.UserChatRoom{}).
+		Where("chat_room_id = ?", chatRoomID).
+		Order("user_id").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// ListMembers returns the memberships of chatRoomID.
+func (r *userChatRoomRepo) ListMembers(chatRoomID uint) ([]model.UserChatRoom, error) {
+	var members []model.UserChatRoom
+	if err := r.db.Where("chat_room_id = ?", chatRoomID).Order("user_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *userChatRoomRepo) SetMuted(userID, chatRoomID uint, muted bool) (int64, error) {
+	res := r.db.Model(&model.UserChatRoom{}).
+		Where("user_id = ? AND chat_room_id = ?", userID, chatRoomID).
+		Update("muted", muted)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepo defines persistence for links between users and identity provider accounts.
+type UserIdentityRepo interface {
+	Get(issuer, subject string) (*model.UserIdentity, error)
+	Create(identity *model.UserIdentity) error
+	CreateWithUser(user *model.User, identity *model.UserIdentity) error
+}
+
+type userIdentityRepo struct {
+	db gormDB
+}
+
+// NewUserIdentityRepo returns a GORM-backed UserIdentityRepo.
+func NewUserIdentityRepo(db gormDB) UserIdentityRepo {
+	return &userIdentityRepo{db: db}
+}
+
+func (r *userIdentityRepo) Get(issuer, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepo) Create(identity *model.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateWithUser stores a new user and its identity in one transaction.
+func (r *userIdentityRepo) CreateWithUser(user *model.User, identity *model.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
@@ -9,7 +9,12 @@ type UserRepo interface {
 	Create(user *model.User) error
 	GetByID(id uint) (*model.User, error)
 	GetByUsername(username string) (*model.User, error)
+	GetByEmail(email string) (*model.User, error)
 	GetAll() ([]model.User, error)
+	ListBots() ([]model.User, error)
+	UpdatePassword(id uint, hash string) error
+	MarkEmailVerified(id uint) error
+	SetAdmin(username string, admin bool) (rowsAffected int64, err error)
 }
 
 type userRepo struct {
@@ -41,6 +46,14 @@ func (r *userRepo) GetByUsername(username string) (*model.User, error) {
 	return &user, nil
 }
 
+func (r *userRepo) GetByEmail(email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepo) GetAll() ([]model.User, error) {
 	var users []model.User
 	if err := r.db.Find(&users).Error; err != nil {
@@ -48,3 +61,24 @@ func (r *userRepo) GetAll() ([]model.User, error) {
 	}
 	return users, nil
 }
+
+func (r *userRepo) ListBots() ([]model.User, error) {
+	var users []model.User
+	if err := r.db.Where("is_bot = ?", true).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepo) UpdatePassword(id uint, hash string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+func (r *userRepo) MarkEmailVerified(id uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("email_verified", true).Error
+}
+
+func (r *userRepo) SetAdmin(username string, admin bool) (int64, error) {
+	res := r.db.Model(&model.User{}).Where("username = ?", username).Update("is_admin", admin)
+	return res.RowsAffected, res.Error
+}
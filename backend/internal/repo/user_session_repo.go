@@ -1,13 +1,19 @@
 package repo
 
 import (
+	"time"
+
 	"backend/internal/model"
+	"gorm.io/gorm"
 )
 
 // UserSessionRepo defines persistence for user sessions.
 type UserSessionRepo interface {
 	Create(session *model.UserSession) error
 	FindValidSession(sessionID string, userID uint) (*model.UserSession, error)
+	GetBySessionID(sessionID string) (*model.UserSession, error)
+	ListActiveByUserID(userID uint) ([]model.UserSession, error)
+	RotateRefreshToken(id uint, oldHash, newHash string) (rowsAffected int64, err error)
 	RevokeAllByUserID(userID uint) error
 	RevokeOne(userID uint, sessionID string) error
 }
@@ -33,6 +39,36 @@ func (r *userSessionRepo) FindValidSession(sessionID string, userID uint) (*mode
 	return &session, nil
 }
 
+func (r *userSessionRepo) GetBySessionID(sessionID string) (*model.UserSession, error) {
+	var session model.UserSession
+	if err := r.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUserID returns the unrevoked, unexpired sessions of a user, most recently used first.
+func (r *userSessionRepo) ListActiveByUserID(userID uint) ([]model.UserSession, error) {
+	var sessions []model.UserSession
+	err := r.db.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RotateRefreshToken swaps the refresh token hash and bumps the session version, but only if the
+// session still holds oldHash, so two refreshes with the same token cannot both succeed.
+func (r *userSessionRepo) RotateRefreshToken(id uint, oldHash, newHash string) (int64, error) {
+	res := r.db.Model(&model.UserSession{}).
+		Where("id = ? AND refresh_token = ? AND revoked = ?", id, oldHash, false).
+		Updates(map[string]interface{}{
+			"refresh_token":   newHash,
+			"session_version": gorm.Expr("session_version + 1"),
+			"last_used_at":    time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
 func (r *userSessionRepo) RevokeAllByUserID(userID uint) error {
 	return r.db.Model(&model.UserSession{}).Where("user_id = ?", userID).Update("revoked", true).Error
 }
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"time"
+
+	"backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// WebhookRepo defines persistence for room webhooks and their deliveries.
+type WebhookRepo interface {
+	Create(hook *model.Webhook) error
+	GetByID(id uint) (*model.Webhook, error)
+	ListByChatRoomID(chatRoomID uint) ([]model.Webhook, error)
+	ListEnabledByChatRoomID(chatRoomID uint) ([]model.Webhook, error)
+	Delete(id uint) error
+	Enable(id uint) error
+	RecordSuccess(id uint) error
+	RecordFailure(id uint, disableAfter int) (disabled bool, err error)
+
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	GetDelivery(id uint) (*model.WebhookDelivery, error)
+	ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	ClaimDelivery(id uint, attempts int, until time.Time) (bool, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+}
+
+type webhookRepo struct {
+	db gormDB
+}
+
+// NewWebhookRepo returns a GORM-backed WebhookRepo.
+func NewWebhookRepo(db gormDB) WebhookRepo {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) Create(hook *model.Webhook) error {
+	return r.db.Create(hook).Error
+}
+
+func (r *webhookRepo) GetByID(id uint) (*model.Webhook, error) {
+	var hook model.Webhook
+	if err := r.db.First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepo) ListByChatRoomID(chatRoomID uint) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	if err := r.db.Where("chat_room_id = ?", chatRoomID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *webhookRepo) ListEnabledByChatRoomID(chatRoomID uint) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	if err := r.db.Where("chat_room_id = ? AND enabled = ?", chatRoomID, true).Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Delete removes the webhook together with its delivery log.
+func (r *webhookRepo) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, id).Error
+	})
+}
+
+func (r *webhookRepo) Enable(id uint) error {
+	return r.db.Model(&model.Webhook{}).Where("id = ?", id).Updates(map[string]any{
+		"enabled":     true,
+		"failures":    0,
+		"disabled_at": nil,
+	}).Error
+}
+
+func (r *webhookRepo) RecordSuccess(id uint) error {
+	return r.db.Model(&model.Webhook{}).Where("id = ? AND failures > 0", id).Update("failures", 0).Error
+}
+
+// RecordFailure counts a failed delivery and disables the webhook once disableAfter deliveries
+// in a row have failed.
+func (r *webhookRepo) RecordFailure(id uint, disableAfter int) (bool, error) {
+	if err := r.db.Model(&model.Webhook{}).Where("id = ?", id).
+		Update("failures", gorm.Expr("failures + 1")).Error; err != nil {
+		return false, err
+	}
+	res := r.db.Model(&model.Webhook{}).
+		Where("id = ? AND enabled = ? AND failures >= ?", id, true, disableAfter).
+		Updates(map[string]any{"enabled": false, "disabled_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *webhookRepo) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *webhookRepo) GetDelivery(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns the latest deliveries of webhookID, newest first.
+func (r *webhookRepo) ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	if err := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is due, oldest first.
+func (r *webhookRepo) ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	if err := r.db.Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery counts an attempt of a pending delivery and pushes its next attempt to until,
+// unless another worker already claimed attempt number attempts+1. It reports whether the
+// caller now owns the attempt.
+func (r *webhookRepo) ClaimDelivery(id uint, attempts int, until time.Time) (bool, error) {
+	res := r.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, model.DeliveryPending, attempts).
+		Updates(map[string]any{"attempts": attempts + 1, "next_attempt_at": until})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *webhookRepo) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
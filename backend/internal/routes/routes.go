@@ -10,6 +10,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
+	"backend/internal/aisearch"
+	"backend/internal/app"
 	"backend/internal/cache"
 	"backend/internal/controller"
 	"backend/internal/kafka"
@@ -18,6 +20,7 @@ import (
 	"backend/internal/middleware/loadshedding"
 	"backend/internal/middleware/logger"
 	"backend/internal/model"
+	"backend/internal/oidc"
 	"backend/internal/repo"
 	"backend/internal/service"
 )
@@ -31,22 +34,80 @@ func SetupWebsocket(r *gin.Engine){
     })
 }*/
 
-func SetupAuthRouter(r *gin.RouterGroup, authService service.AuthService, loadsheddingFunc gin.HandlerFunc) {
+func SetupAuthRouter(r *gin.RouterGroup, authService service.AuthService, accountService service.AccountService, loginGuard *service.LoginGuard, publisher service.EventPublisher, singleSession bool, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
 	// authService := service.NewAuthService(db, typedCache)
 	authController := controller.NewAuthController(authService)
+	authController.Publisher = publisher
+	authController.SingleSession = singleSession
+	authController.Accounts = accountService
+	authController.Guard = loginGuard
+
+	accountController := controller.NewAccountController(accountService)
+	accountController.Publisher = publisher
 
 	authRoutes := r.Group("/auth")
 	authRoutes.Use(loadsheddingFunc)
 	{
 		authRoutes.POST("/register", authController.Register)
 		authRoutes.POST("/login", authController.Login)
+		authRoutes.POST("/refresh", authController.Refresh)
 		authRoutes.POST("/logout", authController.Logout)
+		authRoutes.GET("/sessions", authFunc, authController.ListSessions)
+		authRoutes.DELETE("/sessions/:id", authFunc, authController.RevokeSession)
+		authRoutes.POST("/verify-email/request", authFunc, accountController.RequestEmailVerification)
+		authRoutes.POST("/verify-email/confirm", accountController.ConfirmEmailVerification)
+		authRoutes.POST("/password-reset/request", accountController.RequestPasswordReset)
+		authRoutes.POST("/password-reset/confirm", accountController.ConfirmPasswordReset)
+	}
+}
+
+func SetupAdminRouter(r *gin.RouterGroup, loginGuard *service.LoginGuard, botService service.BotService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	adminController := controller.NewAdminController(loginGuard)
+	botController := controller.NewBotController(botService)
+
+	adminRoutes := r.Group("/admin")
+	adminRoutes.Use(loadsheddingFunc, authFunc, jwtauth.RequireAdmin())
+	{
+		adminRoutes.POST("/users/:username/unlock", adminController.UnlockUser)
+		adminRoutes.POST("/bots", botController.CreateBot)
+		adminRoutes.GET("/bots", botController.ListBots)
+		adminRoutes.POST("/bots/:id/tokens", botController.CreateToken)
+		adminRoutes.GET("/bots/:id/tokens", botController.ListTokens)
+		adminRoutes.DELETE("/tokens/:id", botController.RevokeToken)
 	}
 }
 
-func SetupMembershipRouter(r *gin.RouterGroup, s service.MembershipService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+// SetupOIDCRouter registers single sign-on routes; it is only called when OIDC is enabled.
+func SetupOIDCRouter(r *gin.RouterGroup, oidcService service.OIDCService, loadsheddingFunc gin.HandlerFunc) {
+	oidcController := controller.NewOIDCController(oidcService)
+
+	oidcRoutes := r.Group("/auth/oidc")
+	oidcRoutes.Use(loadsheddingFunc)
+	{
+		oidcRoutes.GET("/login", oidcController.Login)
+		oidcRoutes.GET("/callback", oidcController.Callback)
+	}
+}
+
+func SetupMembershipRouter(r *gin.RouterGroup, s service.MembershipService, publisher service.EventPublisher, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
 	// membershipService := service.NewMembershipService(db)
 	membershipController := controller.NewMembershipController(s)
+	membershipController.Publisher = publisher
+
+	r.POST("/chatrooms/:id/read", loadsheddingFunc, authFunc, membershipController.MarkRead)
+	r.POST("/chatrooms/:id/members/:user_id/promote", loadsheddingFunc, authFunc, membershipController.PromoteMember)
+	r.POST("/chatrooms/:id/members/:user_id/demote", loadsheddingFunc, authFunc, membershipController.DemoteMember)
+	r.POST("/chatrooms/:id/members/:user_id/kick", loadsheddingFunc, authFunc, membershipController.KickMember)
+	r.POST("/chatrooms/:id/members/:user_id/ban", loadsheddingFunc, authFunc, membershipController.BanMember)
+	r.POST("/chatrooms/:id/invites", loadsheddingFunc, authFunc, membershipController.CreateInvite)
+
+	invites := r.Group("/invites")
+	invites.Use(loadsheddingFunc)
+	{
+		invites.GET("/:token", membershipController.PreviewInvite)
+		invites.POST("/:token/accept", authFunc, membershipController.AcceptInvite)
+		invites.DELETE("/:token", authFunc, membershipController.RevokeInvite)
+	}
 
 	memberships := r.Group("/memberships")
 
@@ -56,6 +117,7 @@ func SetupMembershipRouter(r *gin.RouterGroup, s service.MembershipService, auth
 
 	{
 		memberships.POST("/add-user", membershipController.AddUser)
+		memberships.DELETE("", membershipController.LeaveChatRoom)
 		//memberships.POST("/chatrooms", membershipController.GetUserChatRooms)
 		memberships.GET("/:username/chatrooms", func(c *gin.Context) {
 			username := c.Param("username") // <-- string, no conversion
@@ -66,16 +128,54 @@ func SetupMembershipRouter(r *gin.RouterGroup, s service.MembershipService, auth
 
 }
 
-func SetupMessageRouter(r *gin.RouterGroup, messageService service.MessageService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+// SetupMessageRouter guards posting and reading messages with apiAuthFunc, so that bots can use
+// them with API tokens; every other route takes a user session.
+func SetupMessageRouter(r *gin.RouterGroup, messageService service.MessageService, botService service.BotService, attachmentService service.AttachmentService, publisher service.EventPublisher, authFunc gin.HandlerFunc, apiAuthFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
 	messageController := controller.NewMessageController(messageService)
+	messageController.Publisher = publisher
+	messageController.Bots = botService
+	messageController.Attachments = attachmentService
 
-	r.POST("/messages", loadsheddingFunc, authFunc, messageController.CreateMessage)
-	r.GET("/chatrooms/:id/messages", loadsheddingFunc, authFunc, messageController.GetMessagesByChatRoom)
+	r.POST("/messages", loadsheddingFunc, apiAuthFunc, messageController.CreateMessage)
+	r.GET("/chatrooms/:id/messages", loadsheddingFunc, apiAuthFunc, messageController.GetMessagesByChatRoom)
 	r.DELETE("/messages/:id", loadsheddingFunc, authFunc, messageController.DeleteMessage)
+	r.PATCH("/messages/:id", loadsheddingFunc, authFunc, messageController.EditMessage)
+	r.GET("/messages/:id/revisions", loadsheddingFunc, authFunc, messageController.GetMessageRevisions)
+	r.GET("/messages/:id/thread", loadsheddingFunc, authFunc, messageController.GetThread)
+}
+
+// SetupAttachmentRouter registers attachment uploads and downloads. The upload and file routes
+// take the signed token in their path instead of a session, so browsers can use them directly.
+func SetupAttachmentRouter(r *gin.RouterGroup, attachmentService service.AttachmentService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	attachmentController := controller.NewAttachmentController(attachmentService)
+
+	r.POST("/attachments", loadsheddingFunc, authFunc, attachmentController.CreateUpload)
+	r.GET("/attachments/:id", loadsheddingFunc, authFunc, attachmentController.GetAttachment)
+	r.PUT("/uploads/:token", loadsheddingFunc, attachmentController.Upload)
+	r.GET("/files/:token", loadsheddingFunc, attachmentController.Download)
 }
 
-func SetupChatroomRouter(r *gin.RouterGroup, chatRoomService service.ChatRoomService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+func SetupReactionRouter(r *gin.RouterGroup, reactionService service.ReactionService, publisher service.EventPublisher, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	reactionController := controller.NewReactionController(reactionService)
+	reactionController.Publisher = publisher
+
+	r.GET("/messages/:id/reactions", loadsheddingFunc, authFunc, reactionController.GetReactions)
+	r.POST("/messages/:id/reactions", loadsheddingFunc, authFunc, reactionController.AddReaction)
+	r.DELETE("/messages/:id/reactions/:emoji", loadsheddingFunc, authFunc, reactionController.RemoveReaction)
+}
+
+func SetupPinRouter(r *gin.RouterGroup, pinService service.PinService, publisher service.EventPublisher, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	pinController := controller.NewPinController(pinService)
+	pinController.Publisher = publisher
+
+	r.GET("/chatrooms/:id/pins", loadsheddingFunc, authFunc, pinController.ListPins)
+	r.POST("/messages/:id/pin", loadsheddingFunc, authFunc, pinController.PinMessage)
+	r.DELETE("/messages/:id/pin", loadsheddingFunc, authFunc, pinController.UnpinMessage)
+}
+
+func SetupChatroomRouter(r *gin.RouterGroup, chatRoomService service.ChatRoomService, publisher service.EventPublisher, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
 	chatRoomController := controller.NewChatRoomController(chatRoomService)
+	chatRoomController.Publisher = publisher
 
 	chatrooms := r.Group("/chatrooms")
 	chatrooms.Use(loadsheddingFunc)
@@ -84,9 +184,12 @@ func SetupChatroomRouter(r *gin.RouterGroup, chatRoomService service.ChatRoomSer
 		chatrooms.POST("", chatRoomController.CreateChatRoom)
 		chatrooms.GET("", chatRoomController.GetAllChatRooms)
 		chatrooms.GET("/:id", chatRoomController.GetChatRoomByID)
+		chatrooms.PATCH("/:id", chatRoomController.UpdateChatRoom)
 		chatrooms.DELETE("/:id", chatRoomController.DeleteChatRoom)
 		chatrooms.GET("/search", chatRoomController.SearchChatRooms)
 	}
+
+	r.POST("/dms", loadsheddingFunc, authFunc, chatRoomController.CreateDirectRoom)
 }
 
 func SetupUserRouter(r *gin.RouterGroup, userService service.UserService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
@@ -101,7 +204,83 @@ func SetupUserRouter(r *gin.RouterGroup, userService service.UserService, authFu
 	}
 }
 
-func setupKafkaConsumer(kafkaService service.KafkaService) {
+func SetupWebhookRouter(r *gin.RouterGroup, webhookService service.WebhookService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	webhookController := controller.NewWebhookController(webhookService)
+
+	r.POST("/chatrooms/:id/webhooks", loadsheddingFunc, authFunc, webhookController.CreateWebhook)
+	r.GET("/chatrooms/:id/webhooks", loadsheddingFunc, authFunc, webhookController.ListWebhooks)
+
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(loadsheddingFunc)
+	webhooks.Use(authFunc)
+	{
+		webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+		webhooks.POST("/:id/enable", webhookController.EnableWebhook)
+		webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/replay", webhookController.ReplayDelivery)
+	}
+}
+
+func SetupIncomingWebhookRouter(r *gin.RouterGroup, incomingWebhookService service.IncomingWebhookService, publisher service.EventPublisher, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	incomingWebhookController := controller.NewIncomingWebhookController(incomingWebhookService)
+	incomingWebhookController.Publisher = publisher
+
+	r.POST("/chatrooms/:id/incoming-webhooks", loadsheddingFunc, authFunc, incomingWebhookController.CreateIncomingWebhook)
+	r.GET("/chatrooms/:id/incoming-webhooks", loadsheddingFunc, authFunc, incomingWebhookController.ListIncomingWebhooks)
+	r.DELETE("/incoming-webhooks/:id", loadsheddingFunc, authFunc, incomingWebhookController.DeleteIncomingWebhook)
+	// the token in the path is the credential
+	r.POST("/hooks/:token", loadsheddingFunc, incomingWebhookController.Post)
+}
+
+func SetupNotificationRouter(r *gin.RouterGroup, notificationService service.NotificationService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	notificationController := controller.NewNotificationController(notificationService)
+
+	notifications := r.Group("/notifications")
+	notifications.Use(loadsheddingFunc)
+	notifications.Use(authFunc)
+	{
+		notifications.GET("", notificationController.ListNotifications)
+		notifications.POST("/:id/read", notificationController.MarkRead)
+		notifications.POST("/read-all", notificationController.MarkAllRead)
+	}
+}
+
+func SetupSearchRouter(r *gin.RouterGroup, searchService service.SearchService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	searchController := controller.NewSearchController(searchService)
+
+	r.GET("/search/messages", loadsheddingFunc, authFunc, searchController.SearchMessages)
+}
+
+// SetupSemanticSearchRouter registers semantic search; it is only called when it is enabled.
+func SetupSemanticSearchRouter(r *gin.RouterGroup, semanticSearchService service.SemanticSearchService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	semanticSearchController := controller.NewSemanticSearchController(semanticSearchService)
+
+	r.GET("/chatrooms/:id/semantic-search", loadsheddingFunc, authFunc, semanticSearchController.Search)
+}
+
+// SetupCatchUpRouter registers catch-up summaries; it is only called when they are enabled.
+func SetupCatchUpRouter(r *gin.RouterGroup, catchUpService service.CatchUpService, authFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	catchUpController := controller.NewCatchUpController(catchUpService)
+
+	r.GET("/chatrooms/:id/catch-up", loadsheddingFunc, authFunc, catchUpController.CatchUp)
+}
+
+// SetupBotCommandRouter registers the routes bots use to manage their slash commands. They take
+// API tokens only.
+func SetupBotCommandRouter(r *gin.RouterGroup, botCommandService service.BotCommandService, apiAuthFunc gin.HandlerFunc, loadsheddingFunc gin.HandlerFunc) {
+	botCommandController := controller.NewBotCommandController(botCommandService)
+
+	commands := r.Group("/bot/commands")
+	commands.Use(loadsheddingFunc)
+	commands.Use(apiAuthFunc)
+	{
+		commands.POST("", botCommandController.RegisterCommand)
+		commands.GET("", botCommandController.ListCommands)
+		commands.DELETE("/:name", botCommandController.DeleteCommand)
+	}
+}
+
+func setupKafkaConsumer(kafkaService *service.KafkaService) {
 	// Implement Kafka consumer setup here
 	consumer, err := kafka.NewWsOutboundConsumer(
 		[]string{"kafka:9092"},
@@ -113,13 +292,41 @@ func setupKafkaConsumer(kafkaService service.KafkaService) {
 		log.Fatal(err)
 	}
 
-	ctx, _ := context.WithCancel(context.Background())
-	// defer cancel()
+	consumer.Start(context.Background())
+}
+
+// setupWebhookDispatcher queues webhook deliveries for the events sent to clients and starts sending them.
+func setupWebhookDispatcher(dispatcher *service.WebhookDispatcher) {
+	consumer, err := kafka.NewWsOutboundConsumer(
+		[]string{"kafka:9092"},
+		"backend-webhooks",
+		[]string{"notification"},
+		dispatcher.HandleEvent,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	consumer.Start(context.Background())
+	go dispatcher.Run(context.Background())
+}
+
+// setupSemanticIndexer feeds the messages sent to clients into the AI service's index.
+func setupSemanticIndexer(indexer *service.SemanticIndexer) {
+	consumer, err := kafka.NewWsOutboundConsumer(
+		[]string{"kafka:9092"},
+		"backend-semantic-indexer",
+		[]string{"notification"},
+		indexer.HandleEvent,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	consumer.Start(ctx)
+	consumer.Start(context.Background())
 }
 
-func SetupRouter(db *gorm.DB, rds *redis.Client) *gin.Engine {
+func SetupRouter(db *gorm.DB, rds *redis.Client, cfg *app.Config) *gin.Engine {
 	//r := gin.Default()
 	r := gin.New()
 	// init logger
@@ -135,30 +342,137 @@ func SetupRouter(db *gorm.DB, rds *redis.Client) *gin.Engine {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	typedCache := cache.NewTypedCache[service.BlockEntry](10*time.Minute, 15*time.Minute)
 	redisCache := cache.NewRedisCache[[]model.ChatRoom](rds)
 
 	repos := repo.NewRepoContainer(db)
 
-	authService := service.NewAuthService(repos, typedCache)
+	revocations := service.NewRevocationList(cache.NewRedisCache[service.BlockEntry](rds), repos.BlockedJWT)
+	if err := revocations.Restore(); err != nil {
+		log.Println("failed to restore revoked tokens:", err)
+	}
+
+	authService := service.NewAuthService(repos, revocations)
+	mail, err := app.NewMailer(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up mailer: %v", err)
+	}
+	accountService := service.NewAccountService(repos, authService, mail, cfg.Mail.BaseURL)
+	loginAttempts := cache.NewFallbackCache[service.LoginAttempts](
+		cache.NewRedisCache[service.LoginAttempts](rds),
+		cache.NewTypedCache[service.LoginAttempts](time.Hour, 10*time.Minute),
+	)
+	loginGuard := service.NewLoginGuard(loginAttempts, service.LoginGuardOptions{
+		UserThreshold: cfg.Auth.Lockout.UserThreshold,
+		IPThreshold:   cfg.Auth.Lockout.IPThreshold,
+		BaseLockout:   cfg.Auth.Lockout.BaseLockout,
+		MaxLockout:    cfg.Auth.Lockout.MaxLockout,
+	})
+	for _, username := range cfg.Auth.Admins {
+		n, err := repos.User.SetAdmin(username, true)
+		if err != nil {
+			log.Printf("failed to grant admin to %q: %v", username, err)
+		} else if n == 0 {
+			log.Printf("admin %q is not registered yet", username)
+		}
+	}
 	userService := service.NewUserService(repos)
-	chatRoomService := service.NewChatRoomService(repos)
+	chatRoomService := service.NewChatRoomService(repos, redisCache)
 	membershipService := service.NewMembershipService(repos, redisCache)
 	messageService := service.NewMessageService(repos)
+	reactionService := service.NewReactionService(repos)
+	pinService := service.NewPinService(repos)
+	botService := service.NewBotService(repos)
+	notificationService := service.NewNotificationService(repos)
+	searchEngine, err := app.NewSearchEngine(cfg, db)
+	if err != nil {
+		log.Fatalf("failed to set up search: %v", err)
+	}
+	searchService := service.NewSearchService(repos, searchEngine)
+	objectStore, err := app.NewObjectStore(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up attachment store: %v", err)
+	}
+	attachmentSecret, err := app.AttachmentSigningSecret(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up attachments: %v", err)
+	}
+	attachmentService := service.NewAttachmentService(repos, objectStore, service.AttachmentOptions{
+		MaxSize:      cfg.Attachments.MaxSize,
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+		Secret:       attachmentSecret,
+		LinkTTL:      cfg.Attachments.LinkTTL,
+	})
+	messageService.Attachments = attachmentService
 
-	kafkaService := service.KafkaService{
+	kafkaService := &service.KafkaService{
 		Producer:       kafka.NewKafkaProducer([]string{"kafka:9092"}),
 		MessageService: messageService,
+		Attachments:    attachmentService,
+	}
+	messageService.Publisher = kafkaService
+	commands := service.NewCommandRegistry(repos, messageService, kafkaService, cfg.Webhooks.AllowPrivateNetworks)
+	if err := service.RegisterBuiltinCommands(commands, repos, chatRoomService, membershipService); err != nil {
+		log.Fatalf("failed to register commands: %v", err)
 	}
+	kafkaService.Commands = commands
+	botCommandService := service.NewBotCommandService(repos, commands)
 	setupKafkaConsumer(kafkaService)
-	authFunc := jwtauth.NewAuthMiddleware(authService).Auth()
+	webhookService := service.NewWebhookService(repos, cfg.Webhooks.AllowPrivateNetworks)
+	incomingWebhookService := service.NewIncomingWebhookService(repos, messageService)
+	setupWebhookDispatcher(service.NewWebhookDispatcher(repos, service.WebhookDispatcherOptions{
+		MaxAttempts:          cfg.Webhooks.MaxAttempts,
+		BaseBackoff:          cfg.Webhooks.BaseBackoff,
+		MaxBackoff:           cfg.Webhooks.MaxBackoff,
+		DisableAfter:         cfg.Webhooks.DisableAfter,
+		Timeout:              cfg.Webhooks.Timeout,
+		AllowPrivateNetworks: cfg.Webhooks.AllowPrivateNetworks,
+	}))
+	authMiddleware := jwtauth.NewAuthMiddleware(authService)
+	authMiddleware.Bots = botService
+	authFunc := authMiddleware.Auth()
+	apiAuthFunc := authMiddleware.AuthWithAPITokens()
 	loadsheddingFunc := loadshedding.LoadShedding(20, 5, 100*time.Millisecond)
 
+	r.GET("/.well-known/jwks.json", controller.JWKS)
+
 	api := r.Group("/api")
 	SetupUserRouter(api, userService, authFunc, loadsheddingFunc)
-	SetupChatroomRouter(api, chatRoomService, authFunc, loadsheddingFunc)
-	SetupMessageRouter(api, messageService, authFunc, loadsheddingFunc)
-	SetupAuthRouter(api, authService, loadsheddingFunc)
-	SetupMembershipRouter(api, membershipService, authFunc, loadsheddingFunc)
+	SetupChatroomRouter(api, chatRoomService, kafkaService, authFunc, loadsheddingFunc)
+	SetupMessageRouter(api, messageService, botService, attachmentService, kafkaService, authFunc, apiAuthFunc, loadsheddingFunc)
+	SetupAttachmentRouter(api, attachmentService, authFunc, loadsheddingFunc)
+	SetupReactionRouter(api, reactionService, kafkaService, authFunc, loadsheddingFunc)
+	SetupPinRouter(api, pinService, kafkaService, authFunc, loadsheddingFunc)
+	SetupAuthRouter(api, authService, accountService, loginGuard, kafkaService, cfg.Auth.SingleSession, authFunc, loadsheddingFunc)
+	SetupMembershipRouter(api, membershipService, kafkaService, authFunc, loadsheddingFunc)
+	SetupAdminRouter(api, loginGuard, botService, authFunc, loadsheddingFunc)
+	SetupWebhookRouter(api, webhookService, authFunc, loadsheddingFunc)
+	SetupIncomingWebhookRouter(api, incomingWebhookService, kafkaService, authFunc, loadsheddingFunc)
+	SetupSearchRouter(api, searchService, authFunc, loadsheddingFunc)
+	SetupNotificationRouter(api, notificationService, authFunc, loadsheddingFunc)
+	SetupBotCommandRouter(api, botCommandService, apiAuthFunc, loadsheddingFunc)
+	if cfg.SemanticSearch.Enabled {
+		aiClient := aisearch.NewClient(cfg.SemanticSearch.URL, cfg.SemanticSearch.Timeout)
+		setupSemanticIndexer(service.NewSemanticIndexer(repos, aiClient))
+		SetupSemanticSearchRouter(api, service.NewSemanticSearchService(repos, aiClient), authFunc, loadsheddingFunc)
+	}
+	if cfg.CatchUp.Enabled {
+		summaries := cache.NewFallbackCache[service.CatchUp](
+			cache.NewRedisCache[service.CatchUp](rds),
+			cache.NewTypedCache[service.CatchUp](time.Hour, 10*time.Minute),
+		)
+		catchUpService := service.NewCatchUpService(repos, aisearch.NewClient(cfg.CatchUp.URL, cfg.CatchUp.Timeout), summaries)
+		SetupCatchUpRouter(api, catchUpService, authFunc, loadsheddingFunc)
+	}
+	if cfg.OIDC.Enabled {
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		})
+		oidcService := service.NewOIDCService(repos, provider, cache.NewRedisCache[service.OIDCLoginState](rds))
+		SetupOIDCRouter(api, oidcService, loadsheddingFunc)
+	}
 	return r
 }
@@ -0,0 +1,60 @@
+// Package search finds messages by their text. Engine is the extension point: the default
+// engine keeps an SQLite FTS5 index next to the messages table.
+package search
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"time"
+)
+
+// Snippet markers around matched terms, before they are turned into <mark> tags.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+var (
+	ErrEmptyQuery    = errors.New("search text is required")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Query is a message search. RoomIDs limits the search to those rooms and must not be empty;
+// the other filters are optional.
+type Query struct {
+	Text     string
+	RoomIDs  []uint
+	AuthorID uint
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+	Cursor   string
+	Limit    int
+}
+
+// Hit is a message matching a query.
+type Hit struct {
+	MessageID  uint      `json:"message_id"`
+	ChatRoomID uint      `json:"chat_room_id"`
+	UserID     uint      `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Snippet is an HTML-escaped excerpt of the message with the matched terms in <mark> tags.
+	Snippet string `json:"snippet"`
+}
+
+// Result is a page of hits, newest first. NextCursor is empty on the last page.
+type Result struct {
+	Hits       []Hit  `json:"data"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// Engine searches messages.
+type Engine interface {
+	Search(q Query) (*Result, error)
+}
+
+// highlight HTML-escapes a snippet and turns its markers into <mark> tags.
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(escaped)
+}
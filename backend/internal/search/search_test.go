@@ -0,0 +1,18 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchExpression(t *testing.T) {
+	require.Equal(t, `"deploy" "api"`, matchExpression("  deploy api "))
+	require.Equal(t, `"depl"*`, matchExpression("depl*"))
+	require.Equal(t, `"NOT" "a""b" "col:x"`, matchExpression(`NOT a"b col:x`))
+	require.Empty(t, matchExpression(" * ** "))
+}
+
+func TestHighlight(t *testing.T) {
+	require.Equal(t, "&lt;b&gt; <mark>deploy</mark> &amp; go", highlight("<b> "+markStart+"deploy"+markEnd+" & go"))
+}
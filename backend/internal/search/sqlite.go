@@ -0,0 +1,137 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ftsSetup creates the FTS5 index of messages.content and the triggers that keep it in sync.
+// It is an external-content table, so the text itself is only stored in messages.
+var ftsSetup = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+		content, content='messages', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_ai AFTER INSERT ON messages BEGIN
+		INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_ad AFTER DELETE ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_au AFTER UPDATE OF content ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+	END`,
+}
+
+// SQLiteEngine searches messages with an SQLite FTS5 index. Its cursors are message ids.
+type SQLiteEngine struct {
+	db *gorm.DB
+}
+
+// NewSQLiteEngine sets up the index on db, which must already have the messages table. Messages
+// written before the index existed are indexed on the first run.
+func NewSQLiteEngine(db *gorm.DB) (*SQLiteEngine, error) {
+	var existing int64
+	if err := db.Raw(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'messages_fts'`).Scan(&existing).Error; err != nil {
+		return nil, err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range ftsSetup {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if existing == 0 {
+			return tx.Exec(`INSERT INTO messages_fts(messages_fts) VALUES ('rebuild')`).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteEngine{db: db}, nil
+}
+
+type sqliteHit struct {
+	ID        uint
+	RoomID    uint
+	UserID    uint
+	CreatedAt time.Time
+	Snippet   string
+}
+
+func (e *SQLiteEngine) Search(q Query) (*Result, error) {
+	match := matchExpression(q.Text)
+	if match == "" {
+		return nil, ErrEmptyQuery
+	}
+	if len(q.RoomIDs) == 0 {
+		return &Result{Hits: []Hit{}}, nil
+	}
+
+	query := e.db.Table("messages_fts").
+		Select("messages.id, messages.room_id, messages.user_id, messages.created_at, "+
+			"snippet(messages_fts, 0, ?, ?, '…', 16) AS snippet", markStart, markEnd).
+		Joins("JOIN messages ON messages.id = messages_fts.rowid").
+		Where("messages_fts MATCH ?", match).
+		Where("messages.room_id IN ?", q.RoomIDs)
+	if q.AuthorID != 0 {
+		query = query.Where("messages.user_id = ?", q.AuthorID)
+	}
+	// created_at is stored as text, which may carry any offset
+	if !q.From.IsZero() {
+		query = query.Where("julianday(messages.created_at) >= julianday(?)", q.From.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.To.IsZero() {
+		query = query.Where("julianday(messages.created_at) < julianday(?)", q.To.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Cursor != "" {
+		beforeID, err := strconv.ParseUint(q.Cursor, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		query = query.Where("messages.id < ?", beforeID)
+	}
+
+	var rows []sqliteHit
+	if err := query.Order("messages.id DESC").Limit(q.Limit + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: make([]Hit, 0, len(rows))}
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		result.NextCursor = strconv.FormatUint(uint64(rows[len(rows)-1].ID), 10)
+	}
+	for _, row := range rows {
+		result.Hits = append(result.Hits, Hit{
+			MessageID:  row.ID,
+			ChatRoomID: row.RoomID,
+			UserID:     row.UserID,
+			CreatedAt:  row.CreatedAt,
+			Snippet:    highlight(row.Snippet),
+		})
+	}
+	return result, nil
+}
+
+// matchExpression turns the words of text into FTS5 strings that must all match, so that FTS5
+// operators in the text are searched for literally. A trailing * makes a word match as a prefix.
+func matchExpression(text string) string {
+	var parts []string
+	for _, word := range strings.Fields(text) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.TrimRight(word, "*")
+		if word == "" {
+			continue
+		}
+		part := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
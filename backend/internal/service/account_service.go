@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend/internal/mailer"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// AccountService handles email verification and password recovery.
+type AccountService interface {
+	SendEmailVerification(userID uint) error
+	VerifyEmail(token string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) (userID uint, err error)
+}
+
+type accountService struct {
+	repos   *repo.RepoContainer
+	auth    AuthService
+	mailer  mailer.Mailer
+	baseURL string
+}
+
+// NewAccountService returns an AccountService that mails links pointing at baseURL, the address of the frontend.
+func NewAccountService(repos *repo.RepoContainer, auth AuthService, m mailer.Mailer, baseURL string) AccountService {
+	return &accountService{repos: repos, auth: auth, mailer: m, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// SendEmailVerification mails userID a link that confirms their email address.
+// Links sent earlier stop working.
+func (s *accountService) SendEmailVerification(userID uint) error {
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(user.ID, model.TokenPurposeVerifyEmail, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link within 24 hours:\n\n%s\n",
+			user.Username, s.link("/verify-email", token)),
+	})
+}
+
+func (s *accountService) VerifyEmail(token string) error {
+	t, err := s.redeemToken(model.TokenPurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	return s.repos.User.MarkEmailVerified(t.UserID)
+}
+
+// RequestPasswordReset mails a reset link to the user registered with email. Unknown addresses are
+// ignored without an error so the endpoint cannot be used to find out who has an account.
+func (s *accountService) RequestPasswordReset(email string) error {
+	user, err := s.repos.User.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(user.ID, model.TokenPurposeResetPassword, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your password. Open this link within an hour to choose a new one:\n\n%s\n\nIf it wasn't you, ignore this email.\n",
+			user.Username, s.link("/reset-password", token)),
+	})
+}
+
+// ResetPassword sets a new password and logs the user out of every session.
+func (s *accountService) ResetPassword(token, newPassword string) (uint, error) {
+	if newPassword == "" {
+		return 0, errors.New("password is required")
+	}
+	t, err := s.redeemToken(model.TokenPurposeResetPassword, token)
+	if err != nil {
+		return 0, err
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repos.User.UpdatePassword(t.UserID, hash); err != nil {
+		return 0, err
+	}
+	if err := s.repos.AccountToken.InvalidateForUser(t.UserID, model.TokenPurposeResetPassword); err != nil {
+		return 0, err
+	}
+	if err := s.auth.ForceLogoutAll(t.UserID); err != nil {
+		return 0, err
+	}
+	return t.UserID, nil
+}
+
+// issueToken replaces the outstanding tokens of userID for purpose with a new one and returns it.
+func (s *accountService) issueToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	if err := s.repos.AccountToken.InvalidateForUser(userID, purpose); err != nil {
+		return "", err
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.repos.AccountToken.Create(&model.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// redeemToken looks up token and marks it used.
+func (s *accountService) redeemToken(purpose, token string) (*model.AccountToken, error) {
+	if token == "" {
+		return nil, ErrInvalidAccountToken
+	}
+	t, err := s.repos.AccountToken.GetByHash(purpose, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, err
+	}
+	if !t.Usable(time.Now()) {
+		return nil, ErrInvalidAccountToken
+	}
+	used, err := s.repos.AccountToken.Consume(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if used == 0 {
+		return nil, ErrInvalidAccountToken
+	}
+	return t, nil
+}
+
+func (s *accountService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/mailer"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+	"backend/utils"
+)
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var mailLinkRe = regexp.MustCompile(`https?://\S+`)
+
+// lastToken pulls the token out of the link in the last mail sent.
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+	link := mailLinkRe.FindString(m.sent[len(m.sent)-1].Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestAccountService(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	auth := service.NewAuthService(repos, setupTestCache(t))
+	mail := &recordingMailer{}
+	accounts := service.NewAccountService(repos, auth, mail, "http://chat.test/")
+
+	hash, err := utils.HashPassword("old-password")
+	require.NoError(t, err)
+	user := model.User{Username: "carol", Email: "carol@test.com", Password: hash}
+	require.NoError(t, auth.Register(&user))
+
+	t.Run("verify email", func(t *testing.T) {
+		require.NoError(t, accounts.SendEmailVerification(user.ID))
+		stale := mail.lastToken(t)
+		require.NoError(t, accounts.SendEmailVerification(user.ID))
+		token := mail.lastToken(t)
+		require.Equal(t, "carol@test.com", mail.sent[len(mail.sent)-1].To)
+		require.Contains(t, mail.sent[len(mail.sent)-1].Body, "http://chat.test/verify-email?token=")
+
+		require.ErrorIs(t, accounts.VerifyEmail(stale), service.ErrInvalidAccountToken)
+		require.NoError(t, accounts.VerifyEmail(token))
+		require.ErrorIs(t, accounts.VerifyEmail(token), service.ErrInvalidAccountToken)
+
+		fetched, err := repos.User.GetByID(user.ID)
+		require.NoError(t, err)
+		require.True(t, fetched.EmailVerified)
+		require.ErrorIs(t, accounts.SendEmailVerification(user.ID), service.ErrEmailAlreadyVerified)
+	})
+
+	t.Run("unknown email sends nothing", func(t *testing.T) {
+		before := len(mail.sent)
+		require.NoError(t, accounts.RequestPasswordReset("nobody@test.com"))
+		require.Len(t, mail.sent, before)
+	})
+
+	t.Run("reset password revokes sessions", func(t *testing.T) {
+		tokens, err := auth.Login("carol", "old-password", service.ClientInfo{})
+		require.NoError(t, err)
+
+		require.NoError(t, accounts.RequestPasswordReset("carol@test.com"))
+		token := mail.lastToken(t)
+
+		_, err = accounts.ResetPassword("bogus", "new-password")
+		require.ErrorIs(t, err, service.ErrInvalidAccountToken)
+		require.ErrorIs(t, accounts.VerifyEmail(token), service.ErrInvalidAccountToken, "reset tokens must not verify emails")
+
+		userID, err := accounts.ResetPassword(token, "new-password")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, userID)
+
+		_, _, err = auth.ValidateJWT(tokens.AccessToken)
+		require.Error(t, err)
+		_, err = auth.Login("carol", "old-password", service.ClientInfo{})
+		require.Error(t, err)
+		_, err = auth.Login("carol", "new-password", service.ClientInfo{})
+		require.NoError(t, err)
+
+		_, err = accounts.ResetPassword(token, "another-password")
+		require.ErrorIs(t, err, service.ErrInvalidAccountToken)
+	})
+
+	t.Run("expired reset token", func(t *testing.T) {
+		require.NoError(t, accounts.RequestPasswordReset("carol@test.com"))
+		token := mail.lastToken(t)
+		require.NoError(t, db.Model(&model.AccountToken{}).Where("used_at IS NULL").
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, err := accounts.ResetPassword(token, "late-password")
+		require.ErrorIs(t, err, service.ErrInvalidAccountToken)
+	})
+}
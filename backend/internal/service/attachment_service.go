@@ -0,0 +1,461 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"backend/internal/blob"
+	"backend/internal/model"
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+)
+
+// MaxMessageAttachments caps how many files one message can carry.
+const MaxMessageAttachments = 10
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrInvalidAttachment  = errors.New("invalid attachment")
+	ErrInvalidFileToken   = errors.New("invalid or expired link")
+)
+
+// Link purposes, so a download link cannot be used to upload and the other way around.
+const (
+	tokenUpload    = "up"
+	tokenDownload  = "dl"
+	tokenThumbnail = "th"
+)
+
+// AttachmentOptions tunes an AttachmentService. Zero values fall back to the defaults noted per field.
+type AttachmentOptions struct {
+	// MaxSize is the largest file accepted, in bytes (10 MiB).
+	MaxSize int64
+	// AllowedTypes are the accepted MIME types (common images, PDF and plain text).
+	AllowedTypes []string
+	// Secret signs upload and download links. Without it a random one is used, which only
+	// works while a single backend serves the links.
+	Secret []byte
+	// LinkTTL is how long upload and download links stay valid (15 minutes).
+	LinkTTL time.Duration
+}
+
+var defaultAttachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+// UploadTicket tells a client where to PUT the bytes of a new attachment.
+type UploadTicket struct {
+	Attachment *model.Attachment `json:"attachment"`
+	UploadURL  string            `json:"upload_url"`
+	Method     string            `json:"method"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// AttachmentLinks is an attachment with short-lived links to its file and thumbnail.
+type AttachmentLinks struct {
+	*model.Attachment
+	DownloadURL  string `json:"download_url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+type AttachmentService interface {
+	// CreateUpload registers a file a member is about to upload into a room.
+	CreateUpload(userID, chatRoomID uint, filename, contentType string, size int64) (*UploadTicket, error)
+	// Upload stores the bytes of the attachment the upload token was issued for.
+	Upload(token, contentType string, body io.Reader) (*model.Attachment, error)
+	GetAttachment(userID, id uint) (*AttachmentLinks, error)
+	// OpenFile opens the file or thumbnail a download token points at.
+	OpenFile(token string) (*model.Attachment, io.ReadCloser, string, error)
+	// PrepareForMessage checks that userID can send the attachments in ids with a message
+	// in chatRoomID.
+	PrepareForMessage(userID, chatRoomID uint, ids []uint) ([]model.Attachment, error)
+	// LinkToMessage attaches prepared attachments to msg and sets msg.Attachments.
+	LinkToMessage(msg *model.Message, attachments []model.Attachment) error
+	// DeleteMessageAttachments removes the attachments of a deleted message and their files.
+	DeleteMessageAttachments(messageID uint) error
+}
+
+type attachmentService struct {
+	repos *repo.RepoContainer
+	store blob.ObjectStore
+	opts  AttachmentOptions
+	now   func() time.Time
+}
+
+func NewAttachmentService(repos *repo.RepoContainer, store blob.ObjectStore, opts AttachmentOptions) AttachmentService {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 10 << 20
+	}
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = defaultAttachmentTypes
+	}
+	if len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		if _, err := rand.Read(opts.Secret); err != nil {
+			panic(err)
+		}
+	}
+	if opts.LinkTTL <= 0 {
+		opts.LinkTTL = 15 * time.Minute
+	}
+	return &attachmentService{repos: repos, store: store, opts: opts, now: time.Now}
+}
+
+func (s *attachmentService) CreateUpload(userID, chatRoomID uint, filename, contentType string, size int64) (*UploadTicket, error) {
+	ok, err := s.repos.UserChatRoom.Exists(userID, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	filename = cleanFilename(filename)
+	if filename == "" {
+		return nil, fmt.Errorf("%w: filename is required", ErrInvalidAttachment)
+	}
+	contentType, err = s.allowedType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > s.opts.MaxSize {
+		return nil, fmt.Errorf("%w: size must be between 1 and %d bytes", ErrInvalidAttachment, s.opts.MaxSize)
+	}
+
+	attachment := &model.Attachment{
+		UploaderID:  userID,
+		ChatRoomID:  chatRoomID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+	}
+	if err := s.repos.Attachment.Create(attachment); err != nil {
+		return nil, err
+	}
+	attachment.StorageKey = "attachments/" + strconv.FormatUint(uint64(attachment.ID), 10) + "/original"
+	if err := s.repos.Attachment.Update(attachment); err != nil {
+		return nil, err
+	}
+	attachment.URL = attachmentURL(attachment.ID)
+
+	expires := s.now().Add(s.opts.LinkTTL)
+	return &UploadTicket{
+		Attachment: attachment,
+		UploadURL:  "/api/uploads/" + s.sign(tokenUpload, attachment.ID, expires),
+		Method:     http.MethodPut,
+		ExpiresAt:  expires,
+	}, nil
+}
+
+func (s *attachmentService) Upload(token, contentType string, body io.Reader) (*model.Attachment, error) {
+	attachment, err := s.verify(token, tokenUpload)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.UploadedAt != nil {
+		return nil, fmt.Errorf("%w: already uploaded", ErrInvalidAttachment)
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != attachment.ContentType {
+		return nil, fmt.Errorf("%w: content type must be %s", ErrInvalidAttachment, attachment.ContentType)
+	}
+
+	// one byte more than announced tells an oversized body from an exact one
+	data, err := io.ReadAll(io.LimitReader(body, attachment.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != attachment.Size {
+		return nil, fmt.Errorf("%w: expected %d bytes", ErrInvalidAttachment, attachment.Size)
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if sniffed == "text/html" || strings.HasPrefix(attachment.ContentType, "image/") && sniffed != attachment.ContentType {
+		return nil, fmt.Errorf("%w: content does not match %s", ErrInvalidAttachment, attachment.ContentType)
+	}
+
+	if err := s.store.Put(attachment.StorageKey, bytes.NewReader(data), attachment.Size, attachment.ContentType); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		s.addThumbnail(attachment, data)
+	}
+
+	now := s.now()
+	attachment.UploadedAt = &now
+	if err := s.repos.Attachment.Update(attachment); err != nil {
+		return nil, err
+	}
+	attachment.URL = attachmentURL(attachment.ID)
+	return attachment, nil
+}
+
+// addThumbnail records the size of an image and stores a thumbnail of it. An image that cannot
+// be decoded, e.g. WebP, is kept without one.
+func (s *attachmentService) addThumbnail(attachment *model.Attachment, data []byte) {
+	width, height, err := imageSize(data)
+	if err != nil {
+		return
+	}
+	attachment.Width, attachment.Height = width, height
+
+	thumb, contentType, err := makeThumbnail(data)
+	if err != nil {
+		log.Printf("thumbnail for attachment %d: %v", attachment.ID, err)
+		return
+	}
+	key := strings.TrimSuffix(attachment.StorageKey, "original") + "thumbnail"
+	if err := s.store.Put(key, bytes.NewReader(thumb), int64(len(thumb)), contentType); err != nil {
+		log.Printf("thumbnail for attachment %d: %v", attachment.ID, err)
+		return
+	}
+	attachment.ThumbnailKey = key
+}
+
+func (s *attachmentService) GetAttachment(userID, id uint) (*AttachmentLinks, error) {
+	attachment, err := s.repos.Attachment.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	// a pending upload is only visible to its uploader
+	if attachment.UploadedAt == nil && attachment.UploaderID != userID {
+		return nil, ErrAttachmentNotFound
+	}
+	ok, err := s.repos.UserChatRoom.Exists(userID, attachment.ChatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	attachment.URL = attachmentURL(attachment.ID)
+	links := &AttachmentLinks{Attachment: attachment}
+	if attachment.UploadedAt != nil {
+		expires := s.now().Add(s.opts.LinkTTL)
+		links.DownloadURL = "/api/files/" + s.sign(tokenDownload, attachment.ID, expires)
+		if attachment.ThumbnailKey != "" {
+			links.ThumbnailURL = "/api/files/" + s.sign(tokenThumbnail, attachment.ID, expires)
+		}
+	}
+	return links, nil
+}
+
+func (s *attachmentService) OpenFile(token string) (*model.Attachment, io.ReadCloser, string, error) {
+	purpose, _, _, _ := parseFileToken(token)
+	if purpose != tokenDownload && purpose != tokenThumbnail {
+		return nil, nil, "", ErrInvalidFileToken
+	}
+	attachment, err := s.verify(token, purpose)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	if purpose == tokenThumbnail {
+		key = attachment.ThumbnailKey
+		contentType = "image/png"
+		if attachment.ContentType == "image/jpeg" {
+			contentType = "image/jpeg"
+		}
+	}
+	if key == "" || attachment.UploadedAt == nil {
+		return nil, nil, "", ErrAttachmentNotFound
+	}
+	r, err := s.store.Get(key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, "", ErrAttachmentNotFound
+		}
+		return nil, nil, "", err
+	}
+	return attachment, r, contentType, nil
+}
+
+func (s *attachmentService) PrepareForMessage(userID, chatRoomID uint, ids []uint) ([]model.Attachment, error) {
+	if len(ids) > MaxMessageAttachments {
+		return nil, fmt.Errorf("%w: at most %d attachments per message", ErrInvalidAttachment, MaxMessageAttachments)
+	}
+	attachments, err := s.repos.Attachment.ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) != len(ids) {
+		return nil, ErrAttachmentNotFound
+	}
+	for _, a := range attachments {
+		switch {
+		case a.UploaderID != userID:
+			return nil, ErrAttachmentNotFound
+		case a.ChatRoomID != chatRoomID:
+			return nil, fmt.Errorf("%w: attachment %d belongs to another chat room", ErrInvalidAttachment, a.ID)
+		case a.UploadedAt == nil:
+			return nil, fmt.Errorf("%w: attachment %d is not uploaded yet", ErrInvalidAttachment, a.ID)
+		case a.MessageID != nil:
+			return nil, fmt.Errorf("%w: attachment %d is already sent", ErrInvalidAttachment, a.ID)
+		}
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) LinkToMessage(msg *model.Message, attachments []model.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(attachments))
+	for _, a := range attachments {
+		ids = append(ids, a.ID)
+	}
+	rows, err := s.repos.Attachment.LinkToMessage(ids, msg.ID)
+	if err != nil {
+		return err
+	}
+	if rows != int64(len(ids)) {
+		// another message claimed some of them in the meantime; keep only what is ours
+		attachments, err = s.repos.Attachment.ListByMessageIDs([]uint{msg.ID})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range attachments {
+		attachments[i].MessageID = &msg.ID
+		attachments[i].URL = attachmentURL(attachments[i].ID)
+	}
+	msg.Attachments = attachments
+	return nil
+}
+
+func (s *attachmentService) DeleteMessageAttachments(messageID uint) error {
+	attachments, err := s.repos.Attachment.ListByMessageIDs([]uint{messageID})
+	if err != nil || len(attachments) == 0 {
+		return err
+	}
+	if err := s.repos.Attachment.DeleteByMessageID(messageID); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		for _, key := range []string{a.StorageKey, a.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := s.store.Delete(key); err != nil {
+				log.Printf("deleting %s of attachment %d: %v", key, a.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *attachmentService) allowedType(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, allowed := range s.opts.AllowedTypes {
+			if mediaType == allowed {
+				return mediaType, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: content type %q is not allowed", ErrInvalidAttachment, contentType)
+}
+
+// sign returns a token for purpose on attachment id that expires at expires.
+func (s *attachmentService) sign(purpose string, id uint, expires time.Time) string {
+	payload := purpose + "." + strconv.FormatUint(uint64(id), 10) + "." + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, s.opts.Secret)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a token issued by sign and returns the attachment it is for.
+func (s *attachmentService) verify(token, purpose string) (*model.Attachment, error) {
+	gotPurpose, id, expires, ok := parseFileToken(token)
+	if !ok || gotPurpose != purpose || s.now().After(expires) {
+		return nil, ErrInvalidFileToken
+	}
+	if !hmac.Equal([]byte(s.sign(purpose, id, expires)), []byte(token)) {
+		return nil, ErrInvalidFileToken
+	}
+	attachment, err := s.repos.Attachment.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func parseFileToken(token string) (purpose string, id uint, expires time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return "", 0, time.Time{}, false
+	}
+	rawID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, false
+	}
+	return parts[0], uint(rawID), time.Unix(unix, 0), true
+}
+
+// cleanFilename keeps the base name of a client supplied file name, without control characters.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func attachmentURL(id uint) string {
+	return "/api/attachments/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// AttachmentRefs describes attachments for a message event.
+func AttachmentRefs(attachments []model.Attachment) []*kafkapb.AttachmentRef {
+	if len(attachments) == 0 {
+		return nil
+	}
+	refs := make([]*kafkapb.AttachmentRef, 0, len(attachments))
+	for _, a := range attachments {
+		refs = append(refs, &kafkapb.AttachmentRef{
+			Id:          uint64(a.ID),
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			Url:         attachmentURL(a.ID),
+			Width:       uint32(a.Width),
+			Height:      uint32(a.Height),
+		})
+	}
+	return refs
+}
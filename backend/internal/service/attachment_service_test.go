@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/blob"
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// uploadToken takes the token out of an upload or download link.
+func uploadToken(link string) string {
+	return link[strings.LastIndex(link, "/")+1:]
+}
+
+func TestAttachmentService(t *testing.T) {
+	f := setupWebhookFixture(t)
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	attachments := service.NewAttachmentService(f.repos, store, service.AttachmentOptions{MaxSize: 1 << 20})
+	messages := service.NewMessageService(f.repos)
+	messages.Attachments = attachments
+
+	upload := func(t *testing.T, filename, contentType string, data []byte) *model.Attachment {
+		t.Helper()
+		ticket, err := attachments.CreateUpload(f.member.ID, f.room.ID, filename, contentType, int64(len(data)))
+		require.NoError(t, err)
+		attachment, err := attachments.Upload(uploadToken(ticket.UploadURL), contentType, bytes.NewReader(data))
+		require.NoError(t, err)
+		return attachment
+	}
+
+	t.Run("allow-lists", func(t *testing.T) {
+		_, err := attachments.CreateUpload(f.member.ID, f.room.ID, "run.sh", "application/x-sh", 10)
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+		_, err = attachments.CreateUpload(f.member.ID, f.room.ID, "big.png", "image/png", 2<<20)
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+		_, err = attachments.CreateUpload(f.member.ID, f.room.ID, "empty.txt", "text/plain", 0)
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+
+		outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "x"}
+		require.NoError(t, f.repos.User.Create(&outsider))
+		_, err = attachments.CreateUpload(outsider.ID, f.room.ID, "notes.txt", "text/plain", 5)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("uploads are checked against the ticket", func(t *testing.T) {
+		ticket, err := attachments.CreateUpload(f.member.ID, f.room.ID, "../../etc/cat.png", "image/png; charset=binary", 5)
+		require.NoError(t, err)
+		require.Equal(t, "cat.png", ticket.Attachment.Filename)
+		token := uploadToken(ticket.UploadURL)
+
+		_, err = attachments.Upload(token+"x", "image/png", strings.NewReader("12345"))
+		require.ErrorIs(t, err, service.ErrInvalidFileToken)
+		_, err = attachments.Upload(token, "image/jpeg", strings.NewReader("12345"))
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+		_, err = attachments.Upload(token, "image/png", strings.NewReader("123456"))
+		require.ErrorIs(t, err, service.ErrInvalidAttachment, "too long")
+		_, err = attachments.Upload(token, "image/png", strings.NewReader("12345"))
+		require.ErrorIs(t, err, service.ErrInvalidAttachment, "not a png")
+
+		// an upload link does not download
+		_, _, _, err = attachments.OpenFile(token)
+		require.ErrorIs(t, err, service.ErrInvalidFileToken)
+	})
+
+	t.Run("images get a thumbnail", func(t *testing.T) {
+		attachment := upload(t, "chart.png", "image/png", pngBytes(t, 800, 400))
+		require.Equal(t, 800, attachment.Width)
+		require.Equal(t, 400, attachment.Height)
+
+		links, err := attachments.GetAttachment(f.owner.ID, attachment.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, links.ThumbnailURL)
+
+		_, file, contentType, err := attachments.OpenFile(uploadToken(links.ThumbnailURL))
+		require.NoError(t, err)
+		defer file.Close()
+		require.Equal(t, "image/png", contentType)
+		thumb, err := png.Decode(file)
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 320, 160), thumb.Bounds())
+
+		_, err = attachments.Upload(uploadToken(links.DownloadURL), "image/png", bytes.NewReader(pngBytes(t, 1, 1)))
+		require.ErrorIs(t, err, service.ErrInvalidFileToken)
+	})
+
+	t.Run("sent with a message over kafka", func(t *testing.T) {
+		notes := upload(t, "notes.txt", "text/plain", []byte("minutes of the meeting"))
+		producer := &recordingProducer{}
+		kafka := &service.KafkaService{Producer: producer, MessageService: messages, Attachments: attachments}
+
+		kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{
+			UserId: uint32(f.member.ID), RoomId: uint32(f.room.ID), MsgType: "message", Content: []byte("see notes"),
+			Attachments: []*kafkapb.AttachmentRef{{Id: uint64(notes.ID), Filename: "forged.exe", Size: 1}},
+		})
+		event := producer.nth(t, 1)
+		require.Len(t, event.Attachments, 1)
+		require.Equal(t, "notes.txt", event.Attachments[0].Filename)
+		require.Equal(t, int64(22), event.Attachments[0].Size)
+		require.Equal(t, "/api/attachments/"+strconv.FormatUint(uint64(notes.ID), 10), event.Attachments[0].Url)
+
+		page, err := messages.GetMessagesPage(f.member.ID, f.room.ID, 0, 10)
+		require.NoError(t, err)
+		sent := page[len(page)-1]
+		require.Len(t, sent.Attachments, 1)
+		require.Equal(t, notes.ID, sent.Attachments[0].ID)
+
+		// the same attachment cannot be sent twice
+		kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{
+			UserId: uint32(f.member.ID), RoomId: uint32(f.room.ID), MsgType: "message", Content: []byte("again"),
+			Attachments: []*kafkapb.AttachmentRef{{Id: uint64(notes.ID)}},
+		})
+		require.Empty(t, producer.nth(t, 2).Attachments)
+
+		links, err := attachments.GetAttachment(f.owner.ID, notes.ID)
+		require.NoError(t, err)
+		_, file, _, err := attachments.OpenFile(uploadToken(links.DownloadURL))
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		file.Close()
+		require.Equal(t, "minutes of the meeting", string(data))
+
+		// deleting the message removes the file
+		require.NoError(t, messages.DeleteMessage(sent.ID, f.member.ID))
+		_, _, _, err = attachments.OpenFile(uploadToken(links.DownloadURL))
+		require.ErrorIs(t, err, service.ErrAttachmentNotFound)
+		_, err = store.Get("attachments/" + strconv.FormatUint(uint64(notes.ID), 10) + "/original")
+		require.ErrorIs(t, err, blob.ErrNotFound)
+	})
+
+	t.Run("only the uploader sends an attachment, in its room", func(t *testing.T) {
+		notes := upload(t, "notes.txt", "text/plain", []byte("hello"))
+		_, err := attachments.PrepareForMessage(f.owner.ID, f.room.ID, []uint{notes.ID})
+		require.ErrorIs(t, err, service.ErrAttachmentNotFound)
+
+		other := model.ChatRoom{Name: "other"}
+		require.NoError(t, f.repos.ChatRoom.Create(&other))
+		_, err = attachments.PrepareForMessage(f.member.ID, other.ID, []uint{notes.ID})
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+
+		pending, err := attachments.CreateUpload(f.member.ID, f.room.ID, "later.txt", "text/plain", 3)
+		require.NoError(t, err)
+		_, err = attachments.PrepareForMessage(f.member.ID, f.room.ID, []uint{pending.Attachment.ID})
+		require.ErrorIs(t, err, service.ErrInvalidAttachment)
+		_, err = attachments.GetAttachment(f.owner.ID, pending.Attachment.ID)
+		require.ErrorIs(t, err, service.ErrAttachmentNotFound)
+	})
+}
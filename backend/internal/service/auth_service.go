@@ -1,7 +1,9 @@
 package service
 
 import (
+	"crypto/subtle"
 	"errors"
+	"strings"
 	"time"
 
 	"backend/internal/cache"
@@ -16,6 +18,40 @@ type authService struct {
 	cache cache.Cache[BlockEntry]
 }
 
+var (
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// RefreshReuseError is returned by Refresh when a rotated refresh token is presented again.
+// The session it names has been revoked. It matches ErrRefreshTokenReused with errors.Is.
+type RefreshReuseError struct {
+	UserID    uint
+	SessionID string
+}
+
+func (e *RefreshReuseError) Error() string { return ErrRefreshTokenReused.Error() }
+
+func (e *RefreshReuseError) Unwrap() error { return ErrRefreshTokenReused }
+
+// ClientInfo describes the device a login comes from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// AuthTokens is what a client gets back from logging in or refreshing.
+type AuthTokens struct {
+	UserID       uint
+	AccessToken  string
+	JTI          string
+	ExpiresAt    time.Time
+	SessionID    string
+	RefreshToken string
+}
+
 type BlockEntry struct {
 	JTI string
 	Exp time.Time
@@ -23,11 +59,15 @@ type BlockEntry struct {
 
 type AuthService interface {
 	Register(user *model.User) error
-	Login(username, password string) (uint, string, string, string, error)
+	Login(username, password string, client ClientInfo) (*AuthTokens, error)
+	Refresh(refreshToken string) (*AuthTokens, error)
 	Logout(tokenStr string) error
 	ValidateJWT(tokenStr string) (*model.User, *model.UserSession, error)
 	ForceLogoutAll(userID uint) error
 	ForceLogoutOne(userID uint, sessionID string) error
+	ForceLogoutOthers(userID uint, keepSessionID string) ([]string, error)
+	ListSessions(userID uint) ([]model.UserSession, error)
+	RevokeSession(userID uint, sessionID string) error
 	GetUserIDByUsername(username string) (uint, error)
 	Block(jti string, exp time.Time) error
 	IsBlocked(jti string) bool
@@ -48,37 +88,126 @@ func (s *authService) Register(user *model.User) error {
 	return s.repos.User.Create(user)
 }
 
-func (s *authService) Login(username, password string) (uint, string, string, string, error) {
+func (s *authService) Login(username, password string, client ClientInfo) (*AuthTokens, error) {
 	user, err := s.repos.User.GetByUsername(username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, "", "", "", errors.New("invalid username or password")
+			return nil, ErrInvalidCredentials
 		}
-		return 0, "", "", "", err
+		return nil, err
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return 0, "", "", "", errors.New("invalid username or password")
+		return nil, ErrInvalidCredentials
+	}
+
+	return startSession(s.repos, user, client)
+}
+
+// startSession opens a new session for user and issues its first token pair.
+func startSession(repos *repo.RepoContainer, user *model.User, client ClientInfo) (*AuthTokens, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	session := model.UserSession{
-		UserID:     user.ID,
-		SessionID:  generateSessionID(),
-		CreatedAt:  time.Now(),
-		LastUsedAt: time.Now(),
-		ExpiresAt:  time.Now().Add(7 * 24 * time.Hour),
+		UserID:         user.ID,
+		SessionID:      sessionID,
+		SessionVersion: 1,
+		RefreshToken:   hashToken(refreshToken),
+		DeviceInfo:     client.UserAgent,
+		IPAddress:      client.IPAddress,
+		CreatedAt:      time.Now(),
+		LastUsedAt:     time.Now(),
+		ExpiresAt:      time.Now().Add(7 * 24 * time.Hour),
 	}
 
-	if err := s.repos.UserSession.Create(&session); err != nil {
-		return 0, "", "", "", err
+	if err := repos.UserSession.Create(&session); err != nil {
+		return nil, err
+	}
+
+	return issueTokens(user, &session, refreshToken)
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh token. Each refresh
+// bumps the session version, so access tokens issued before it stop validating. Presenting a
+// refresh token that was already rotated out means it leaked, so the whole session is revoked.
+func (s *authService) Refresh(refreshToken string) (*AuthTokens, error) {
+	sessionID, _, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.repos.UserSession.GetBySessionID(sessionID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.Revoked || session.RefreshToken == "" || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	oldHash := hashToken(refreshToken)
+	if subtle.ConstantTimeCompare([]byte(oldHash), []byte(session.RefreshToken)) != 1 {
+		return nil, s.revokeReusedSession(session)
+	}
+
+	next, err := newRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.repos.UserSession.RotateRefreshToken(session.ID, oldHash, hashToken(next))
+	if err != nil {
+		return nil, err
+	}
+	if rotated == 0 {
+		// another request rotated this token first
+		return nil, s.revokeReusedSession(session)
+	}
+	session.SessionVersion++
+
+	user, err := s.repos.User.GetByID(session.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return issueTokens(user, session, next)
+}
+
+// revokeReusedSession revokes a session whose refresh token leaked and returns the error
+// Refresh reports it with.
+func (s *authService) revokeReusedSession(session *model.UserSession) error {
+	if err := s.ForceLogoutOne(session.UserID, session.SessionID); err != nil {
+		return err
 	}
+	return &RefreshReuseError{UserID: session.UserID, SessionID: session.SessionID}
+}
 
-	token, jti, _, err := utils.GenerateJWT(user.Username, session.SessionID)
+func issueTokens(user *model.User, session *model.UserSession, refreshToken string) (*AuthTokens, error) {
+	token, jti, exp, err := utils.GenerateJWT(user.ID, user.Username, session.SessionID, session.SessionVersion)
 	if err != nil {
-		return 0, "", "", "", err
+		return nil, err
 	}
+	return &AuthTokens{
+		UserID:       user.ID,
+		AccessToken:  token,
+		JTI:          jti,
+		ExpiresAt:    exp,
+		SessionID:    session.SessionID,
+		RefreshToken: refreshToken,
+	}, nil
+}
 
-	return user.ID, token, jti, session.SessionID, nil
+// newRefreshToken returns an opaque refresh token prefixed with its session ID.
+func newRefreshToken(sessionID string) (string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return sessionID + "." + secret, nil
 }
 
 func (s *authService) Logout(tokenStr string) error {
@@ -103,8 +232,7 @@ func (s *authService) Logout(tokenStr string) error {
 	if err != nil {
 		return err
 	}
-	s.ForceLogoutAll(userID)
-	return nil
+	return s.ForceLogoutOne(userID, claims.SessionID)
 }
 
 func (s *authService) ValidateJWT(tokenStr string) (*model.User, *model.UserSession, error) {
@@ -112,6 +240,9 @@ func (s *authService) ValidateJWT(tokenStr string) (*model.User, *model.UserSess
 	if err != nil {
 		return nil, nil, errors.New("invalid token")
 	}
+	if s.cache != nil && s.IsBlocked(claims.ID) {
+		return nil, nil, errors.New("token revoked")
+	}
 
 	username := claims.Username
 	userID, err := s.GetUserIDByUsername(username)
@@ -124,6 +255,9 @@ func (s *authService) ValidateJWT(tokenStr string) (*model.User, *model.UserSess
 	if err != nil {
 		return nil, nil, errors.New("session invalid or revoked")
 	}
+	if claims.SessionVersion != session.SessionVersion {
+		return nil, nil, errors.New("token superseded by refresh")
+	}
 
 	user, err := s.repos.User.GetByID(userID)
 	if err != nil {
@@ -134,16 +268,77 @@ func (s *authService) ValidateJWT(tokenStr string) (*model.User, *model.UserSess
 }
 
 func (s *authService) ForceLogoutAll(userID uint) error {
-	return s.repos.UserSession.RevokeAllByUserID(userID)
+	sessions, err := s.repos.UserSession.ListActiveByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repos.UserSession.RevokeAllByUserID(userID); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.blockSession(session.SessionID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *authService) ForceLogoutOne(userID uint, sessionID string) error {
-	return s.repos.UserSession.RevokeOne(userID, sessionID)
+	if err := s.repos.UserSession.RevokeOne(userID, sessionID); err != nil {
+		return err
+	}
+	return s.blockSession(sessionID)
+}
+
+// blockSession puts sessionID on the revocation list until the last access token it may have
+// issued expires. Replicas that only read the list, like the connection gateway, never see
+// the session table, so revoking the row alone would leave its tokens usable.
+func (s *authService) blockSession(sessionID string) error {
+	if s.cache == nil {
+		return nil
+	}
+	exp := time.Now().Add(utils.AccessTokenLifetime)
+	return s.Block(RevokedSessionKeyPrefix+sessionID, exp)
+}
+
+// ForceLogoutOthers revokes every active session of userID but keepSessionID and returns the
+// ids of the sessions it revoked.
+func (s *authService) ForceLogoutOthers(userID uint, keepSessionID string) ([]string, error) {
+	sessions, err := s.repos.UserSession.ListActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	var revoked []string
+	for _, session := range sessions {
+		if session.SessionID == keepSessionID {
+			continue
+		}
+		if err := s.ForceLogoutOne(userID, session.SessionID); err != nil {
+			return revoked, err
+		}
+		revoked = append(revoked, session.SessionID)
+	}
+	return revoked, nil
+}
+
+// ListSessions returns the devices userID is currently logged in on.
+func (s *authService) ListSessions(userID uint) ([]model.UserSession, error) {
+	return s.repos.UserSession.ListActiveByUserID(userID)
+}
+
+// RevokeSession logs one of userID's devices out.
+func (s *authService) RevokeSession(userID uint, sessionID string) error {
+	if _, err := s.repos.UserSession.FindValidSession(sessionID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return s.ForceLogoutOne(userID, sessionID)
 }
 
 func (s *authService) Block(jti string, exp time.Time) error {
-	s.cache.Set(jti, BlockEntry{JTI: jti, Exp: exp}, time.Until(exp))
-	return nil
+	return s.cache.Set(jti, BlockEntry{JTI: jti, Exp: exp}, time.Until(exp))
 }
 
 func (s *authService) IsBlocked(jti string) bool {
@@ -159,8 +354,12 @@ func (s *authService) Clean(jti string) {
 	}()
 }
 
-func generateSessionID() string {
-	return time.Now().Format("20060102150405") + "-" + RandomString(8)
+func generateSessionID() (string, error) {
+	suffix, err := randomToken(9)
+	if err != nil {
+		return "", err
+	}
+	return time.Now().Format("20060102150405") + "-" + suffix, nil
 }
 
 func RandomString(n int) string {
@@ -21,7 +21,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	assert.NoError(t, err, "failed to connect database")
 
 	// Migrate schema
-	err = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{})
+	err = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{}, &model.MessageRevision{}, &model.MessageReaction{}, &model.RoomBan{}, &model.RoomInvite{}, &model.BlockedJWT{}, &model.AccountToken{}, &model.UserIdentity{}, &model.APIToken{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.IncomingWebhook{}, &model.BotCommand{}, &model.Mention{}, &model.Attachment{}, &model.PinnedMessage{})
 	assert.NoError(t, err, "failed to migrate database")
 
 	return db
@@ -85,28 +85,141 @@ func TestLogin(t *testing.T) {
 	require.NoError(t, repos.User.Create(user))
 
 	// Case 1: invalid username
-	_, _, _, _, err := auth.Login("wronguser", password)
+	_, err := auth.Login("wronguser", password, service.ClientInfo{})
 	require.Error(t, err)
 	require.Equal(t, "invalid username or password", err.Error())
 
 	// Case 2: invalid password
-	_, _, _, _, err = auth.Login("loginuser", "wrongpassword")
+	_, err = auth.Login("loginuser", "wrongpassword", service.ClientInfo{})
 	require.Error(t, err)
 	require.Equal(t, "invalid username or password", err.Error())
 
 	// Case 3: successful login
-	id, token, jti, sessionID, err := auth.Login("loginuser", password)
+	tokens, err := auth.Login("loginuser", password, service.ClientInfo{})
 	require.NoError(t, err)
-	require.Equal(t, user.ID, id)
-	require.NotEmpty(t, token)
-	require.NotEmpty(t, jti)
-	require.NotEmpty(t, sessionID)
+	require.Equal(t, user.ID, tokens.UserID)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.NotEmpty(t, tokens.JTI)
+	require.NotEmpty(t, tokens.SessionID)
+	require.NotEmpty(t, tokens.RefreshToken)
 
-	// Verify session in DB
+	// Verify session in DB, which only keeps a hash of the refresh token
 	var session model.UserSession
-	err = db.Where("session_id = ?", sessionID).First(&session).Error
+	err = db.Where("session_id = ?", tokens.SessionID).First(&session).Error
 	require.NoError(t, err)
 	require.Equal(t, user.ID, session.UserID)
+	require.NotEmpty(t, session.RefreshToken)
+	require.NotEqual(t, tokens.RefreshToken, session.RefreshToken)
+}
+
+func TestSessions(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	auth := service.NewAuthService(repos, setupTestCache(t))
+
+	hashed, _ := utils.HashPassword("password123")
+	user := &model.User{Username: "traveler", Email: "traveler@example.com", Password: hashed}
+	require.NoError(t, repos.User.Create(user))
+
+	laptop, err := auth.Login("traveler", "password123", service.ClientInfo{UserAgent: "laptop", IPAddress: "10.0.0.1"})
+	require.NoError(t, err)
+	phone, err := auth.Login("traveler", "password123", service.ClientInfo{UserAgent: "phone", IPAddress: "10.0.0.2"})
+	require.NoError(t, err)
+	tablet, err := auth.Login("traveler", "password123", service.ClientInfo{UserAgent: "tablet", IPAddress: "10.0.0.3"})
+	require.NoError(t, err)
+
+	sessions, err := auth.ListSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	// revoking one device leaves the others logged in
+	require.NoError(t, auth.RevokeSession(user.ID, laptop.SessionID))
+	_, _, err = auth.ValidateJWT(laptop.AccessToken)
+	require.Error(t, err)
+	// the gateway only sees the revocation list, so the session must be on it
+	require.True(t, auth.IsBlocked(service.RevokedSessionKeyPrefix+laptop.SessionID))
+	require.False(t, auth.IsBlocked(service.RevokedSessionKeyPrefix+phone.SessionID))
+	_, _, err = auth.ValidateJWT(phone.AccessToken)
+	require.NoError(t, err)
+
+	err = auth.RevokeSession(user.ID, laptop.SessionID)
+	require.ErrorIs(t, err, service.ErrSessionNotFound)
+	err = auth.RevokeSession(user.ID+1, phone.SessionID)
+	require.ErrorIs(t, err, service.ErrSessionNotFound)
+
+	// logging out only ends the current session
+	require.NoError(t, auth.Logout(phone.AccessToken))
+	_, _, err = auth.ValidateJWT(tablet.AccessToken)
+	require.NoError(t, err)
+
+	sessions, err = auth.ListSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, tablet.SessionID, sessions[0].SessionID)
+	require.Equal(t, "tablet", sessions[0].DeviceInfo)
+	require.Equal(t, "10.0.0.3", sessions[0].IPAddress)
+
+	// single-session logins end every other device but the new one
+	desktop, err := auth.Login("traveler", "password123", service.ClientInfo{UserAgent: "desktop", IPAddress: "10.0.0.4"})
+	require.NoError(t, err)
+	revoked, err := auth.ForceLogoutOthers(user.ID, desktop.SessionID)
+	require.NoError(t, err)
+	require.Equal(t, []string{tablet.SessionID}, revoked)
+	_, _, err = auth.ValidateJWT(desktop.AccessToken)
+	require.NoError(t, err)
+	_, _, err = auth.ValidateJWT(tablet.AccessToken)
+	require.Error(t, err)
+	require.True(t, auth.IsBlocked(service.RevokedSessionKeyPrefix+tablet.SessionID))
+
+	require.NoError(t, auth.ForceLogoutAll(user.ID))
+	require.True(t, auth.IsBlocked(service.RevokedSessionKeyPrefix+desktop.SessionID))
+}
+
+func TestRefresh(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	auth := service.NewAuthService(repos, nil)
+
+	hashed, _ := utils.HashPassword("password123")
+	user := &model.User{Username: "refresher", Email: "refresh@example.com", Password: hashed}
+	require.NoError(t, repos.User.Create(user))
+
+	first, err := auth.Login("refresher", "password123", service.ClientInfo{})
+	require.NoError(t, err)
+
+	t.Run("rotation supersedes old access token", func(t *testing.T) {
+		second, err := auth.Refresh(first.RefreshToken)
+		require.NoError(t, err)
+		require.Equal(t, first.SessionID, second.SessionID)
+		require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		_, _, err = auth.ValidateJWT(first.AccessToken)
+		require.Error(t, err)
+		_, session, err := auth.ValidateJWT(second.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, 2, session.SessionVersion)
+
+		third, err := auth.Refresh(second.RefreshToken)
+		require.NoError(t, err)
+
+		// reusing a rotated token revokes the session, including its newest tokens
+		_, err = auth.Refresh(second.RefreshToken)
+		require.ErrorIs(t, err, service.ErrRefreshTokenReused)
+		var reused *service.RefreshReuseError
+		require.ErrorAs(t, err, &reused)
+		require.Equal(t, second.SessionID, reused.SessionID)
+		_, _, err = auth.ValidateJWT(third.AccessToken)
+		require.Error(t, err)
+		_, err = auth.Refresh(third.RefreshToken)
+		require.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	})
+
+	t.Run("malformed or unknown token", func(t *testing.T) {
+		_, err := auth.Refresh("garbage")
+		require.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+		_, err = auth.Refresh("nosuchsession.secret")
+		require.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	})
 }
 
 func TestValidateJWT(t *testing.T) {
@@ -122,17 +235,18 @@ func TestValidateJWT(t *testing.T) {
 	require.NoError(t, repos.User.Create(&user))
 
 	session := model.UserSession{
-		UserID:     user.ID,
-		SessionID:  "session123",
-		CreatedAt:  time.Now(),
-		LastUsedAt: time.Now(),
-		ExpiresAt:  time.Now().Add(time.Hour),
-		Revoked:    false,
+		UserID:         user.ID,
+		SessionID:      "session123",
+		SessionVersion: 1,
+		CreatedAt:      time.Now(),
+		LastUsedAt:     time.Now(),
+		ExpiresAt:      time.Now().Add(time.Hour),
+		Revoked:        false,
 	}
 	require.NoError(t, repos.UserSession.Create(&session))
 
 	// Generate valid JWT
-	token, _, _, err := utils.GenerateJWT(user.Username, session.SessionID)
+	token, _, _, err := utils.GenerateJWT(user.ID, user.Username, session.SessionID, session.SessionVersion)
 	require.NoError(t, err)
 
 	// -------------------------------
@@ -153,30 +267,38 @@ func TestValidateJWT(t *testing.T) {
 	// -------------------------------
 	// Case 3: Username not found
 	// -------------------------------
-	tokenBadUser, _, _, err := utils.GenerateJWT("does_not_exist", session.SessionID)
+	tokenBadUser, _, _, err := utils.GenerateJWT(user.ID, "does_not_exist", session.SessionID, session.SessionVersion)
 	require.NoError(t, err)
 
 	_, _, err = s.ValidateJWT(tokenBadUser)
 	require.Error(t, err)
 	require.Equal(t, "username is invalid", err.Error())
 
-	// Case 4: Session revoked
+	// Case 4: Token from an older session version
+	tokenStale, _, _, err := utils.GenerateJWT(user.ID, user.Username, session.SessionID, session.SessionVersion-1)
+	require.NoError(t, err)
+
+	_, _, err = s.ValidateJWT(tokenStale)
+	require.Error(t, err)
+	require.Equal(t, "token superseded by refresh", err.Error())
+
+	// Case 5: Session revoked
 	require.NoError(t, repos.UserSession.RevokeOne(user.ID, session.SessionID))
 
-	tokenRevoked, _, _, err := utils.GenerateJWT(user.Username, session.SessionID)
+	tokenRevoked, _, _, err := utils.GenerateJWT(user.ID, user.Username, session.SessionID, session.SessionVersion)
 	require.NoError(t, err)
 
 	_, _, err = s.ValidateJWT(tokenRevoked)
 	require.Error(t, err)
 	require.Equal(t, "session invalid or revoked", err.Error())
 
-	// Case 5: User deleted — new DB, session repo only (no user for "tester")
+	// Case 6: User deleted — new DB, session repo only (no user for "tester")
 	db2 := setupTestDB(t)
 	repos2 := repo.NewRepoContainer(db2)
 	s2 := service.NewAuthService(repos2, nil)
 	require.NoError(t, repos2.UserSession.Create(&session))
 
-	tokenMissingUser, _, _, err := utils.GenerateJWT("tester", session.SessionID)
+	tokenMissingUser, _, _, err := utils.GenerateJWT(user.ID, "tester", session.SessionID, session.SessionVersion)
 	require.NoError(t, err)
 
 	_, _, err = s2.ValidateJWT(tokenMissingUser)
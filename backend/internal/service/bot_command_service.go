@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCommand     = errors.New("invalid command")
+	ErrCommandTaken       = errors.New("command name is taken")
+	ErrBotCommandNotFound = errors.New("command not found")
+)
+
+var botCommandName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// BotCommandService lets bots register the slash commands they serve.
+type BotCommandService interface {
+	RegisterCommand(botID uint, name, description, callbackURL string) (cmd *model.BotCommand, secret string, err error)
+	ListCommands(botID uint) ([]model.BotCommand, error)
+	DeleteCommand(botID uint, name string) error
+}
+
+type botCommandService struct {
+	repos    *repo.RepoContainer
+	registry *CommandRegistry
+}
+
+func NewBotCommandService(repos *repo.RepoContainer, registry *CommandRegistry) BotCommandService {
+	return &botCommandService{repos: repos, registry: registry}
+}
+
+// RegisterCommand registers /name for botID. The returned secret signs the callbacks and is
+// not shown again.
+func (s *botCommandService) RegisterCommand(botID uint, name, description, callbackURL string) (*model.BotCommand, string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+	if !botCommandName.MatchString(name) {
+		return nil, "", fmt.Errorf("%w: name must be 1 to 32 lowercase letters, digits, dashes or underscores", ErrInvalidCommand)
+	}
+	if err := validateWebhookURL(callbackURL, s.registry != nil && s.registry.allowPrivateNetworks); err != nil {
+		if errors.Is(err, ErrPrivateAddress) {
+			return nil, "", fmt.Errorf("%w: %w", ErrInvalidCommand, ErrPrivateAddress)
+		}
+		return nil, "", fmt.Errorf("%w: callback_url must be an absolute http or https URL", ErrInvalidCommand)
+	}
+	if s.registry != nil && s.registry.IsBuiltin(name) {
+		return nil, "", ErrCommandTaken
+	}
+	if _, err := s.repos.BotCommand.GetByName(name); err == nil {
+		return nil, "", ErrCommandTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	cmd := &model.BotCommand{
+		BotID:       botID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CallbackURL: callbackURL,
+		Secret:      secret,
+	}
+	if err := s.repos.BotCommand.Create(cmd); err != nil {
+		return nil, "", err
+	}
+	return cmd, secret, nil
+}
+
+func (s *botCommandService) ListCommands(botID uint) ([]model.BotCommand, error) {
+	return s.repos.BotCommand.ListByBotID(botID)
+}
+
+func (s *botCommandService) DeleteCommand(botID uint, name string) error {
+	n, err := s.repos.BotCommand.Delete(botID, strings.ToLower(strings.TrimPrefix(name, "/")))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBotCommandNotFound
+	}
+	return nil
+}
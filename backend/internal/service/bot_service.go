@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix marks API tokens so they can be told apart from session JWTs.
+const APITokenPrefix = "bot_"
+
+// apiTokenTouchInterval limits how often LastUsedAt is written for a busy token.
+const apiTokenTouchInterval = time.Minute
+
+var (
+	ErrInvalidAPIToken  = errors.New("invalid or revoked api token")
+	ErrAPITokenNotFound = errors.New("api token not found")
+	ErrNotBot           = errors.New("user is not a bot")
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrScopeDenied      = errors.New("api token is not allowed to do this")
+)
+
+// knownScopes maps each scope to whether it can be narrowed to a room.
+var knownScopes = map[string]bool{
+	model.ScopeMessagesRead:  true,
+	model.ScopeMessagesWrite: true,
+	model.ScopeCommandsWrite: false,
+}
+
+// BotService manages bot users and the API tokens they authenticate with.
+type BotService interface {
+	CreateBot(username string) (*model.User, error)
+	ListBots() ([]model.User, error)
+	CreateToken(botID uint, name string, scopes []string, ttl time.Duration) (token string, info *model.APIToken, err error)
+	ListTokens(botID uint) ([]model.APIToken, error)
+	RevokeToken(id uint) error
+	Authenticate(token string) (*model.User, *model.APIToken, error)
+	Authorize(token *model.APIToken, scope string, roomID uint) error
+}
+
+type botService struct {
+	repos *repo.RepoContainer
+}
+
+func NewBotService(repos *repo.RepoContainer) BotService {
+	return &botService{repos: repos}
+}
+
+// CreateBot registers a bot user. Bots have no password, so they can only authenticate with API tokens.
+func (s *botService) CreateBot(username string) (*model.User, error) {
+	if username == "" {
+		return nil, errors.New("user name is required")
+	}
+	bot := &model.User{
+		Username:  username,
+		Email:     username + "@bots.invalid",
+		IsBot:     true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repos.User.Create(bot); err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+func (s *botService) ListBots() ([]model.User, error) {
+	return s.repos.User.ListBots()
+}
+
+// CreateToken issues an API token for botID. The token itself is only returned here; a ttl of
+// zero means the token does not expire.
+func (s *botService) CreateToken(botID uint, name string, scopes []string, ttl time.Duration) (string, *model.APIToken, error) {
+	if err := s.requireBot(botID); err != nil {
+		return "", nil, err
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if err := validateScope(scope); err != nil {
+			return "", nil, err
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	token := APITokenPrefix + secret
+	info := &model.APIToken{
+		UserID:    botID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    strings.Join(scopes, " "),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		info.ExpiresAt = &expiresAt
+	}
+	if err := s.repos.APIToken.Create(info); err != nil {
+		return "", nil, err
+	}
+	return token, info, nil
+}
+
+func (s *botService) ListTokens(botID uint) ([]model.APIToken, error) {
+	if err := s.requireBot(botID); err != nil {
+		return nil, err
+	}
+	return s.repos.APIToken.ListByUserID(botID)
+}
+
+func (s *botService) RevokeToken(id uint) error {
+	if _, err := s.repos.APIToken.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPITokenNotFound
+		}
+		return err
+	}
+	return s.repos.APIToken.Revoke(id)
+}
+
+// Authenticate resolves an API token to its bot.
+func (s *botService) Authenticate(token string) (*model.User, *model.APIToken, error) {
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	info, err := s.repos.APIToken.GetByHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if !info.Usable(now) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	user, err := s.repos.User.GetByID(info.UserID)
+	if err != nil || !user.IsBot {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	if info.LastUsedAt == nil || now.Sub(*info.LastUsedAt) > apiTokenTouchInterval {
+		if err := s.repos.APIToken.Touch(info.ID, now); err == nil {
+			info.LastUsedAt = &now
+		}
+	}
+	return user, info, nil
+}
+
+// Authorize checks that token grants scope in roomID and that its bot is a member of the room.
+func (s *botService) Authorize(token *model.APIToken, scope string, roomID uint) error {
+	if token == nil || !token.Allows(scope, roomID) {
+		return ErrScopeDenied
+	}
+	member, err := s.repos.UserChatRoom.Exists(token.UserID, roomID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return fmt.Errorf("%w: bot is not a member of room %d", ErrScopeDenied, roomID)
+	}
+	return nil
+}
+
+func (s *botService) requireBot(userID uint) error {
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !user.IsBot {
+		return ErrNotBot
+	}
+	return nil
+}
+
+// validateScope accepts a known scope, optionally narrowed to a room as "<scope>:<room id>".
+func validateScope(scope string) error {
+	if _, ok := knownScopes[scope]; ok {
+		return nil
+	}
+	i := strings.LastIndex(scope, ":")
+	if i > 0 && knownScopes[scope[:i]] {
+		if id, err := strconv.ParseUint(scope[i+1:], 10, 64); err == nil && id > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+)
+
+func TestBotService(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	bots := service.NewBotService(repos)
+
+	bot, err := bots.CreateBot("helper")
+	require.NoError(t, err)
+	require.True(t, bot.IsBot)
+	human := model.User{Username: "dave", Email: "dave@test.com", Password: "x"}
+	require.NoError(t, repos.User.Create(&human))
+
+	lobby := model.ChatRoom{Name: "lobby"}
+	other := model.ChatRoom{Name: "other"}
+	require.NoError(t, repos.ChatRoom.Create(&lobby))
+	require.NoError(t, repos.ChatRoom.Create(&other))
+	for _, room := range []model.ChatRoom{lobby, other} {
+		require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: bot.ID, ChatRoomID: room.ID, JoinedAt: time.Now()}))
+	}
+
+	t.Run("tokens are only issued to bots with known scopes", func(t *testing.T) {
+		_, _, err := bots.CreateToken(human.ID, "nope", []string{model.ScopeMessagesWrite}, 0)
+		require.ErrorIs(t, err, service.ErrNotBot)
+		_, _, err = bots.CreateToken(bot.ID+100, "nope", []string{model.ScopeMessagesWrite}, 0)
+		require.ErrorIs(t, err, service.ErrUserNotFound)
+		for _, scopes := range [][]string{nil, {"admin"}, {"messages:write:lobby"}, {"messages:write:0"}} {
+			_, _, err = bots.CreateToken(bot.ID, "nope", scopes, 0)
+			require.ErrorIs(t, err, service.ErrInvalidScope, "%v", scopes)
+		}
+	})
+
+	t.Run("authenticate and revoke", func(t *testing.T) {
+		token, info, err := bots.CreateToken(bot.ID, "ci", []string{model.ScopeMessagesWrite}, time.Hour)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, service.APITokenPrefix))
+		require.NotContains(t, info.TokenHash, token)
+
+		user, got, err := bots.Authenticate(token)
+		require.NoError(t, err)
+		require.Equal(t, bot.ID, user.ID)
+		require.Equal(t, info.ID, got.ID)
+		require.NotNil(t, got.LastUsedAt)
+
+		_, _, err = bots.Authenticate(token + "x")
+		require.ErrorIs(t, err, service.ErrInvalidAPIToken)
+
+		require.NoError(t, bots.RevokeToken(info.ID))
+		_, _, err = bots.Authenticate(token)
+		require.ErrorIs(t, err, service.ErrInvalidAPIToken)
+		require.ErrorIs(t, bots.RevokeToken(info.ID+100), service.ErrAPITokenNotFound)
+	})
+
+	t.Run("expired tokens are rejected", func(t *testing.T) {
+		token, info, err := bots.CreateToken(bot.ID, "short", []string{model.ScopeMessagesRead}, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, db.Model(info).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+		_, _, err = bots.Authenticate(token)
+		require.ErrorIs(t, err, service.ErrInvalidAPIToken)
+	})
+
+	t.Run("authorize checks scope, room and membership", func(t *testing.T) {
+		scope := fmt.Sprintf("%s:%d", model.ScopeMessagesWrite, lobby.ID)
+		token, _, err := bots.CreateToken(bot.ID, "lobby", []string{scope, model.ScopeMessagesRead}, 0)
+		require.NoError(t, err)
+		_, info, err := bots.Authenticate(token)
+		require.NoError(t, err)
+
+		require.NoError(t, bots.Authorize(info, model.ScopeMessagesWrite, lobby.ID))
+		require.ErrorIs(t, bots.Authorize(info, model.ScopeMessagesWrite, other.ID), service.ErrScopeDenied)
+		require.NoError(t, bots.Authorize(info, model.ScopeMessagesRead, other.ID))
+
+		// reading a room the bot has not joined is still denied
+		hidden := model.ChatRoom{Name: "hidden"}
+		require.NoError(t, repos.ChatRoom.Create(&hidden))
+		require.ErrorIs(t, bots.Authorize(info, model.ScopeMessagesRead, hidden.ID), service.ErrScopeDenied)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		list, err := bots.ListBots()
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, "helper", list[0].Username)
+
+		tokens, err := bots.ListTokens(bot.ID)
+		require.NoError(t, err)
+		require.Len(t, tokens, 3)
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+)
+
+// RoomUpdatedEventType is the msg_type of the event sent to a room when its details change.
+const RoomUpdatedEventType = "room_updated"
+
+const (
+	maxDice      = 20
+	maxDiceSides = 1000
+)
+
+// RegisterBuiltinCommands registers /help, /topic, /invite, /mute, /unmute and /roll.
+func RegisterBuiltinCommands(r *CommandRegistry, repos *repo.RepoContainer, chatRooms ChatRoomService, memberships MembershipService) error {
+	commands := []Command{
+		{
+			Name:        "help",
+			Usage:       "/help",
+			Description: "List the commands you can use in this room",
+			Handler: func(req CommandRequest) (string, error) {
+				var b strings.Builder
+				b.WriteString("Commands:")
+				for _, cmd := range r.Builtins() {
+					fmt.Fprintf(&b, "\n%s - %s", cmd.Usage, cmd.Description)
+				}
+				botCmds, err := repos.BotCommand.ListForChatRoom(req.RoomID)
+				if err != nil {
+					return "", err
+				}
+				for _, cmd := range botCmds {
+					fmt.Fprintf(&b, "\n/%s - %s", cmd.Name, cmd.Description)
+				}
+				return b.String(), nil
+			},
+		},
+		{
+			Name:        "topic",
+			Usage:       "/topic [new topic]",
+			Description: "Show the room topic, or change it (owners and admins)",
+			Handler: func(req CommandRequest) (string, error) {
+				if req.Args == "" {
+					room, err := chatRooms.GetChatRoomByID(req.RoomID)
+					if err != nil {
+						return "", err
+					}
+					if room.Topic == "" {
+						return "This room has no topic.", nil
+					}
+					return "Topic: " + room.Topic, nil
+				}
+				room, err := chatRooms.SetTopic(req.UserID, req.RoomID, req.Args)
+				if err != nil {
+					return "", err
+				}
+				if content, err := json.Marshal(room); err == nil {
+					r.publish(&kafkapb.KafkaEvent{
+						UserId:  uint32(req.UserID),
+						RoomId:  uint32(req.RoomID),
+						MsgType: RoomUpdatedEventType,
+						Content: content,
+					})
+				}
+				return "Topic set to: " + room.Topic, nil
+			},
+		},
+		{
+			Name:        "invite",
+			Usage:       "/invite @username",
+			Description: "Add someone to this room",
+			Handler: func(req CommandRequest) (string, error) {
+				username := strings.TrimPrefix(req.Args, "@")
+				if username == "" || strings.ContainsAny(username, " \t") {
+					return "", errors.New("usage: /invite @username")
+				}
+				if err := memberships.AddUserToChatRoom(req.UserID, username, req.RoomID); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Added %s to the room.", username), nil
+			},
+		},
+		{
+			Name:        "mute",
+			Usage:       "/mute",
+			Description: "Stop getting notified about @room mentions in this room",
+			Handler: func(req CommandRequest) (string, error) {
+				if _, err := repos.UserChatRoom.SetMuted(req.UserID, req.RoomID, true); err != nil {
+					return "", err
+				}
+				return "Muted this room. Type /unmute to undo.", nil
+			},
+		},
+		{
+			Name:        "unmute",
+			Usage:       "/unmute",
+			Description: "Get notified about this room again",
+			Handler: func(req CommandRequest) (string, error) {
+				if _, err := repos.UserChatRoom.SetMuted(req.UserID, req.RoomID, false); err != nil {
+					return "", err
+				}
+				return "Unmuted this room.", nil
+			},
+		},
+		{
+			Name:        "roll",
+			Usage:       "/roll [NdM]",
+			Description: "Roll dice, 1d6 by default",
+			Handler: func(req CommandRequest) (string, error) {
+				dice, sides, err := parseDice(req.Args)
+				if err != nil {
+					return "", err
+				}
+				rolls := make([]string, dice)
+				total := 0
+				for i := range rolls {
+					n := rand.IntN(sides) + 1
+					total += n
+					rolls[i] = strconv.Itoa(n)
+				}
+				if dice == 1 {
+					return fmt.Sprintf("You rolled %dd%d: %d", dice, sides, total), nil
+				}
+				return fmt.Sprintf("You rolled %dd%d: %s (total %d)", dice, sides, strings.Join(rolls, ", "), total), nil
+			},
+		},
+	}
+
+	for _, cmd := range commands {
+		if err := r.Register(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDice parses "NdM", "dM" or "M". An empty spec is one six-sided die.
+func parseDice(spec string) (dice, sides int, err error) {
+	if spec == "" {
+		return 1, 6, nil
+	}
+	usage := fmt.Errorf("usage: /roll [NdM], with up to %d dice of up to %d sides", maxDice, maxDiceSides)
+	count, faces, found := strings.Cut(strings.ToLower(spec), "d")
+	if !found {
+		count, faces = "1", count
+	}
+	if count == "" {
+		count = "1"
+	}
+	if dice, err = strconv.Atoi(count); err != nil || dice < 1 || dice > maxDice {
+		return 0, 0, usage
+	}
+	if sides, err = strconv.Atoi(faces); err != nil || sides < 2 || sides > maxDiceSides {
+		return 0, 0, usage
+	}
+	return dice, sides, nil
+}
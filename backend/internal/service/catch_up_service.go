@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend/internal/aisearch"
+	"backend/internal/cache"
+	"backend/internal/model"
+	"backend/internal/repo"
+)
+
+// CatchUpKeyPrefix namespaces cached catch-up summaries.
+const CatchUpKeyPrefix = "catchup:"
+
+const (
+	// maxCatchUpMessages caps how many unread messages go into one summary; older ones are left out.
+	maxCatchUpMessages = 200
+	catchUpSentences   = 5
+	catchUpTTL         = time.Hour
+)
+
+var ErrSummaryUnavailable = errors.New("summaries are unavailable")
+
+// Summarizer condenses documents into a few sentences. *aisearch.Client implements it.
+type Summarizer interface {
+	Summarize(docs []aisearch.Document, maxSentences int) (*aisearch.Summary, error)
+}
+
+// CatchUp is a digest of the messages a member missed in a room.
+type CatchUp struct {
+	ChatRoomID    uint `json:"chat_room_id"`
+	FromMessageID uint `json:"from_message_id"`
+	ToMessageID   uint `json:"to_message_id"`
+	MessageCount  int  `json:"message_count"`
+	// Truncated is set when there were more unread messages than went into the summary.
+	Truncated bool   `json:"truncated"`
+	Summary   string `json:"summary"`
+	// SourceMessageIDs are the messages the summary was taken from, oldest first.
+	SourceMessageIDs []uint `json:"source_message_ids"`
+}
+
+type CatchUpService interface {
+	CatchUp(userID, chatRoomID uint) (*CatchUp, error)
+}
+
+type catchUpService struct {
+	repos      *repo.RepoContainer
+	summarizer Summarizer
+	cache      cache.Cache[CatchUp]
+}
+
+func NewCatchUpService(repos *repo.RepoContainer, summarizer Summarizer, c cache.Cache[CatchUp]) CatchUpService {
+	return &catchUpService{repos: repos, summarizer: summarizer, cache: c}
+}
+
+// CatchUp summarizes the messages of chatRoomID after the caller's read marker. Summaries are
+// cached per room and message range, so members who missed the same messages share one.
+func (s *catchUpService) CatchUp(userID, chatRoomID uint) (*CatchUp, error) {
+	membership, err := s.repos.UserChatRoom.Get(userID, chatRoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrForbidden
+		}
+		return nil, err
+	}
+
+	// one extra message tells whether anything unread was left out
+	messages, err := s.repos.Message.GetByRoomIDBeforeWithLimit(chatRoomID, 0, maxCatchUpMessages+1)
+	if err != nil {
+		return nil, err
+	}
+	unread := messages[:0]
+	for _, msg := range messages {
+		if msg.ID > membership.LastReadMessageID {
+			unread = append(unread, msg)
+		}
+	}
+	truncated := len(unread) > maxCatchUpMessages
+	if truncated {
+		unread = unread[len(unread)-maxCatchUpMessages:]
+	}
+
+	result := CatchUp{ChatRoomID: chatRoomID, SourceMessageIDs: []uint{}}
+	if len(unread) == 0 {
+		return &result, nil
+	}
+	result.FromMessageID = unread[0].ID
+	result.ToMessageID = unread[len(unread)-1].ID
+	result.MessageCount = len(unread)
+
+	key := fmt.Sprintf("%s%d:%d-%d:%d", CatchUpKeyPrefix, chatRoomID, result.FromMessageID, result.ToMessageID, result.MessageCount)
+	if cached, ok := s.cache.Get(key); ok {
+		cached.Truncated = truncated
+		return &cached, nil
+	}
+
+	summary, err := s.summarizer.Summarize(s.documents(unread), catchUpSentences)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSummaryUnavailable, err)
+	}
+	result.Summary = summary.Summary
+	for _, docID := range summary.Sources {
+		if id, ok := messageIDFromDocument(docID); ok {
+			result.SourceMessageIDs = append(result.SourceMessageIDs, id)
+		}
+	}
+	_ = s.cache.Set(key, result, catchUpTTL)
+
+	result.Truncated = truncated
+	return &result, nil
+}
+
+// documents turns messages into summarizer input, prefixing each with its author's name.
+func (s *catchUpService) documents(messages []model.Message) []aisearch.Document {
+	names := map[uint]string{}
+	docs := make([]aisearch.Document, 0, len(messages))
+	for _, msg := range messages {
+		name, ok := names[msg.UserID]
+		if !ok {
+			if user, err := s.repos.User.GetByID(msg.UserID); err == nil {
+				name = user.Username
+			}
+			names[msg.UserID] = name
+		}
+		text := msg.Content
+		if name != "" {
+			text = name + ": " + text
+		}
+		docs = append(docs, aisearch.Document{
+			ID:       messageDocumentID(msg.ID),
+			Text:     text,
+			Metadata: map[string]any{"message_id": msg.ID, "author_id": msg.UserID},
+		})
+	}
+	return docs
+}
@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/aisearch"
+	"backend/internal/cache"
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+// fakeSummarizer "summarizes" by keeping the first and last documents.
+type fakeSummarizer struct {
+	calls int
+	last  []aisearch.Document
+	err   error
+}
+
+func (f *fakeSummarizer) Summarize(docs []aisearch.Document, maxSentences int) (*aisearch.Summary, error) {
+	f.calls++
+	f.last = docs
+	if f.err != nil {
+		return nil, f.err
+	}
+	first, last := docs[0], docs[len(docs)-1]
+	return &aisearch.Summary{Summary: first.Text + " ... " + last.Text, Sources: []string{first.ID, last.ID}}, nil
+}
+
+func TestCatchUpService(t *testing.T) {
+	f := setupWebhookFixture(t)
+	messages := service.NewMessageService(f.repos)
+	summarizer := &fakeSummarizer{}
+	catchUps := service.NewCatchUpService(f.repos, summarizer, cache.NewTypedCache[service.CatchUp](time.Hour, time.Minute))
+
+	var sent []*model.Message
+	for _, content := range []string{"deploy moved to friday", "who has the rollback plan?", "it is in the wiki"} {
+		msg, err := messages.CreateMessage(f.member.ID, f.room.ID, content)
+		require.NoError(t, err)
+		sent = append(sent, msg)
+	}
+
+	t.Run("summarizes unread messages with their sources", func(t *testing.T) {
+		got, err := catchUps.CatchUp(f.owner.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Equal(t, 3, got.MessageCount)
+		require.Equal(t, sent[0].ID, got.FromMessageID)
+		require.Equal(t, sent[2].ID, got.ToMessageID)
+		require.False(t, got.Truncated)
+		require.Equal(t, "member: deploy moved to friday ... member: it is in the wiki", got.Summary)
+		require.Equal(t, []uint{sent[0].ID, sent[2].ID}, got.SourceMessageIDs)
+		require.Len(t, summarizer.last, 3)
+
+		// the same range is served from the cache
+		_, err = catchUps.CatchUp(f.member.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, summarizer.calls)
+	})
+
+	t.Run("starts after the read marker", func(t *testing.T) {
+		require.NoError(t, f.repos.UserChatRoom.UpdateLastRead(f.owner.ID, f.room.ID, sent[1].ID))
+		got, err := catchUps.CatchUp(f.owner.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, got.MessageCount)
+		require.Equal(t, sent[2].ID, got.SourceMessageIDs[0])
+		require.Equal(t, 2, summarizer.calls)
+
+		require.NoError(t, f.repos.UserChatRoom.UpdateLastRead(f.owner.ID, f.room.ID, sent[2].ID))
+		got, err = catchUps.CatchUp(f.owner.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Zero(t, got.MessageCount)
+		require.Empty(t, got.Summary)
+		require.Equal(t, 2, summarizer.calls)
+	})
+
+	t.Run("members only", func(t *testing.T) {
+		outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "x"}
+		require.NoError(t, f.repos.User.Create(&outsider))
+		_, err := catchUps.CatchUp(outsider.ID, f.room.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("summarizer down", func(t *testing.T) {
+		summarizer.err = errors.New("connection refused")
+		_, err := messages.CreateMessage(f.member.ID, f.room.ID, "anyone there?")
+		require.NoError(t, err)
+		_, err = catchUps.CatchUp(f.owner.ID, f.room.ID)
+		require.ErrorIs(t, err, service.ErrSummaryUnavailable)
+	})
+}
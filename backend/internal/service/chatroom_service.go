@@ -2,61 +2,230 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
 
+	"backend/internal/cache"
 	"backend/internal/model"
 	"backend/internal/repo"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrChatRoomExists     = errors.New("chat room already exists")
+	ErrChatRoomNotFound   = errors.New("chat room not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrDirectWithSelf     = errors.New("cannot start a direct conversation with yourself")
+	ErrTopicTooLong       = errors.New("topic is too long")
+	ErrDescriptionTooLong = errors.New("description is too long")
+	ErrInvalidAvatarURL   = errors.New("avatar must be an http or https URL")
+	ErrReservedRoomName   = errors.New("room names starting with " + directRoomPrefix + " are reserved")
+)
+
+const (
+	// MaxTopicLength caps a room topic, in characters.
+	MaxTopicLength = 250
+	// MaxDescriptionLength caps a room description, in characters.
+	MaxDescriptionLength = 2000
+	maxAvatarURLLength   = 2048
+	// directRoomPrefix starts the names of direct rooms; other rooms cannot use it.
+	directRoomPrefix = "dm:"
+)
+
+// RoomUpdate holds the details of a room to change. Nil fields are left as they are,
+// empty strings clear them.
+type RoomUpdate struct {
+	Topic       *string
+	Description *string
+	AvatarURL   *string
+}
+
 type chatRoomService struct {
 	repos *repo.RepoContainer
+	// cache holds the subscribed rooms per username, shared with the membership service
+	cache cache.Cache[[]model.ChatRoom]
 }
 
-func NewChatRoomService(repos *repo.RepoContainer) *chatRoomService {
-	return &chatRoomService{repos: repos}
+func NewChatRoomService(repos *repo.RepoContainer, c cache.Cache[[]model.ChatRoom]) *chatRoomService {
+	return &chatRoomService{repos: repos, cache: c}
 }
 
 type ChatRoomService interface {
-	CreateChatRoom(name string) (*model.ChatRoom, error)
-	GetAllChatRooms() ([]model.ChatRoom, error)
+	CreateChatRoom(name string, creatorID uint) (*model.ChatRoom, error)
+	CreatePrivateChatRoom(name string, creatorID uint) (*model.ChatRoom, error)
+	GetOrCreateDirectRoom(userID uint, peerUsername string) (*model.ChatRoom, bool, error)
+	GetAllChatRooms(userID uint) ([]model.ChatRoom, error)
 	GetChatRoomByID(id uint) (*model.ChatRoom, error)
-	DeleteChatRoom(id uint) error
+	GetVisibleChatRoom(userID, id uint) (*model.ChatRoom, error)
+	DeleteChatRoom(id, userID uint) error
 	GetChatRoomByName(name string) (*model.ChatRoom, error)
-	SearchChatRoomsByName(keyword string) ([]model.ChatRoom, error)
+	SearchChatRoomsByName(userID uint, keyword string) ([]model.ChatRoom, error)
+	SetTopic(actorID, id uint, topic string) (*model.ChatRoom, error)
+	UpdateRoom(actorID, id uint, update RoomUpdate) (*model.ChatRoom, error)
+}
+
+// CreateChatRoom creates a public room owned by its creator.
+func (s *chatRoomService) CreateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	return s.createOwnedRoom(name, model.ChatRoomPublic, creatorID)
 }
 
-func (s *chatRoomService) CreateChatRoom(name string) (*model.ChatRoom, error) {
+// CreatePrivateChatRoom creates a room that only its members can find, owned by its creator.
+func (s *chatRoomService) CreatePrivateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	return s.createOwnedRoom(name, model.ChatRoomPrivate, creatorID)
+}
+
+func (s *chatRoomService) createOwnedRoom(name, kind string, creatorID uint) (*model.ChatRoom, error) {
+	if strings.HasPrefix(name, directRoomPrefix) {
+		return nil, ErrReservedRoomName
+	}
 	existing, err := s.repos.ChatRoom.GetByName(name)
 	if err == nil && existing != nil {
-		return nil, errors.New("chat room already exists")
+		return nil, ErrChatRoomExists
 	}
 
-	chatRoom := &model.ChatRoom{Name: name}
-	if err := s.repos.ChatRoom.Create(chatRoom); err != nil {
+	chatRoom := &model.ChatRoom{Name: name, Kind: kind}
+	owner := model.UserChatRoom{UserID: creatorID, Role: model.RoleOwner}
+	if err := s.repos.ChatRoom.CreateWithMembers(chatRoom, []model.UserChatRoom{owner}); err != nil {
 		return nil, err
 	}
+	s.invalidateRooms(creatorID)
 	return chatRoom, nil
 }
 
-func (s *chatRoomService) GetAllChatRooms() ([]model.ChatRoom, error) {
-	return s.repos.ChatRoom.GetAll()
+// GetOrCreateDirectRoom returns the direct conversation between userID and peerUsername,
+// creating it on first use. The bool reports whether the room was created.
+func (s *chatRoomService) GetOrCreateDirectRoom(userID uint, peerUsername string) (*model.ChatRoom, bool, error) {
+	peer, err := s.repos.User.GetByUsername(peerUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrUserNotFound
+		}
+		return nil, false, err
+	}
+	if peer.ID == userID {
+		return nil, false, ErrDirectWithSelf
+	}
+
+	// the room is looked up by its members, never by its name, which is only a label
+	key := directRoomKey(userID, peer.ID)
+	existing, err := s.repos.ChatRoom.GetDirect(key)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	chatRoom := &model.ChatRoom{Name: directRoomPrefix + key, Kind: model.ChatRoomDirect, DirectKey: &key}
+	// Neither side owns a direct conversation
+	members := []model.UserChatRoom{
+		{UserID: userID, Role: model.RoleMember},
+		{UserID: peer.ID, Role: model.RoleMember},
+	}
+	if err := s.repos.ChatRoom.CreateWithMembers(chatRoom, members); err != nil {
+		// Lost a race against the peer opening the same conversation
+		if existing, getErr := s.repos.ChatRoom.GetDirect(key); getErr == nil {
+			return existing, false, nil
+		}
+		return nil, false, err
+	}
+	s.invalidateRooms(userID, peer.ID)
+	return chatRoom, true, nil
+}
+
+// GetAllChatRooms lists public rooms plus the private and direct rooms userID belongs to.
+func (s *chatRoomService) GetAllChatRooms(userID uint) ([]model.ChatRoom, error) {
+	return s.repos.ChatRoom.GetAllVisibleTo(userID)
 }
 
 func (s *chatRoomService) GetChatRoomByID(id uint) (*model.ChatRoom, error) {
 	chatRoom, err := s.repos.ChatRoom.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("chat room not found")
+			return nil, ErrChatRoomNotFound
 		}
 		return nil, err
 	}
 	return chatRoom, nil
 }
 
-func (s *chatRoomService) DeleteChatRoom(id uint) error {
+// GetVisibleChatRoom returns room id as seen by userID. Private and direct rooms of which
+// userID is not a member are reported as not found.
+func (s *chatRoomService) GetVisibleChatRoom(userID, id uint) (*model.ChatRoom, error) {
+	return getVisibleRoom(s.repos, userID, id)
+}
+
+// getVisibleRoom returns ErrChatRoomNotFound unless room id is public or userID is a member of it.
+func getVisibleRoom(repos *repo.RepoContainer, userID, id uint) (*model.ChatRoom, error) {
+	chatRoom, err := repos.ChatRoom.GetVisibleTo(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatRoomNotFound
+		}
+		return nil, err
+	}
+	return chatRoom, nil
+}
+
+// DeleteChatRoom deletes a room on behalf of userID, who must be allowed to by their role.
+func (s *chatRoomService) DeleteChatRoom(id, userID uint) error {
+	if err := requirePermission(s.repos, userID, id, PermDeleteRoom); err != nil {
+		return err
+	}
 	return s.repos.ChatRoom.Delete(id)
 }
 
+// SetTopic changes the topic of a room on behalf of actorID, who must be allowed to by their role.
+// An empty topic clears it.
+func (s *chatRoomService) SetTopic(actorID, id uint, topic string) (*model.ChatRoom, error) {
+	return s.UpdateRoom(actorID, id, RoomUpdate{Topic: &topic})
+}
+
+// UpdateRoom changes the topic, description and avatar of a room on behalf of actorID, who
+// must be allowed to by their role.
+func (s *chatRoomService) UpdateRoom(actorID, id uint, update RoomUpdate) (*model.ChatRoom, error) {
+	if err := requirePermission(s.repos, actorID, id, PermEditRoom); err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if update.Topic != nil {
+		topic := strings.TrimSpace(*update.Topic)
+		if utf8.RuneCountInString(topic) > MaxTopicLength {
+			return nil, ErrTopicTooLong
+		}
+		fields["topic"] = topic
+	}
+	if update.Description != nil {
+		description := strings.TrimSpace(*update.Description)
+		if utf8.RuneCountInString(description) > MaxDescriptionLength {
+			return nil, ErrDescriptionTooLong
+		}
+		fields["description"] = description
+	}
+	if update.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*update.AvatarURL)
+		if avatarURL != "" && !validAvatarURL(avatarURL) {
+			return nil, ErrInvalidAvatarURL
+		}
+		fields["avatar_url"] = avatarURL
+	}
+	if err := s.repos.ChatRoom.UpdateDetails(id, fields); err != nil {
+		return nil, err
+	}
+
+	// members have the room cached in their subscribed rooms
+	if memberIDs, err := s.repos.UserChatRoom.ListUserIDs(id); err == nil {
+		invalidateRoomCache(s.repos, s.cache, memberIDs...)
+	}
+	chatRoom, err := s.repos.ChatRoom.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChatRoomNotFound
+	}
+	return chatRoom, err
+}
+
 func (s *chatRoomService) GetChatRoomByName(name string) (*model.ChatRoom, error) {
 	if name == "" {
 		return nil, errors.New("chat room name is required")
@@ -71,6 +240,28 @@ func (s *chatRoomService) GetChatRoomByName(name string) (*model.ChatRoom, error
 	return chatRoom, nil
 }
 
-func (s *chatRoomService) SearchChatRoomsByName(keyword string) ([]model.ChatRoom, error) {
-	return s.repos.ChatRoom.SearchByName(keyword)
+func (s *chatRoomService) SearchChatRoomsByName(userID uint, keyword string) ([]model.ChatRoom, error) {
+	return s.repos.ChatRoom.SearchVisibleByName(userID, keyword)
+}
+
+// invalidateRooms drops the cached subscribed rooms of userIDs after their memberships changed.
+func (s *chatRoomService) invalidateRooms(userIDs ...uint) {
+	invalidateRoomCache(s.repos, s.cache, userIDs...)
+}
+
+// validAvatarURL reports whether raw is an absolute http(s) URL, so clients can load it as an image.
+func validAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// directRoomKey is the DirectKey of the direct conversation between two users.
+func directRoomKey(a, b uint) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d:%d", a, b)
 }
@@ -1,12 +1,15 @@
 package service_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	//    "time"
 
 	"github.com/stretchr/testify/require"
 	//    "gorm.io/gorm"
 
+	"backend/internal/model"
 	"backend/internal/repo"
 	"backend/internal/service"
 )
@@ -14,30 +17,30 @@ import (
 func TestChatRoomService(t *testing.T) {
 	db := setupTestDB(t)
 	repos := repo.NewRepoContainer(db)
-	chatSvc := service.NewChatRoomService(repos)
+	chatSvc := service.NewChatRoomService(repos, setupCache())
 
 	t.Run("CreateChatRoom", func(t *testing.T) {
 		// successful creation
-		room, err := chatSvc.CreateChatRoom("General")
+		room, err := chatSvc.CreateChatRoom("General", 1)
 		require.NoError(t, err)
 		require.NotZero(t, room.ID)
 		require.Equal(t, "General", room.Name)
 
 		// duplicate creation should fail
-		_, err = chatSvc.CreateChatRoom("General")
+		_, err = chatSvc.CreateChatRoom("General", 1)
 		require.Error(t, err)
 		require.Equal(t, "chat room already exists", err.Error())
 	})
 
 	t.Run("GetAllChatRooms", func(t *testing.T) {
-		rooms, err := chatSvc.GetAllChatRooms()
+		rooms, err := chatSvc.GetAllChatRooms(0)
 		require.NoError(t, err)
 		require.GreaterOrEqual(t, len(rooms), 1)
 	})
 
 	t.Run("GetChatRoomByID", func(t *testing.T) {
 		// create a new room
-		room, _ := chatSvc.CreateChatRoom("Tech")
+		room, _ := chatSvc.CreateChatRoom("Tech", 1)
 		fetched, err := chatSvc.GetChatRoomByID(room.ID)
 		require.NoError(t, err)
 		require.Equal(t, "Tech", fetched.Name)
@@ -49,17 +52,17 @@ func TestChatRoomService(t *testing.T) {
 	})
 
 	t.Run("DeleteChatRoom", func(t *testing.T) {
-		room, _ := chatSvc.CreateChatRoom("Random")
-		err := chatSvc.DeleteChatRoom(room.ID)
+		room, _ := chatSvc.CreateChatRoom("Random", 1)
+		err := chatSvc.DeleteChatRoom(room.ID, 1)
 		require.NoError(t, err)
 
 		// deleting again should not fail but no room exists
-		err = chatSvc.DeleteChatRoom(room.ID)
+		err = chatSvc.DeleteChatRoom(room.ID, 1)
 		require.NoError(t, err)
 	})
 
 	t.Run("GetChatRoomByName", func(t *testing.T) {
-		room, _ := chatSvc.CreateChatRoom("Sports")
+		room, _ := chatSvc.CreateChatRoom("Sports", 1)
 		fetched, err := chatSvc.GetChatRoomByName("Sports")
 		require.NoError(t, err)
 		require.Equal(t, room.ID, fetched.ID)
@@ -77,11 +80,11 @@ func TestChatRoomService(t *testing.T) {
 
 	t.Run("SearchChatRoomsByName", func(t *testing.T) {
 		// create multiple rooms
-		_, _ = chatSvc.CreateChatRoom("Gaming")
-		_, _ = chatSvc.CreateChatRoom("GameDev")
-		_, _ = chatSvc.CreateChatRoom("Music")
+		_, _ = chatSvc.CreateChatRoom("Gaming", 1)
+		_, _ = chatSvc.CreateChatRoom("GameDev", 1)
+		_, _ = chatSvc.CreateChatRoom("Music", 1)
 
-		results, err := chatSvc.SearchChatRoomsByName("Gam")
+		results, err := chatSvc.SearchChatRoomsByName(0, "Gam")
 		require.NoError(t, err)
 		require.GreaterOrEqual(t, len(results), 2)
 
@@ -89,8 +92,168 @@ func TestChatRoomService(t *testing.T) {
 			require.Contains(t, r.Name, "Gam")
 		}
 
-		results, err = chatSvc.SearchChatRoomsByName("Nonexistent")
+		results, err = chatSvc.SearchChatRoomsByName(0, "Nonexistent")
 		require.NoError(t, err)
 		require.Empty(t, results)
 	})
 }
+
+func TestChatRoomService_Conversations(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	chatSvc := service.NewChatRoomService(repos, setupCache())
+
+	alice := model.User{Username: "alice", Email: "alice@test.com", Password: "test123"}
+	bob := model.User{Username: "bob", Email: "bob@test.com", Password: "test123"}
+	carol := model.User{Username: "carol", Email: "carol@test.com", Password: "test123"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+	require.NoError(t, db.Create(&carol).Error)
+
+	_, err := chatSvc.CreateChatRoom("lobby", bob.ID)
+	require.NoError(t, err)
+
+	t.Run("direct room is created once", func(t *testing.T) {
+		room, created, err := chatSvc.GetOrCreateDirectRoom(alice.ID, "bob")
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, model.ChatRoomDirect, room.Kind)
+
+		again, created, err := chatSvc.GetOrCreateDirectRoom(bob.ID, "alice")
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, room.ID, again.ID)
+
+		for _, userID := range []uint{alice.ID, bob.ID} {
+			ok, err := repos.UserChatRoom.Exists(userID, room.ID)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("direct room errors", func(t *testing.T) {
+		_, _, err := chatSvc.GetOrCreateDirectRoom(alice.ID, "alice")
+		require.ErrorIs(t, err, service.ErrDirectWithSelf)
+
+		_, _, err = chatSvc.GetOrCreateDirectRoom(alice.ID, "ghost")
+		require.ErrorIs(t, err, service.ErrUserNotFound)
+	})
+
+	t.Run("private room includes creator", func(t *testing.T) {
+		room, err := chatSvc.CreatePrivateChatRoom("secret", alice.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.ChatRoomPrivate, room.Kind)
+
+		ok, err := repos.UserChatRoom.Exists(alice.ID, room.ID)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("non-members only see public rooms", func(t *testing.T) {
+		rooms, err := chatSvc.GetAllChatRooms(carol.ID)
+		require.NoError(t, err)
+		require.Len(t, rooms, 1)
+		require.Equal(t, "lobby", rooms[0].Name)
+
+		rooms, err = chatSvc.SearchChatRoomsByName(carol.ID, "secret")
+		require.NoError(t, err)
+		require.Empty(t, rooms)
+	})
+
+	t.Run("members see their private and direct rooms", func(t *testing.T) {
+		rooms, err := chatSvc.GetAllChatRooms(alice.ID)
+		require.NoError(t, err)
+		require.Len(t, rooms, 3)
+
+		rooms, err = chatSvc.SearchChatRoomsByName(alice.ID, "secret")
+		require.NoError(t, err)
+		require.Len(t, rooms, 1)
+
+		rooms, err = chatSvc.GetAllChatRooms(bob.ID)
+		require.NoError(t, err)
+		require.Len(t, rooms, 2)
+	})
+
+	t.Run("private and direct rooms are hidden from non-members", func(t *testing.T) {
+		secret, err := chatSvc.GetChatRoomByName("secret")
+		require.NoError(t, err)
+		dm, _, err := chatSvc.GetOrCreateDirectRoom(alice.ID, "bob")
+		require.NoError(t, err)
+		lobby, err := chatSvc.GetChatRoomByName("lobby")
+		require.NoError(t, err)
+
+		for _, id := range []uint{secret.ID, dm.ID} {
+			_, err := chatSvc.GetVisibleChatRoom(carol.ID, id)
+			require.ErrorIs(t, err, service.ErrChatRoomNotFound)
+			room, err := chatSvc.GetVisibleChatRoom(alice.ID, id)
+			require.NoError(t, err)
+			require.Equal(t, id, room.ID)
+		}
+		_, err = chatSvc.GetVisibleChatRoom(carol.ID, lobby.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("direct room names cannot be taken", func(t *testing.T) {
+		name := fmt.Sprintf("dm:%d:%d", alice.ID, carol.ID)
+		_, err := chatSvc.CreateChatRoom(name, bob.ID)
+		require.ErrorIs(t, err, service.ErrReservedRoomName)
+		_, err = chatSvc.CreatePrivateChatRoom(name, bob.ID)
+		require.ErrorIs(t, err, service.ErrReservedRoomName)
+
+		room, created, err := chatSvc.GetOrCreateDirectRoom(carol.ID, "alice")
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, model.ChatRoomDirect, room.Kind)
+		require.Equal(t, name, room.Name)
+		for _, userID := range []uint{alice.ID, carol.ID} {
+			ok, err := repos.UserChatRoom.Exists(userID, room.ID)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	})
+}
+
+func TestChatRoomService_UpdateRoom(t *testing.T) {
+	f := setupWebhookFixture(t)
+	chatSvc := service.NewChatRoomService(f.repos, setupCache())
+	text := func(s string) *string { return &s }
+
+	t.Run("needs permission", func(t *testing.T) {
+		_, err := chatSvc.UpdateRoom(f.member.ID, f.room.ID, service.RoomUpdate{Description: text("ours now")})
+		require.ErrorIs(t, err, service.ErrForbidden)
+
+		// permission is checked before the fields, so invalid input does not leak the rules
+		_, err = chatSvc.UpdateRoom(f.member.ID, f.room.ID, service.RoomUpdate{Topic: text(strings.Repeat("t", service.MaxTopicLength+1))})
+		require.ErrorIs(t, err, service.ErrForbidden)
+		_, err = chatSvc.UpdateRoom(f.member.ID+100, f.room.ID, service.RoomUpdate{AvatarURL: text("javascript:alert(1)")})
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("validates fields", func(t *testing.T) {
+		_, err := chatSvc.UpdateRoom(f.owner.ID, f.room.ID, service.RoomUpdate{Topic: text(strings.Repeat("t", service.MaxTopicLength+1))})
+		require.ErrorIs(t, err, service.ErrTopicTooLong)
+		_, err = chatSvc.UpdateRoom(f.owner.ID, f.room.ID, service.RoomUpdate{Description: text(strings.Repeat("d", service.MaxDescriptionLength+1))})
+		require.ErrorIs(t, err, service.ErrDescriptionTooLong)
+		for _, u := range []string{"javascript:alert(1)", "/avatar.png", "https://"} {
+			_, err = chatSvc.UpdateRoom(f.owner.ID, f.room.ID, service.RoomUpdate{AvatarURL: text(u)})
+			require.ErrorIs(t, err, service.ErrInvalidAvatarURL, u)
+		}
+	})
+
+	t.Run("changes only the given fields", func(t *testing.T) {
+		room, err := chatSvc.UpdateRoom(f.owner.ID, f.room.ID, service.RoomUpdate{
+			Topic:       text(" Deploys "),
+			Description: text("Where releases are coordinated."),
+			AvatarURL:   text("https://cdn.test/ops.png"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Deploys", room.Topic)
+		require.Equal(t, "https://cdn.test/ops.png", room.AvatarURL)
+
+		room, err = chatSvc.UpdateRoom(f.owner.ID, f.room.ID, service.RoomUpdate{AvatarURL: text("")})
+		require.NoError(t, err)
+		require.Equal(t, "Deploys", room.Topic)
+		require.Equal(t, "Where releases are coordinated.", room.Description)
+		require.Empty(t, room.AvatarURL)
+	})
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+	"gorm.io/gorm"
+)
+
+const (
+	// EphemeralEventType is the msg_type of replies that only the user who ran a command sees.
+	EphemeralEventType = "ephemeral"
+
+	botCommandTimeout  = 5 * time.Second
+	maxBotCommandReply = 64 << 10
+)
+
+// CommandRequest is one run of a slash command.
+type CommandRequest struct {
+	UserID uint
+	RoomID uint
+	Name   string
+	Args   string
+}
+
+// CommandHandler runs a command and returns the reply shown to the user who ran it.
+// Errors are shown to that user too.
+type CommandHandler func(req CommandRequest) (string, error)
+
+// Command is a slash command built into the backend.
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Handler     CommandHandler
+}
+
+// BotCommandPayload is the JSON body POSTed to a bot when one of its commands is run.
+type BotCommandPayload struct {
+	Command    string `json:"command"`
+	Args       string `json:"args"`
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	ChatRoomID uint   `json:"chat_room_id"`
+}
+
+// BotCommandResponse is what a bot answers a command with. With response_type "in_channel" the
+// text is posted to the room as the bot; otherwise only the user who ran the command sees it.
+type BotCommandResponse struct {
+	Text         string `json:"text"`
+	ResponseType string `json:"response_type"`
+}
+
+// IsCommand reports whether a chat message is a slash command. Messages that start with "//"
+// are plain messages starting with a slash.
+func IsCommand(content string) bool {
+	return len(content) > 1 && content[0] == '/' && content[1] != '/' && content[1] != ' '
+}
+
+// CommandRegistry runs the slash commands sent as chat messages, both the built-in ones and
+// the ones bots register. Replies go out as ephemeral events to the user who ran the command.
+type CommandRegistry struct {
+	repos     *repo.RepoContainer
+	messages  MessageService
+	publisher EventPublisher
+	client    *http.Client
+	// allowPrivateNetworks lets bot callbacks reach loopback and private addresses.
+	allowPrivateNetworks bool
+
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+// NewCommandRegistry returns a CommandRegistry. Bot callbacks are only sent to public
+// addresses unless allowPrivateNetworks is set.
+func NewCommandRegistry(repos *repo.RepoContainer, messages MessageService, publisher EventPublisher, allowPrivateNetworks bool) *CommandRegistry {
+	return &CommandRegistry{
+		repos:                repos,
+		messages:             messages,
+		publisher:            publisher,
+		client:               newOutboundClient(botCommandTimeout, allowPrivateNetworks),
+		allowPrivateNetworks: allowPrivateNetworks,
+		commands:             map[string]Command{},
+	}
+}
+
+// Register adds a built-in command.
+func (r *CommandRegistry) Register(cmd Command) error {
+	name := strings.ToLower(cmd.Name)
+	if !botCommandName.MatchString(name) || cmd.Handler == nil {
+		return fmt.Errorf("invalid command %q", cmd.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.commands[name]; ok {
+		return fmt.Errorf("command /%s is already registered", name)
+	}
+	cmd.Name = name
+	r.commands[name] = cmd
+	return nil
+}
+
+// IsBuiltin reports whether name is a built-in command, which bots cannot take.
+func (r *CommandRegistry) IsBuiltin(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.commands[strings.ToLower(name)]
+	return ok
+}
+
+// Builtins returns the built-in commands sorted by name.
+func (r *CommandRegistry) Builtins() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmds := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Dispatch runs the command in content for userID in roomID. Bot commands run in the background.
+func (r *CommandRegistry) Dispatch(userID, roomID uint, content string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(content), "/"), " ")
+	req := CommandRequest{UserID: userID, RoomID: roomID, Name: strings.ToLower(name), Args: strings.TrimSpace(args)}
+
+	member, err := r.repos.UserChatRoom.Exists(userID, roomID)
+	if err != nil {
+		log.Println("[commands] membership lookup failed:", err)
+		return
+	}
+	if !member {
+		r.Reply(userID, roomID, "You can only run commands in rooms you are a member of.")
+		return
+	}
+
+	r.mu.RLock()
+	cmd, ok := r.commands[req.Name]
+	r.mu.RUnlock()
+	if ok {
+		reply, err := cmd.Handler(req)
+		if err != nil {
+			reply = fmt.Sprintf("/%s: %v", req.Name, err)
+		}
+		r.Reply(userID, roomID, reply)
+		return
+	}
+
+	botCmd, err := r.repos.BotCommand.GetByName(req.Name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Println("[commands] bot command lookup failed:", err)
+		return
+	}
+	// bots only serve their commands in rooms they are in
+	if botCmd != nil {
+		if ok, err := r.repos.UserChatRoom.Exists(botCmd.BotID, roomID); err == nil && ok {
+			go r.runBotCommand(botCmd, req)
+			return
+		}
+	}
+	r.Reply(userID, roomID, fmt.Sprintf("Unknown command /%s. Type /help to list the commands.", req.Name))
+}
+
+// Reply sends text to userID only.
+func (r *CommandRegistry) Reply(userID, roomID uint, text string) {
+	if text == "" {
+		return
+	}
+	r.publish(&kafkapb.KafkaEvent{
+		UserId:  uint32(userID),
+		RoomId:  uint32(roomID),
+		MsgType: EphemeralEventType,
+		Content: []byte(text),
+	})
+}
+
+func (r *CommandRegistry) publish(event *kafkapb.KafkaEvent) {
+	if r.publisher == nil {
+		return
+	}
+	if err := r.publisher.HandleOutgoingMessage(event); err != nil {
+		log.Println("[commands] publish failed:", err)
+	}
+}
+
+func (r *CommandRegistry) runBotCommand(cmd *model.BotCommand, req CommandRequest) {
+	resp, err := r.callBot(cmd, req)
+	if err != nil {
+		log.Printf("[commands] /%s: %v", cmd.Name, err)
+		r.Reply(req.UserID, req.RoomID, fmt.Sprintf("/%s did not respond, try again later.", cmd.Name))
+		return
+	}
+	if resp.ResponseType != "in_channel" {
+		r.Reply(req.UserID, req.RoomID, resp.Text)
+		return
+	}
+	if strings.TrimSpace(resp.Text) == "" {
+		return
+	}
+
+	msg, err := r.messages.CreateMessage(cmd.BotID, req.RoomID, resp.Text)
+	if err != nil {
+		log.Printf("[commands] /%s: failed to post reply: %v", cmd.Name, err)
+		return
+	}
+	r.publish(&kafkapb.KafkaEvent{
+		Id:      uint64(msg.ID),
+		UserId:  uint32(msg.UserID),
+		RoomId:  uint32(msg.RoomID),
+		MsgType: "message",
+		Content: []byte(msg.Content),
+	})
+}
+
+// callBot POSTs the command to the bot, signed like a webhook delivery.
+func (r *CommandRegistry) callBot(cmd *model.BotCommand, req CommandRequest) (*BotCommandResponse, error) {
+	payload := BotCommandPayload{Command: cmd.Name, Args: req.Args, UserID: req.UserID, ChatRoomID: req.RoomID}
+	if user, err := r.repos.User.GetByID(req.UserID); err == nil {
+		payload.Username = user.Username
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	httpReq, err := http.NewRequest(http.MethodPost, cmd.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(WebhookEventHeader, "command")
+	httpReq.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(WebhookSignatureHeader, SignWebhook(cmd.Secret, timestamp, body))
+
+	httpResp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %d", httpResp.StatusCode)
+	}
+
+	var resp BotCommandResponse
+	raw, err := io.ReadAll(io.LimitReader(httpResp.Body, maxBotCommandReply))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return &resp, nil
+}
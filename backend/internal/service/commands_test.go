@@ -0,0 +1,260 @@
+package service_test
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+// recordingProducer keeps the events KafkaService publishes.
+type recordingProducer struct {
+	mu     sync.Mutex
+	events []*kafkapb.KafkaEvent
+}
+
+func (p *recordingProducer) Publish(topic string, key []byte, value []byte) error {
+	event := &kafkapb.KafkaEvent{}
+	if err := proto.Unmarshal(value, event); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingProducer) Close() error { return nil }
+
+// nth waits for the nth event (1-based) and returns it.
+func (p *recordingProducer) nth(t *testing.T, n int) *kafkapb.KafkaEvent {
+	t.Helper()
+	var event *kafkapb.KafkaEvent
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(p.events) < n {
+			return false
+		}
+		event = p.events[n-1]
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+	return event
+}
+
+func (p *recordingProducer) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.events)
+}
+
+type commandFixture struct {
+	webhookFixture
+	producer *recordingProducer
+	kafka    *service.KafkaService
+	registry *service.CommandRegistry
+}
+
+func setupCommandFixture(t *testing.T) commandFixture {
+	t.Helper()
+	f := commandFixture{webhookFixture: setupWebhookFixture(t), producer: &recordingProducer{}}
+	messages := service.NewMessageService(f.repos)
+	f.kafka = &service.KafkaService{Producer: f.producer, MessageService: messages}
+	// bot callbacks in these tests go to loopback receivers
+	f.registry = service.NewCommandRegistry(f.repos, messages, f.kafka, true)
+	require.NoError(t, service.RegisterBuiltinCommands(f.registry, f.repos,
+		service.NewChatRoomService(f.repos, setupCache()), service.NewMembershipService(f.repos, setupCache())))
+	f.kafka.Commands = f.registry
+	return f
+}
+
+// run sends content as a chat message from userID and returns the reply it gets.
+func (f commandFixture) run(t *testing.T, userID uint, content string) *kafkapb.KafkaEvent {
+	t.Helper()
+	n := f.producer.count()
+	f.kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{
+		UserId:  uint32(userID),
+		RoomId:  uint32(f.room.ID),
+		MsgType: "message",
+		Content: []byte(content),
+	})
+	return f.producer.nth(t, n+1)
+}
+
+func TestIsCommand(t *testing.T) {
+	require.True(t, service.IsCommand("/roll 2d6"))
+	require.False(t, service.IsCommand("//roll"))
+	require.False(t, service.IsCommand("/ not a command"))
+	require.False(t, service.IsCommand("/"))
+	require.False(t, service.IsCommand("hello /roll"))
+}
+
+func TestBuiltinCommands(t *testing.T) {
+	f := setupCommandFixture(t)
+
+	t.Run("replies are ephemeral and not stored", func(t *testing.T) {
+		reply := f.run(t, f.member.ID, "/roll")
+		require.Equal(t, service.EphemeralEventType, reply.MsgType)
+		require.Equal(t, uint32(f.member.ID), reply.UserId)
+		require.Regexp(t, `^You rolled 1d6: [1-6]$`, string(reply.Content))
+
+		msgs, err := f.repos.Message.GetByRoomID(f.room.ID)
+		require.NoError(t, err)
+		require.Empty(t, msgs)
+	})
+
+	t.Run("roll validates dice", func(t *testing.T) {
+		require.Regexp(t, `^You rolled 3d20: \d+, \d+, \d+ \(total \d+\)$`, string(f.run(t, f.member.ID, "/roll 3d20").Content))
+		require.Contains(t, string(f.run(t, f.member.ID, "/roll 100d6").Content), "usage: /roll")
+		require.Contains(t, string(f.run(t, f.member.ID, "/roll d1").Content), "usage: /roll")
+	})
+
+	t.Run("escaped slash is a plain message", func(t *testing.T) {
+		event := f.run(t, f.member.ID, "//roll")
+		require.Equal(t, "message", event.MsgType)
+		require.Equal(t, "/roll", string(event.Content))
+		// the stored id replaces the client's temp id
+		msgs, err := f.repos.Message.GetByRoomID(f.room.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint64(msgs[len(msgs)-1].ID), event.Id)
+	})
+
+	t.Run("topic needs permission", func(t *testing.T) {
+		// the gateway binds UserId to the token subject, so this is the member whatever they claimed
+		require.Contains(t, string(f.run(t, f.member.ID, "/topic ours now").Content), service.ErrForbidden.Error())
+		room, err := f.repos.ChatRoom.GetByID(f.room.ID)
+		require.NoError(t, err)
+		require.Empty(t, room.Topic)
+
+		n := f.producer.count()
+		f.kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{UserId: uint32(f.owner.ID), RoomId: uint32(f.room.ID), MsgType: "message", Content: []byte("/topic Deploys and incidents")})
+		updated := f.producer.nth(t, n+1)
+		require.Equal(t, service.RoomUpdatedEventType, updated.MsgType)
+		require.NoError(t, json.Unmarshal(updated.Content, room))
+		require.Equal(t, "Deploys and incidents", room.Topic)
+		require.Equal(t, service.EphemeralEventType, f.producer.nth(t, n+2).MsgType)
+
+		require.Equal(t, "Topic: Deploys and incidents", string(f.run(t, f.member.ID, "/topic").Content))
+	})
+
+	t.Run("mute and unmute", func(t *testing.T) {
+		f.run(t, f.member.ID, "/mute")
+		membership, err := f.repos.UserChatRoom.Get(f.member.ID, f.room.ID)
+		require.NoError(t, err)
+		require.True(t, membership.Muted)
+
+		f.run(t, f.member.ID, "/unmute")
+		membership, err = f.repos.UserChatRoom.Get(f.member.ID, f.room.ID)
+		require.NoError(t, err)
+		require.False(t, membership.Muted)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		require.Contains(t, string(f.run(t, f.member.ID, "/nope").Content), "Unknown command /nope")
+	})
+
+	t.Run("non-members cannot run commands", func(t *testing.T) {
+		outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "x"}
+		require.NoError(t, f.repos.User.Create(&outsider))
+		require.Contains(t, string(f.run(t, outsider.ID, "/roll").Content), "member")
+	})
+}
+
+func TestBotCommands(t *testing.T) {
+	f := setupCommandFixture(t)
+	commands := service.NewBotCommandService(f.repos, f.registry)
+	bot := model.User{Username: "deploybot", Email: "deploybot@bots.invalid", IsBot: true}
+	require.NoError(t, f.repos.User.Create(&bot))
+
+	var (
+		secret   string
+		payloads = make(chan service.BotCommandPayload, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(service.WebhookTimestampHeader), 10, 64)
+		if r.Header.Get(service.WebhookSignatureHeader) != service.SignWebhook(secret, ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload service.BotCommandPayload
+		_ = json.Unmarshal(body, &payload)
+		payloads <- payload
+		_ = json.NewEncoder(w).Encode(service.BotCommandResponse{Text: "deploying " + payload.Args, ResponseType: "in_channel"})
+	}))
+	defer srv.Close()
+
+	t.Run("registration is validated", func(t *testing.T) {
+		_, _, err := commands.RegisterCommand(bot.ID, "Not Valid", "", srv.URL)
+		require.ErrorIs(t, err, service.ErrInvalidCommand)
+		_, _, err = commands.RegisterCommand(bot.ID, "deploy", "", "ftp://example.test")
+		require.ErrorIs(t, err, service.ErrInvalidCommand)
+		_, _, err = commands.RegisterCommand(bot.ID, "/roll", "", srv.URL)
+		require.ErrorIs(t, err, service.ErrCommandTaken)
+	})
+
+	cmd, s, err := commands.RegisterCommand(bot.ID, "/Deploy", "Ship a service", srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, "deploy", cmd.Name)
+	secret = s
+	_, _, err = commands.RegisterCommand(bot.ID, "deploy", "", srv.URL)
+	require.ErrorIs(t, err, service.ErrCommandTaken)
+
+	t.Run("bot must be in the room", func(t *testing.T) {
+		require.Contains(t, string(f.run(t, f.member.ID, "/deploy api").Content), "Unknown command /deploy")
+	})
+
+	t.Run("callback reply is posted as the bot", func(t *testing.T) {
+		require.NoError(t, f.repos.UserChatRoom.Create(&model.UserChatRoom{UserID: bot.ID, ChatRoomID: f.room.ID, Role: model.RoleMember}))
+		require.Contains(t, string(f.run(t, f.member.ID, "/help").Content), "/deploy - Ship a service")
+
+		posted := f.run(t, f.member.ID, "/deploy api")
+		payload := <-payloads
+		require.Equal(t, service.BotCommandPayload{Command: "deploy", Args: "api", UserID: f.member.ID, Username: "member", ChatRoomID: f.room.ID}, payload)
+		require.Equal(t, "message", posted.MsgType)
+		require.Equal(t, uint32(bot.ID), posted.UserId)
+		require.Equal(t, "deploying api", string(posted.Content))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, commands.DeleteCommand(bot.ID, "deploy"))
+		require.ErrorIs(t, commands.DeleteCommand(bot.ID, "deploy"), service.ErrBotCommandNotFound)
+	})
+}
+
+func TestBotCommands_RefuseInternalCallbacks(t *testing.T) {
+	f := setupCommandFixture(t)
+	f.registry = service.NewCommandRegistry(f.repos, service.NewMessageService(f.repos), f.kafka, false)
+	f.kafka.Commands = f.registry
+	commands := service.NewBotCommandService(f.repos, f.registry)
+	bot := model.User{Username: "probebot", Email: "probebot@bots.invalid", IsBot: true}
+	require.NoError(t, f.repos.User.Create(&bot))
+	require.NoError(t, f.repos.UserChatRoom.Create(&model.UserChatRoom{UserID: bot.ID, ChatRoomID: f.room.ID, Role: model.RoleMember}))
+
+	_, _, err := commands.RegisterCommand(bot.ID, "probe", "", "http://169.254.169.254/latest/meta-data")
+	require.ErrorIs(t, err, service.ErrInvalidCommand)
+	require.ErrorIs(t, err, service.ErrPrivateAddress)
+
+	// a host that resolves to loopback by the time the command runs is refused when dialing
+	called := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called <- struct{}{} }))
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, f.repos.BotCommand.Create(&model.BotCommand{BotID: bot.ID, Name: "probe", CallbackURL: "http://localhost:" + port, Secret: "s"}))
+
+	require.Equal(t, "/probe did not respond, try again later.", string(f.run(t, f.member.ID, "/probe").Content))
+	require.Empty(t, called)
+}
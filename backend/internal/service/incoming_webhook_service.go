@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// MaxIncomingMessageLength caps the content posted through an incoming webhook, in characters.
+const MaxIncomingMessageLength = 4000
+
+var (
+	ErrInvalidWebhookToken = errors.New("unknown webhook")
+	ErrMessageTooLong      = errors.New("message is too long")
+)
+
+// IncomingWebhookService manages incoming webhooks and posts the messages sent to them.
+type IncomingWebhookService interface {
+	CreateIncomingWebhook(userID, chatRoomID uint, name string) (hook *model.IncomingWebhook, token string, err error)
+	ListIncomingWebhooks(userID, chatRoomID uint) ([]model.IncomingWebhook, error)
+	DeleteIncomingWebhook(userID, hookID uint) error
+	Post(token, content string) (*model.Message, error)
+}
+
+type incomingWebhookService struct {
+	repos    *repo.RepoContainer
+	messages MessageService
+}
+
+func NewIncomingWebhookService(repos *repo.RepoContainer, messages MessageService) IncomingWebhookService {
+	return &incomingWebhookService{repos: repos, messages: messages}
+}
+
+// CreateIncomingWebhook adds a webhook to chatRoomID. Its messages are posted by a new bot
+// user called name. The returned token is not shown again.
+func (s *incomingWebhookService) CreateIncomingWebhook(userID, chatRoomID uint, name string) (*model.IncomingWebhook, string, error) {
+	if err := requirePermission(s.repos, userID, chatRoomID, PermManageWebhooks); err != nil {
+		return nil, "", err
+	}
+	username := strings.Trim(usernameUnsafe.ReplaceAllString(strings.TrimSpace(name), "-"), "-.")
+	if username == "" || len(username) > 32 {
+		return nil, "", fmt.Errorf("%w: name must be 1 to 32 letters, digits, dots, dashes or underscores", ErrInvalidWebhook)
+	}
+	if _, err := s.repos.User.GetByUsername(username); err == nil {
+		return nil, "", fmt.Errorf("%w: name %q is already taken", ErrInvalidWebhook, username)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	bot := &model.User{
+		Username:  username,
+		Email:     username + "@bots.invalid",
+		IsBot:     true,
+		CreatedAt: time.Now(),
+	}
+	hook := &model.IncomingWebhook{
+		ChatRoomID: chatRoomID,
+		CreatedBy:  userID,
+		Name:       username,
+		TokenHash:  hashToken(token),
+	}
+	if err := s.repos.IncomingHook.CreateWithBot(bot, hook); err != nil {
+		return nil, "", err
+	}
+	return hook, token, nil
+}
+
+func (s *incomingWebhookService) ListIncomingWebhooks(userID, chatRoomID uint) ([]model.IncomingWebhook, error) {
+	if err := requirePermission(s.repos, userID, chatRoomID, PermManageWebhooks); err != nil {
+		return nil, err
+	}
+	return s.repos.IncomingHook.ListByChatRoomID(chatRoomID)
+}
+
+func (s *incomingWebhookService) DeleteIncomingWebhook(userID, hookID uint) error {
+	hook, err := s.repos.IncomingHook.GetByID(hookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	if err := requirePermission(s.repos, userID, hook.ChatRoomID, PermManageWebhooks); err != nil {
+		return err
+	}
+	return s.repos.IncomingHook.Delete(hook)
+}
+
+// Post creates a message from the webhook's bot in the webhook's room.
+func (s *incomingWebhookService) Post(token, content string) (*model.Message, error) {
+	hook, err := s.repos.IncomingHook.GetByHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidWebhookToken
+		}
+		return nil, err
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, ErrEmptyContent
+	}
+	if utf8.RuneCountInString(content) > MaxIncomingMessageLength {
+		return nil, ErrMessageTooLong
+	}
+
+	msg, err := s.messages.CreateMessage(hook.BotID, hook.ChatRoomID, content)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repos.IncomingHook.Touch(hook.ID, time.Now())
+	return msg, nil
+}
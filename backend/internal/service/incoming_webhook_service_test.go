@@ -0,0 +1,51 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/service"
+)
+
+func TestIncomingWebhookService(t *testing.T) {
+	f := setupWebhookFixture(t)
+	hooks := service.NewIncomingWebhookService(f.repos, service.NewMessageService(f.repos))
+
+	_, _, err := hooks.CreateIncomingWebhook(f.member.ID, f.room.ID, "alerts")
+	require.ErrorIs(t, err, service.ErrForbidden)
+	_, _, err = hooks.CreateIncomingWebhook(f.owner.ID, f.room.ID, "member")
+	require.ErrorIs(t, err, service.ErrInvalidWebhook)
+
+	hook, token, err := hooks.CreateIncomingWebhook(f.owner.ID, f.room.ID, "grafana alerts")
+	require.NoError(t, err)
+	require.Equal(t, "grafana-alerts", hook.Name)
+	bot, err := f.repos.User.GetByID(hook.BotID)
+	require.NoError(t, err)
+	require.True(t, bot.IsBot)
+	member, err := f.repos.UserChatRoom.Exists(bot.ID, f.room.ID)
+	require.NoError(t, err)
+	require.True(t, member)
+
+	msg, err := hooks.Post(token, "  disk 91% full  ")
+	require.NoError(t, err)
+	require.Equal(t, "disk 91% full", msg.Content)
+	require.Equal(t, bot.ID, msg.UserID)
+	require.Equal(t, f.room.ID, msg.RoomID)
+
+	_, err = hooks.Post(token+"x", "hi")
+	require.ErrorIs(t, err, service.ErrInvalidWebhookToken)
+	_, err = hooks.Post(token, " ")
+	require.ErrorIs(t, err, service.ErrEmptyContent)
+	_, err = hooks.Post(token, strings.Repeat("a", service.MaxIncomingMessageLength+1))
+	require.ErrorIs(t, err, service.ErrMessageTooLong)
+
+	require.ErrorIs(t, hooks.DeleteIncomingWebhook(f.member.ID, hook.ID), service.ErrForbidden)
+	require.NoError(t, hooks.DeleteIncomingWebhook(f.owner.ID, hook.ID))
+	_, err = hooks.Post(token, "still there?")
+	require.ErrorIs(t, err, service.ErrInvalidWebhookToken)
+	member, err = f.repos.UserChatRoom.Exists(bot.ID, f.room.ID)
+	require.NoError(t, err)
+	require.False(t, member)
+}
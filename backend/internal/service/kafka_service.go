@@ -1,9 +1,12 @@
 package service
 
 import (
+	"bytes"
+	"errors"
 	"log"
 	"time"
 
+	"backend/internal/model"
 	kafkapb "backend/proto/kafka"
 
 	"google.golang.org/protobuf/proto"
@@ -19,9 +22,18 @@ type OutgoingHandler interface {
 	Deliver(data []byte) error
 }
 
+// EventPublisher publishes events to the notification topic so connected clients receive them.
+type EventPublisher interface {
+	HandleOutgoingMessage(event *kafkapb.KafkaEvent) error
+}
+
 type KafkaService struct {
 	Producer       MessageProducer
 	MessageService MessageService
+	// Commands runs chat messages that are slash commands instead of posting them.
+	Commands *CommandRegistry
+	// Attachments links the files a chat message refers to. Without it refs are dropped.
+	Attachments AttachmentService
 }
 
 func (s *KafkaService) HandleOutboundEvent(event *kafkapb.KafkaEvent) {
@@ -43,14 +55,78 @@ func (s *KafkaService) handleChatMessage(event *kafkapb.KafkaEvent) {
 	// Process chat message event
 	// For example, you might want to log it or transform it before publishing
 	//
-	_, err := s.MessageService.CreateMessage(uint(event.UserId), uint(event.RoomId), string(event.Content))
+	if s.Commands != nil && IsCommand(string(event.Content)) {
+		s.Commands.Dispatch(uint(event.UserId), uint(event.RoomId), string(event.Content))
+		return
+	}
+	// "//" escapes a message that starts with a slash
+	if bytes.HasPrefix(event.Content, []byte("//")) {
+		event.Content = event.Content[1:]
+	}
+	attachments := s.prepareAttachments(event)
+
+	if event.ParentId != 0 {
+		msg, err := s.MessageService.CreateReply(uint(event.UserId), uint(event.RoomId), uint(event.ParentId), string(event.Content))
+		if err != nil {
+			log.Println("Error creating reply:", err)
+			return
+		}
+		s.linkAttachments(event, msg, attachments)
+		// Point the event at the thread root so clients render it in the right thread
+		event.Id = uint64(msg.ID)
+		event.ParentId = uint64(*msg.ParentID)
+		s.HandleOutgoingMessage(event)
+		return
+	}
+
+	msg, err := s.MessageService.CreateMessage(uint(event.UserId), uint(event.RoomId), string(event.Content))
+	if errors.Is(err, ErrNotMember) || errors.Is(err, ErrBanned) {
+		// the room must not see messages from people who cannot post in it
+		log.Println("Dropping message:", err)
+		return
+	}
 	if err != nil {
 		log.Println("Error creating message:", err)
+	} else {
+		// clients match the echo by temp id; everyone else needs the stored id
+		event.Id = uint64(msg.ID)
+		s.linkAttachments(event, msg, attachments)
 	}
 
 	s.HandleOutgoingMessage(event)
 }
 
+// prepareAttachments checks the attachments a chat message refers to. The refs from the client
+// are dropped either way; only linked attachments are sent on, as described by the backend.
+func (s *KafkaService) prepareAttachments(event *kafkapb.KafkaEvent) []model.Attachment {
+	refs := event.Attachments
+	event.Attachments = nil
+	if len(refs) == 0 || s.Attachments == nil {
+		return nil
+	}
+	ids := make([]uint, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, uint(ref.Id))
+	}
+	attachments, err := s.Attachments.PrepareForMessage(uint(event.UserId), uint(event.RoomId), ids)
+	if err != nil {
+		log.Println("Error preparing attachments:", err)
+		return nil
+	}
+	return attachments
+}
+
+func (s *KafkaService) linkAttachments(event *kafkapb.KafkaEvent, msg *model.Message, attachments []model.Attachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	if err := s.Attachments.LinkToMessage(msg, attachments); err != nil {
+		log.Println("Error linking attachments:", err)
+		return
+	}
+	event.Attachments = AttachmentRefs(msg.Attachments)
+}
+
 func (s *KafkaService) handleJoin(event *kafkapb.KafkaEvent) {
 	// Process room join event
 	// For example, you might want to log it or transform it before publishing
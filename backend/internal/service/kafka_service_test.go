@@ -0,0 +1,54 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+func TestKafkaService_IgnoresEditsAndReactions(t *testing.T) {
+	repos := repo.NewRepoContainer(setupTestDB(t))
+	producer := &recordingProducer{}
+	messages := service.NewMessageService(repos)
+	kafka := &service.KafkaService{Producer: producer, MessageService: messages}
+
+	require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: 10, Name: "ops"}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: 10, Role: model.RoleMember}))
+	msg, err := messages.CreateMessage(1, 10, "original")
+	require.NoError(t, err)
+
+	// edits and reactions are REST-only; the same events from the gateway do nothing
+	for _, msgType := range []string{"edit", "reaction_add", "reaction_remove"} {
+		kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{
+			Id: uint64(msg.ID), UserId: 1, RoomId: 10, MsgType: msgType, Content: []byte("forged"),
+		})
+	}
+	require.Zero(t, producer.count())
+
+	stored, err := repos.Message.GetByID(msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "original", stored.Content)
+	reactions, err := repos.Reaction.GetByMessageID(msg.ID)
+	require.NoError(t, err)
+	require.Empty(t, reactions)
+}
+
+func TestKafkaService_DropsMessagesFromNonMembers(t *testing.T) {
+	repos := repo.NewRepoContainer(setupTestDB(t))
+	producer := &recordingProducer{}
+	kafka := &service.KafkaService{Producer: producer, MessageService: service.NewMessageService(repos)}
+
+	require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: 10, Name: "ops"}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: 10, Role: model.RoleMember}))
+	kafka.HandleOutboundEvent(&kafkapb.KafkaEvent{UserId: 2, RoomId: 10, MsgType: "message", Content: []byte("hi all")})
+	require.Zero(t, producer.count())
+
+	msgs, err := repos.Message.GetByRoomID(10)
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+}
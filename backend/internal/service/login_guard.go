@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/cache"
+)
+
+// LoginAttemptsKeyPrefix namespaces failed login counters in the cache.
+const LoginAttemptsKeyPrefix = "login:attempts:"
+
+// LoginAttempts is the failed login history of one username or IP address.
+type LoginAttempts struct {
+	Failures    int
+	Lockouts    int
+	LockedUntil time.Time
+}
+
+// LoginLockedError is returned while a username or IP address is locked out.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuardOptions tunes a LoginGuard. Zero values fall back to the defaults noted per field.
+type LoginGuardOptions struct {
+	// UserThreshold is how many failures in a row lock a username (5).
+	UserThreshold int
+	// IPThreshold is how many failures in a row lock an IP address (20).
+	IPThreshold int
+	// BaseLockout is the first lockout; every further lockout doubles it (1 minute).
+	BaseLockout time.Duration
+	// MaxLockout caps the lockout (1 hour).
+	MaxLockout time.Duration
+	// Memory is how long failures are remembered after the last one (24 hours).
+	Memory time.Duration
+}
+
+// LoginGuard tracks failed logins per username and per IP address and locks them out for
+// exponentially growing windows once they fail too often.
+type LoginGuard struct {
+	store cache.Cache[LoginAttempts]
+	opts  LoginGuardOptions
+	now   func() time.Time
+}
+
+func NewLoginGuard(store cache.Cache[LoginAttempts], opts LoginGuardOptions) *LoginGuard {
+	if opts.UserThreshold <= 0 {
+		opts.UserThreshold = 5
+	}
+	if opts.IPThreshold <= 0 {
+		opts.IPThreshold = 20
+	}
+	if opts.BaseLockout <= 0 {
+		opts.BaseLockout = time.Minute
+	}
+	if opts.MaxLockout <= 0 {
+		opts.MaxLockout = time.Hour
+	}
+	if opts.Memory <= 0 {
+		opts.Memory = 24 * time.Hour
+	}
+	return &LoginGuard{store: store, opts: opts, now: time.Now}
+}
+
+// Check returns a *LoginLockedError if either the username or the IP address is locked out.
+func (g *LoginGuard) Check(username, ip string) error {
+	now := g.now()
+	var wait time.Duration
+	for _, key := range g.keys(username, ip) {
+		if attempts, ok := g.store.Get(key); ok && attempts.LockedUntil.After(now) {
+			wait = max(wait, attempts.LockedUntil.Sub(now))
+		}
+	}
+	if wait > 0 {
+		return &LoginLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed login.
+func (g *LoginGuard) Fail(username, ip string) error {
+	now := g.now()
+	for i, key := range g.keys(username, ip) {
+		threshold := g.opts.UserThreshold
+		if i == 1 {
+			threshold = g.opts.IPThreshold
+		}
+
+		attempts, _ := g.store.Get(key)
+		attempts.Failures++
+		if attempts.Failures >= threshold {
+			attempts.Failures = 0
+			attempts.Lockouts++
+			attempts.LockedUntil = now.Add(g.lockout(attempts.Lockouts))
+		}
+		ttl := g.opts.Memory
+		if until := attempts.LockedUntil.Sub(now) + g.opts.Memory; until > ttl {
+			ttl = until
+		}
+		if err := g.store.Set(key, attempts, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed forgets the failures of username. The IP address keeps its history, so that one
+// valid account cannot be used to reset the counter of an address guessing other passwords.
+func (g *LoginGuard) Succeed(username string) error {
+	return g.Unlock(username)
+}
+
+// Unlock lifts the lockout of username and forgets its failures.
+func (g *LoginGuard) Unlock(username string) error {
+	return g.store.Delete(userAttemptsKey(username))
+}
+
+func (g *LoginGuard) lockout(lockouts int) time.Duration {
+	d := g.opts.BaseLockout
+	for i := 1; i < lockouts && d < g.opts.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, g.opts.MaxLockout)
+}
+
+func (g *LoginGuard) keys(username, ip string) []string {
+	keys := []string{userAttemptsKey(username)}
+	if ip != "" {
+		keys = append(keys, LoginAttemptsKeyPrefix+"ip:"+ip)
+	}
+	return keys
+}
+
+func userAttemptsKey(username string) string {
+	return LoginAttemptsKeyPrefix + "user:" + strings.ToLower(strings.TrimSpace(username))
+}
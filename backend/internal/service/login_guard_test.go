@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/cache"
+	"backend/internal/service"
+)
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var locked *service.LoginLockedError
+	require.True(t, errors.As(err, &locked), "expected a lockout, got %v", err)
+	return locked.RetryAfter
+}
+
+func TestLoginGuard(t *testing.T) {
+	newGuard := func() *service.LoginGuard {
+		store := cache.NewTypedCache[service.LoginAttempts](time.Hour, time.Minute)
+		return service.NewLoginGuard(store, service.LoginGuardOptions{
+			UserThreshold: 3,
+			IPThreshold:   5,
+			BaseLockout:   time.Minute,
+			MaxLockout:    3 * time.Minute,
+		})
+	}
+
+	t.Run("username lockouts grow exponentially up to the cap", func(t *testing.T) {
+		guard := newGuard()
+		for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+			for i := 0; i < 3; i++ {
+				require.NoError(t, guard.Fail("Mallory", ""))
+			}
+			wait := retryAfter(t, guard.Check("mallory", "10.0.0.1"))
+			require.InDelta(t, want.Seconds(), wait.Seconds(), 1)
+		}
+	})
+
+	t.Run("success resets the username but not the address", func(t *testing.T) {
+		guard := newGuard()
+		for i := 0; i < 2; i++ {
+			require.NoError(t, guard.Fail("alice", "10.0.0.2"))
+		}
+		require.NoError(t, guard.Succeed("alice"))
+		require.NoError(t, guard.Fail("alice", "10.0.0.2"))
+		require.NoError(t, guard.Check("alice", "10.0.0.3"))
+
+		for _, name := range []string{"bob", "carol"} {
+			require.NoError(t, guard.Fail(name, "10.0.0.2"))
+		}
+		retryAfter(t, guard.Check("dave", "10.0.0.2"))
+		require.NoError(t, guard.Check("dave", "10.0.0.4"))
+	})
+
+	t.Run("unlock lifts a lockout", func(t *testing.T) {
+		guard := newGuard()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, guard.Fail("erin", ""))
+		}
+		retryAfter(t, guard.Check("erin", ""))
+		require.NoError(t, guard.Unlock("erin"))
+		require.NoError(t, guard.Check("erin", ""))
+	})
+}
@@ -11,6 +11,17 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrNotMember         = errors.New("user is not a member of this chatroom")
+	ErrDirectRoomMembers = errors.New("cannot add members to a direct conversation")
+	ErrInvalidRole       = errors.New("role must be admin or member")
+	ErrOwnerRole         = errors.New("the owner's role cannot be changed")
+	ErrOwnerCannotLeave  = errors.New("the owner cannot leave the chatroom")
+	ErrBanned            = errors.New("user is banned from this chatroom")
+	ErrInviteNotFound    = errors.New("invite not found")
+	ErrInviteInvalid     = errors.New("invite is expired, revoked or used up")
+)
+
 type membershipService struct {
 	repos *repo.RepoContainer
 	cache cache.Cache[[]model.ChatRoom]
@@ -21,24 +32,51 @@ func NewMembershipService(repos *repo.RepoContainer, c cache.Cache[[]model.ChatR
 }
 
 type MembershipService interface {
-	AddUserToChatRoom(username string, chatRoomID uint) error
+	AddUserToChatRoom(actorID uint, username string, chatRoomID uint) error
+	SetMemberRole(actorID, chatRoomID, targetUserID uint, role string) error
+	LeaveChatRoom(userID, chatRoomID uint) error
+	KickMember(actorID, chatRoomID, targetUserID uint) error
+	BanMember(actorID, chatRoomID, targetUserID uint) error
 	GetUserSubscribedChatRooms(username string) ([]model.ChatRoom, error)
 	GetUserChatRoomsFromDB(username string) ([]model.ChatRoom, error)
+	GetUnreadCounts(username string) (map[uint]int64, error)
+	MarkRead(userID, chatRoomID, messageID uint) (uint, error)
+	CreateInvite(actorID, chatRoomID uint, ttl time.Duration, maxUses int) (*model.RoomInvite, error)
+	PreviewInvite(token string) (*model.InvitePreview, error)
+	AcceptInvite(userID uint, token string) (*model.ChatRoom, error)
+	RevokeInvite(actorID uint, token string) error
 }
 
-func (s *membershipService) AddUserToChatRoom(username string, chatRoomID uint) error {
+// AddUserToChatRoom adds username to a room on behalf of actorID. Anyone may join a public room
+// themselves; adding others or joining a private room needs a role that allows adding members.
+func (s *membershipService) AddUserToChatRoom(actorID uint, username string, chatRoomID uint) error {
 	user, err := s.repos.User.GetByUsername(username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		return err
 	}
 
-	exists, err := s.repos.ChatRoom.ExistsByID(chatRoomID)
-	if err != nil || !exists {
+	room, err := s.repos.ChatRoom.GetByID(chatRoomID)
+	if err != nil {
 		return errors.New("chatroom not found")
 	}
+	if room.Kind == model.ChatRoomDirect {
+		return ErrDirectRoomMembers
+	}
+	banned, err := s.repos.RoomBan.Exists(user.ID, chatRoomID)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return ErrBanned
+	}
+	if user.ID != actorID || room.Kind != model.ChatRoomPublic {
+		if err := requirePermission(s.repos, actorID, chatRoomID, PermAddMembers); err != nil {
+			return err
+		}
+	}
 
 	ok, err := s.repos.UserChatRoom.Exists(user.ID, chatRoomID)
 	if err != nil {
@@ -47,19 +85,27 @@ func (s *membershipService) AddUserToChatRoom(username string, chatRoomID uint)
 	if ok {
 		return errors.New("user already in chatroom")
 	}
+	return s.addMember(user, chatRoomID)
+}
 
+func (s *membershipService) addMember(user *model.User, chatRoomID uint) error {
 	membership := model.UserChatRoom{
 		UserID:     user.ID,
 		ChatRoomID: chatRoomID,
 		JoinedAt:   time.Now(),
+		Role:       model.RoleMember,
 	}
 	if err := s.repos.UserChatRoom.Create(&membership); err != nil {
 		return err
 	}
+	s.recacheUserRooms(user.Username)
+	return nil
+}
 
+// recacheUserRooms reloads the cached subscribed rooms of username after they joined a room.
+func (s *membershipService) recacheUserRooms(username string) {
 	rooms, _ := s.GetUserChatRoomsFromDB(username)
 	s.cache.Set(username, rooms, 30*time.Minute)
-	return nil
 }
 
 func (s *membershipService) GetUserSubscribedChatRooms(username string) ([]model.ChatRoom, error) {
@@ -98,3 +144,290 @@ func (s *membershipService) GetUserChatRoomsFromDB(username string) ([]model.Cha
 
 	return s.repos.UserChatRoom.GetChatRoomsByUserID(user.ID)
 }
+
+// GetUnreadCounts returns the number of unread messages per chat room of a user.
+// Counts are always read from the DB since they change with every message.
+func (s *membershipService) GetUnreadCounts(username string) (map[uint]int64, error) {
+	user, err := s.repos.User.GetByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return map[uint]int64{}, nil
+		}
+		return nil, err
+	}
+	return s.repos.UserChatRoom.CountUnreadByUserID(user.ID)
+}
+
+// MarkRead marks messages up to messageID as read, or up to the latest message when messageID is 0.
+// It returns the stored read marker, which stays put when messageID is older than it.
+func (s *membershipService) MarkRead(userID, chatRoomID, messageID uint) (uint, error) {
+	ok, err := s.repos.UserChatRoom.Exists(userID, chatRoomID)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrNotMember
+	}
+
+	if messageID == 0 {
+		messageID, err = s.repos.Message.GetLatestIDByRoomID(chatRoomID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.repos.UserChatRoom.UpdateLastRead(userID, chatRoomID, messageID); err != nil {
+		return 0, err
+	}
+	membership, err := s.repos.UserChatRoom.Get(userID, chatRoomID)
+	if err != nil {
+		return 0, err
+	}
+	return membership.LastReadMessageID, nil
+}
+
+// SetMemberRole promotes or demotes targetUserID to role on behalf of actorID.
+func (s *membershipService) SetMemberRole(actorID, chatRoomID, targetUserID uint, role string) error {
+	if role != model.RoleAdmin && role != model.RoleMember {
+		return ErrInvalidRole
+	}
+	if err := requirePermission(s.repos, actorID, chatRoomID, PermManageRoles); err != nil {
+		return err
+	}
+
+	target, err := s.repos.UserChatRoom.Get(targetUserID, chatRoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	if target.Role == model.RoleOwner {
+		return ErrOwnerRole
+	}
+	return s.repos.UserChatRoom.UpdateRole(targetUserID, chatRoomID, role)
+}
+
+// LeaveChatRoom removes userID from a room. The owner has to stay so the room keeps an owner.
+func (s *membershipService) LeaveChatRoom(userID, chatRoomID uint) error {
+	membership, err := s.repos.UserChatRoom.Get(userID, chatRoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	if membership.Role == model.RoleOwner {
+		return ErrOwnerCannotLeave
+	}
+	return s.removeMember(userID, chatRoomID)
+}
+
+// KickMember removes targetUserID from a room on behalf of actorID, who has to outrank them.
+func (s *membershipService) KickMember(actorID, chatRoomID, targetUserID uint) error {
+	actor, err := requireMembership(s.repos, actorID, chatRoomID, PermRemoveMembers)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.repos.UserChatRoom.Get(targetUserID, chatRoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	if !Outranks(actor.Role, target.Role) {
+		return ErrForbidden
+	}
+	return s.removeMember(targetUserID, chatRoomID)
+}
+
+// BanMember removes targetUserID from a room if they are a member and keeps them from being added back.
+func (s *membershipService) BanMember(actorID, chatRoomID, targetUserID uint) error {
+	actor, err := requireMembership(s.repos, actorID, chatRoomID, PermRemoveMembers)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.repos.UserChatRoom.Get(targetUserID, chatRoomID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if target != nil && !Outranks(actor.Role, target.Role) {
+		return ErrForbidden
+	}
+	if targetUserID == actorID {
+		return ErrForbidden
+	}
+
+	banned, err := s.repos.RoomBan.Exists(targetUserID, chatRoomID)
+	if err != nil {
+		return err
+	}
+	if !banned {
+		ban := model.RoomBan{ChatRoomID: chatRoomID, UserID: targetUserID, BannedBy: actorID}
+		if err := s.repos.RoomBan.Create(&ban); err != nil {
+			return err
+		}
+	}
+
+	if target == nil {
+		return nil
+	}
+	return s.removeMember(targetUserID, chatRoomID)
+}
+
+// CreateInvite issues an invite link for a room that expires after ttl and allows maxUses joins (0 for no limit).
+func (s *membershipService) CreateInvite(actorID, chatRoomID uint, ttl time.Duration, maxUses int) (*model.RoomInvite, error) {
+	room, err := s.repos.ChatRoom.GetByID(chatRoomID)
+	if err != nil {
+		return nil, errors.New("chatroom not found")
+	}
+	if room.Kind == model.ChatRoomDirect {
+		return nil, ErrDirectRoomMembers
+	}
+	if err := requirePermission(s.repos, actorID, chatRoomID, PermAddMembers); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	invite := model.RoomInvite{
+		Token:      token,
+		ChatRoomID: chatRoomID,
+		CreatedBy:  actorID,
+		ExpiresAt:  time.Now().Add(ttl),
+		MaxUses:    maxUses,
+	}
+	if err := s.repos.RoomInvite.Create(&invite); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// PreviewInvite shows which room a usable invite leads to without joining it.
+func (s *membershipService) PreviewInvite(token string) (*model.InvitePreview, error) {
+	invite, err := s.usableInvite(token)
+	if err != nil {
+		return nil, err
+	}
+	room, err := s.repos.ChatRoom.GetByID(invite.ChatRoomID)
+	if err != nil {
+		return nil, ErrInviteNotFound
+	}
+
+	preview := model.InvitePreview{ChatRoom: *room, ExpiresAt: invite.ExpiresAt}
+	if invite.MaxUses > 0 {
+		preview.UsesLeft = invite.MaxUses - invite.Uses
+	}
+	return &preview, nil
+}
+
+// AcceptInvite adds userID to the invite's room. Existing members get the room back without using up
+// the invite; bans still apply.
+func (s *membershipService) AcceptInvite(userID uint, token string) (*model.ChatRoom, error) {
+	invite, err := s.repos.RoomInvite.GetByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, err
+	}
+	room, err := s.repos.ChatRoom.GetByID(invite.ChatRoomID)
+	if err != nil {
+		return nil, ErrInviteNotFound
+	}
+	ok, err := s.repos.UserChatRoom.Exists(userID, room.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return room, nil
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInviteInvalid
+	}
+
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	banned, err := s.repos.RoomBan.Exists(userID, room.ID)
+	if err != nil {
+		return nil, err
+	}
+	if banned {
+		return nil, ErrBanned
+	}
+
+	membership := model.UserChatRoom{
+		UserID:     user.ID,
+		ChatRoomID: room.ID,
+		JoinedAt:   time.Now(),
+		Role:       model.RoleMember,
+	}
+	used, err := s.repos.RoomInvite.Accept(invite.ID, &membership)
+	if err != nil {
+		return nil, err
+	}
+	if used == 0 {
+		return nil, ErrInviteInvalid
+	}
+	s.recacheUserRooms(user.Username)
+	return room, nil
+}
+
+// RevokeInvite disables an invite. Its creator or anyone who may add members to the room can revoke it.
+func (s *membershipService) RevokeInvite(actorID uint, token string) error {
+	invite, err := s.repos.RoomInvite.GetByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInviteNotFound
+		}
+		return err
+	}
+	if invite.CreatedBy != actorID {
+		if err := requirePermission(s.repos, actorID, invite.ChatRoomID, PermAddMembers); err != nil {
+			return err
+		}
+	}
+	return s.repos.RoomInvite.Revoke(invite.ID)
+}
+
+func (s *membershipService) usableInvite(token string) (*model.RoomInvite, error) {
+	invite, err := s.repos.RoomInvite.GetByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInviteInvalid
+	}
+	return invite, nil
+}
+
+func (s *membershipService) removeMember(userID, chatRoomID uint) error {
+	if _, err := s.repos.UserChatRoom.Delete(userID, chatRoomID); err != nil {
+		return err
+	}
+	invalidateRoomCache(s.repos, s.cache, userID)
+	return nil
+}
+
+// invalidateRoomCache drops the cached subscribed rooms of userIDs, which are keyed by username.
+func invalidateRoomCache(repos *repo.RepoContainer, c cache.Cache[[]model.ChatRoom], userIDs ...uint) {
+	for _, userID := range userIDs {
+		user, err := repos.User.GetByID(userID)
+		if err != nil {
+			continue
+		}
+		if err := c.Delete(user.Username); err != nil {
+			log.Println("cache delete failed:", err)
+		}
+	}
+}
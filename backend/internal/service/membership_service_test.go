@@ -1,12 +1,12 @@
 package service_test
 
 import (
-	//    "errors"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
-	//    "gorm.io/gorm"
+	"gorm.io/gorm"
 
 	"backend/internal/cache"
 	"backend/internal/model"
@@ -65,3 +65,254 @@ func TestGetUserChatRoomsFromDB(t *testing.T) {
 		require.Equal(t, "General", rooms[0].Name)
 	})
 }
+
+func TestMarkReadAndUnreadCounts(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	svc := service.NewMembershipService(repos, setupCache())
+
+	alice := model.User{Username: "alice", Email: "alice@test.com", Password: "test123"}
+	bob := model.User{Username: "bob", Email: "bob@test.com", Password: "test123"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+
+	room := model.ChatRoom{Name: "General"}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&model.UserChatRoom{UserID: alice.ID, ChatRoomID: room.ID, JoinedAt: time.Now()}).Error)
+
+	var msgs []model.Message
+	for _, content := range []string{"one", "two", "three"} {
+		msg := model.Message{UserID: bob.ID, RoomID: room.ID, Content: content}
+		require.NoError(t, repos.Message.Create(&msg))
+		msgs = append(msgs, msg)
+	}
+	// Own messages never count as unread
+	require.NoError(t, repos.Message.Create(&model.Message{UserID: alice.ID, RoomID: room.ID, Content: "mine"}))
+	// nor do thread replies
+	require.NoError(t, repos.Message.Create(&model.Message{UserID: bob.ID, RoomID: room.ID, Content: "re: one", ParentID: &msgs[0].ID}))
+
+	t.Run("all unread initially", func(t *testing.T) {
+		counts, err := svc.GetUnreadCounts("alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), counts[room.ID])
+	})
+
+	t.Run("mark up to a message", func(t *testing.T) {
+		lastRead, err := svc.MarkRead(alice.ID, room.ID, msgs[1].ID)
+		require.NoError(t, err)
+		require.Equal(t, msgs[1].ID, lastRead)
+
+		counts, err := svc.GetUnreadCounts("alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), counts[room.ID])
+	})
+
+	t.Run("marker never moves backwards", func(t *testing.T) {
+		lastRead, err := svc.MarkRead(alice.ID, room.ID, msgs[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, msgs[1].ID, lastRead)
+
+		counts, err := svc.GetUnreadCounts("alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), counts[room.ID])
+	})
+
+	t.Run("zero marks latest", func(t *testing.T) {
+		_, err := svc.MarkRead(alice.ID, room.ID, 0)
+		require.NoError(t, err)
+
+		counts, err := svc.GetUnreadCounts("alice")
+		require.NoError(t, err)
+		require.Zero(t, counts[room.ID])
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		_, err := svc.MarkRead(bob.ID, room.ID, 0)
+		require.ErrorIs(t, err, service.ErrNotMember)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		counts, err := svc.GetUnreadCounts("ghost")
+		require.NoError(t, err)
+		require.Empty(t, counts)
+	})
+}
+
+func TestLeaveKickBan(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	c := setupCache()
+	chatSvc := service.NewChatRoomService(repos, c)
+	svc := service.NewMembershipService(repos, c)
+
+	owner := model.User{Username: "owner", Email: "owner@test.com", Password: "test123"}
+	admin := model.User{Username: "admin", Email: "admin@test.com", Password: "test123"}
+	member := model.User{Username: "member", Email: "member@test.com", Password: "test123"}
+	for _, u := range []*model.User{&owner, &admin, &member} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	room, err := chatSvc.CreateChatRoom("Club", owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.AddUserToChatRoom(admin.ID, "admin", room.ID))
+	require.NoError(t, svc.SetMemberRole(owner.ID, room.ID, admin.ID, model.RoleAdmin))
+	require.NoError(t, svc.AddUserToChatRoom(member.ID, "member", room.ID))
+
+	t.Run("leave drops cached rooms", func(t *testing.T) {
+		_, cached := c.Get("member")
+		require.True(t, cached)
+
+		require.NoError(t, svc.LeaveChatRoom(member.ID, room.ID))
+
+		_, cached = c.Get("member")
+		require.False(t, cached)
+		rooms, err := svc.GetUserChatRoomsFromDB("member")
+		require.NoError(t, err)
+		require.Empty(t, rooms)
+
+		err = svc.LeaveChatRoom(member.ID, room.ID)
+		require.ErrorIs(t, err, service.ErrNotMember)
+	})
+
+	t.Run("owner cannot leave", func(t *testing.T) {
+		err := svc.LeaveChatRoom(owner.ID, room.ID)
+		require.ErrorIs(t, err, service.ErrOwnerCannotLeave)
+	})
+
+	t.Run("kick", func(t *testing.T) {
+		require.NoError(t, svc.AddUserToChatRoom(member.ID, "member", room.ID))
+
+		err := svc.KickMember(member.ID, room.ID, admin.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		err = svc.KickMember(admin.ID, room.ID, owner.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+
+		require.NoError(t, svc.KickMember(admin.ID, room.ID, member.ID))
+		ok, err := repos.UserChatRoom.Exists(member.ID, room.ID)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// kicked users may come back
+		require.NoError(t, svc.AddUserToChatRoom(member.ID, "member", room.ID))
+	})
+
+	t.Run("ban blocks re-adding", func(t *testing.T) {
+		require.NoError(t, svc.BanMember(admin.ID, room.ID, member.ID))
+		ok, err := repos.UserChatRoom.Exists(member.ID, room.ID)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		err = svc.AddUserToChatRoom(member.ID, "member", room.ID)
+		require.ErrorIs(t, err, service.ErrBanned)
+		err = svc.AddUserToChatRoom(owner.ID, "member", room.ID)
+		require.ErrorIs(t, err, service.ErrBanned)
+
+		// banning again is a no-op
+		require.NoError(t, svc.BanMember(owner.ID, room.ID, member.ID))
+	})
+}
+
+func TestInvites(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	c := setupCache()
+	chatSvc := service.NewChatRoomService(repos, c)
+	svc := service.NewMembershipService(repos, c)
+
+	owner := model.User{Username: "owner", Email: "owner@test.com", Password: "test123"}
+	alice := model.User{Username: "alice", Email: "alice@test.com", Password: "test123"}
+	bob := model.User{Username: "bob", Email: "bob@test.com", Password: "test123"}
+	carol := model.User{Username: "carol", Email: "carol@test.com", Password: "test123"}
+	for _, u := range []*model.User{&owner, &alice, &bob, &carol} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	room, err := chatSvc.CreatePrivateChatRoom("Secret", owner.ID)
+	require.NoError(t, err)
+
+	t.Run("members without permission cannot invite", func(t *testing.T) {
+		_, err := svc.CreateInvite(alice.ID, room.ID, time.Hour, 0)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("usage limit", func(t *testing.T) {
+		invite, err := svc.CreateInvite(owner.ID, room.ID, time.Hour, 1)
+		require.NoError(t, err)
+		require.NotEmpty(t, invite.Token)
+
+		preview, err := svc.PreviewInvite(invite.Token)
+		require.NoError(t, err)
+		require.Equal(t, "Secret", preview.ChatRoom.Name)
+		require.Equal(t, 1, preview.UsesLeft)
+
+		joined, err := svc.AcceptInvite(alice.ID, invite.Token)
+		require.NoError(t, err)
+		require.Equal(t, room.ID, joined.ID)
+		rooms, _ := c.Get("alice")
+		require.Len(t, rooms, 1)
+
+		// accepting again as a member doesn't count
+		_, err = svc.AcceptInvite(alice.ID, invite.Token)
+		require.NoError(t, err)
+
+		_, err = svc.AcceptInvite(bob.ID, invite.Token)
+		require.ErrorIs(t, err, service.ErrInviteInvalid)
+		_, err = svc.PreviewInvite(invite.Token)
+		require.ErrorIs(t, err, service.ErrInviteInvalid)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		invite, err := svc.CreateInvite(owner.ID, room.ID, -time.Minute, 0)
+		require.NoError(t, err)
+
+		_, err = svc.AcceptInvite(bob.ID, invite.Token)
+		require.ErrorIs(t, err, service.ErrInviteInvalid)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		invite, err := svc.CreateInvite(owner.ID, room.ID, time.Hour, 0)
+		require.NoError(t, err)
+
+		err = svc.RevokeInvite(alice.ID, invite.Token)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		require.NoError(t, svc.RevokeInvite(owner.ID, invite.Token))
+
+		_, err = svc.AcceptInvite(bob.ID, invite.Token)
+		require.ErrorIs(t, err, service.ErrInviteInvalid)
+	})
+
+	t.Run("bans still apply", func(t *testing.T) {
+		invite, err := svc.CreateInvite(owner.ID, room.ID, time.Hour, 0)
+		require.NoError(t, err)
+		require.NoError(t, svc.BanMember(owner.ID, room.ID, carol.ID))
+
+		_, err = svc.AcceptInvite(carol.ID, invite.Token)
+		require.ErrorIs(t, err, service.ErrBanned)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := svc.AcceptInvite(bob.ID, "nope")
+		require.ErrorIs(t, err, service.ErrInviteNotFound)
+	})
+
+	t.Run("failed joins keep the use", func(t *testing.T) {
+		invite, err := svc.CreateInvite(owner.ID, room.ID, time.Hour, 1)
+		require.NoError(t, err)
+
+		failJoin := func(tx *gorm.DB) {
+			if _, ok := tx.Statement.Dest.(*model.UserChatRoom); ok {
+				tx.AddError(errors.New("disk full"))
+			}
+		}
+		require.NoError(t, db.Callback().Create().Before("gorm:create").Register("fail_join", failJoin))
+		_, err = svc.AcceptInvite(bob.ID, invite.Token)
+		require.Error(t, err)
+		require.NoError(t, db.Callback().Create().Remove("fail_join"))
+
+		preview, err := svc.PreviewInvite(invite.Token)
+		require.NoError(t, err)
+		require.Equal(t, 1, preview.UsesLeft)
+		_, err = svc.AcceptInvite(bob.ID, invite.Token)
+		require.NoError(t, err)
+	})
+}
@@ -3,11 +3,31 @@ package service
 import (
 	"backend/internal/model"
 	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
 	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MessageDeletedEventType is the msg_type of the event sent to a room when a message is deleted.
+const MessageDeletedEventType = "delete"
+
+var (
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrNotMessageAuthor   = errors.New("only the author can edit this message")
+	ErrEmptyContent       = errors.New("message content is required")
+	ErrThreadRoomMismatch = errors.New("parent message belongs to another chat room")
 )
 
 type messageService struct {
 	repos *repo.RepoContainer
+	// Publisher sends mention events to the users a message mentions, and delete events to
+	// the room. Nothing is sent when it is nil.
+	Publisher EventPublisher
+	// Attachments removes the files of deleted messages. They are kept when it is nil.
+	Attachments AttachmentService
 }
 
 func NewMessageService(repos *repo.RepoContainer) *messageService {
@@ -16,13 +36,21 @@ func NewMessageService(repos *repo.RepoContainer) *messageService {
 
 type MessageService interface {
 	CreateMessage(userID, roomID uint, content string) (*model.Message, error)
-	GetMessagesByChatRoom(chatRoomID uint) ([]model.Message, error)
-	DeleteMessage(id uint) error
+	CreateReply(userID, roomID, parentID uint, content string) (*model.Message, error)
+	GetMessagesByChatRoom(userID, chatRoomID uint) ([]model.Message, error)
+	DeleteMessage(id, userID uint) error
 	GetMessagesWithLimit(roomID uint, limit int) ([]model.Message, error)
-	GetMessagesPage(roomID uint, beforeID uint, limit int) ([]model.Message, error)
+	GetMessagesPage(userID, roomID uint, beforeID uint, limit int) ([]model.Message, error)
+	GetThreadPage(userID, parentID uint, beforeID uint, limit int) ([]model.Message, error)
+	EditMessage(id, userID uint, content string) (*model.Message, error)
+	GetMessageRevisions(userID, id uint) ([]model.MessageRevision, error)
 }
 
 func (s *messageService) CreateMessage(userID, roomID uint, content string) (*model.Message, error) {
+	if err := s.requirePoster(userID, roomID); err != nil {
+		return nil, err
+	}
+
 	msg := &model.Message{
 		Content: content,
 		UserID:  userID,
@@ -32,21 +60,127 @@ func (s *messageService) CreateMessage(userID, roomID uint, content string) (*mo
 	if err := s.repos.Message.Create(msg); err != nil {
 		return nil, err
 	}
+	s.recordMentions(msg)
 
 	return msg, nil
 }
 
-func (s *messageService) GetMessagesByChatRoom(chatRoomID uint) ([]model.Message, error) {
-	return s.repos.Message.GetByRoomID(chatRoomID)
+// CreateReply posts a message into the thread of parentID. Replies to a reply are
+// attached to the root of its thread, so threads are a single level deep.
+func (s *messageService) CreateReply(userID, roomID, parentID uint, content string) (*model.Message, error) {
+	if err := s.requirePoster(userID, roomID); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.repos.Message.GetByID(parentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if parent.RoomID != roomID {
+		return nil, ErrThreadRoomMismatch
+	}
+
+	rootID := parent.ID
+	if parent.ParentID != nil {
+		rootID = *parent.ParentID
+	}
+
+	msg := &model.Message{
+		Content:  content,
+		UserID:   userID,
+		RoomID:   roomID,
+		ParentID: &rootID,
+	}
+	if err := s.repos.Message.Create(msg); err != nil {
+		return nil, err
+	}
+	s.recordMentions(msg)
+
+	return msg, nil
 }
 
-func (s *messageService) DeleteMessage(id uint) error {
-	rows, err := s.repos.Message.Delete(id)
+// requirePoster returns ErrBanned or ErrNotMember unless userID may post in roomID.
+func (s *messageService) requirePoster(userID, roomID uint) error {
+	banned, err := s.repos.RoomBan.Exists(userID, roomID)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errors.New("message not found")
+	if banned {
+		return ErrBanned
+	}
+	member, err := s.repos.UserChatRoom.Exists(userID, roomID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// GetMessagesByChatRoom lists the messages of a room that userID can see.
+func (s *messageService) GetMessagesByChatRoom(userID, chatRoomID uint) ([]model.Message, error) {
+	if _, err := getVisibleRoom(s.repos, userID, chatRoomID); err != nil {
+		return nil, err
+	}
+	messages, err := s.repos.Message.GetByRoomID(chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(messages); err != nil {
+		return nil, err
+	}
+	return messages, s.loadAttachments(messages)
+}
+
+// DeleteMessage deletes a message on behalf of userID. Authors may delete their own messages,
+// others need a role that allows deleting messages in the room.
+func (s *messageService) DeleteMessage(id, userID uint) error {
+	msg, err := s.repos.Message.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+	if msg.UserID != userID {
+		if err := requirePermission(s.repos, userID, msg.RoomID, PermDeleteMessages); err != nil {
+			return err
+		}
+	}
+
+	// deleting a thread root takes its replies with it
+	deleted, err := s.repos.Message.Delete(id)
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return ErrMessageNotFound
+	}
+	for _, deletedID := range deleted {
+		if _, err := s.repos.Pin.Delete(deletedID); err != nil {
+			log.Println("Error unpinning deleted message:", err)
+		}
+		if s.Attachments != nil {
+			if err := s.Attachments.DeleteMessageAttachments(deletedID); err != nil {
+				log.Println("Error deleting attachments:", err)
+			}
+		}
+
+		if s.Publisher != nil {
+			event := &kafkapb.KafkaEvent{
+				Id:      uint64(deletedID),
+				UserId:  uint32(userID),
+				RoomId:  uint32(msg.RoomID),
+				MsgType: MessageDeletedEventType,
+			}
+			if err := s.Publisher.HandleOutgoingMessage(event); err != nil {
+				log.Println("Error publishing delete event:", err)
+			}
+		}
 	}
 	return nil
 }
@@ -55,6 +189,135 @@ func (s *messageService) GetMessagesWithLimit(roomID uint, limit int) ([]model.M
 	return s.repos.Message.GetByRoomIDWithLimit(roomID, limit)
 }
 
-func (s *messageService) GetMessagesPage(roomID uint, beforeID uint, limit int) ([]model.Message, error) {
-	return s.repos.Message.GetByRoomIDBeforeWithLimit(roomID, beforeID, limit)
+func (s *messageService) GetMessagesPage(userID, roomID uint, beforeID uint, limit int) ([]model.Message, error) {
+	if _, err := getVisibleRoom(s.repos, userID, roomID); err != nil {
+		return nil, err
+	}
+	messages, err := s.repos.Message.GetByRoomIDBeforeWithLimit(roomID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(messages); err != nil {
+		return nil, err
+	}
+	return messages, s.loadAttachments(messages)
+}
+
+func (s *messageService) GetThreadPage(userID, parentID uint, beforeID uint, limit int) ([]model.Message, error) {
+	if _, err := s.getVisibleMessage(userID, parentID); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repos.Message.GetRepliesBeforeWithLimit(parentID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(messages); err != nil {
+		return nil, err
+	}
+	return messages, s.loadAttachments(messages)
+}
+
+// loadAttachments fills in the files sent with each message.
+func (s *messageService) loadAttachments(messages []model.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	attachments, err := s.repos.Attachment.ListByMessageIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint][]model.Attachment, len(messages))
+	for _, a := range attachments {
+		a.URL = attachmentURL(a.ID)
+		byMessage[*a.MessageID] = append(byMessage[*a.MessageID], a)
+	}
+	for i := range messages {
+		messages[i].Attachments = byMessage[messages[i].ID]
+	}
+	return nil
+}
+
+// attachReactions fills in the aggregated reaction counts of each message.
+func (s *messageService) attachReactions(messages []model.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	counts, err := s.repos.Reaction.CountByMessageIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint][]model.ReactionCount, len(messages))
+	for _, count := range counts {
+		byMessage[count.MessageID] = append(byMessage[count.MessageID], count)
+	}
+	for i := range messages {
+		messages[i].Reactions = byMessage[messages[i].ID]
+	}
+	return nil
+}
+
+// EditMessage replaces the content of a message, keeping the previous content as a revision.
+// Only the author of the message is allowed to edit it.
+func (s *messageService) EditMessage(id, userID uint, content string) (*model.Message, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyContent
+	}
+
+	msg, err := s.repos.Message.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if msg.UserID != userID {
+		return nil, ErrNotMessageAuthor
+	}
+	if msg.Content == content {
+		return msg, nil
+	}
+
+	if err := s.repos.Message.Edit(msg, content, userID); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *messageService) GetMessageRevisions(userID, id uint) ([]model.MessageRevision, error) {
+	if _, err := s.getVisibleMessage(userID, id); err != nil {
+		return nil, err
+	}
+	return s.repos.Revision.GetByMessageID(id)
+}
+
+// getVisibleMessage loads message id when userID can see its room. Messages in rooms
+// userID cannot see do not exist as far as they are concerned.
+func (s *messageService) getVisibleMessage(userID, id uint) (*model.Message, error) {
+	msg, err := s.repos.Message.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if _, err := getVisibleRoom(s.repos, userID, msg.RoomID); err != nil {
+		if errors.Is(err, ErrChatRoomNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return msg, nil
 }
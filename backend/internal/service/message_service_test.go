@@ -1,12 +1,14 @@
 package service_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	//    "gorm.io/gorm"
 
+	"backend/internal/model"
 	"backend/internal/repo"
 	"backend/internal/service"
 )
@@ -15,6 +17,11 @@ func TestMessageService(t *testing.T) {
 	db := setupTestDB(t)
 	repos := repo.NewRepoContainer(db)
 	msgSvc := service.NewMessageService(repos)
+	// only members can post, so user 1 joins every room used below
+	for _, roomID := range []uint{1, 100, 200, 300, 400} {
+		require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: roomID, Name: fmt.Sprintf("room-%d", roomID)}))
+		require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: roomID, Role: model.RoleMember}))
+	}
 
 	t.Run("CreateMessage", func(t *testing.T) {
 		msg, err := msgSvc.CreateMessage(1, 1, "Hello world")
@@ -22,19 +29,62 @@ func TestMessageService(t *testing.T) {
 		require.NotZero(t, msg.ID)
 	})
 
+	t.Run("only members can post", func(t *testing.T) {
+		_, err := msgSvc.CreateMessage(2, 1, "let me in")
+		require.ErrorIs(t, err, service.ErrNotMember)
+
+		root, err := msgSvc.CreateMessage(1, 1, "members only")
+		require.NoError(t, err)
+		_, err = msgSvc.CreateReply(2, 1, root.ID, "let me in")
+		require.ErrorIs(t, err, service.ErrNotMember)
+
+		require.NoError(t, repos.RoomBan.Create(&model.RoomBan{ChatRoomID: 1, UserID: 2, BannedBy: 1}))
+		_, err = msgSvc.CreateMessage(2, 1, "still here")
+		require.ErrorIs(t, err, service.ErrBanned)
+
+		msgs, err := msgSvc.GetMessagesByChatRoom(1, 1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			require.Equal(t, uint(1), msg.UserID)
+		}
+	})
+
 	t.Run("GetMessagesByChatRoom", func(t *testing.T) {
 		// create two messages for the chat room
 		_, _ = msgSvc.CreateMessage(1, 100, "msg1")
 		time.Sleep(2 * time.Millisecond)
 		_, _ = msgSvc.CreateMessage(1, 100, "msg2")
 
-		msgs, err := msgSvc.GetMessagesByChatRoom(100)
+		msgs, err := msgSvc.GetMessagesByChatRoom(1, 100)
 		require.NoError(t, err)
 		require.Len(t, msgs, 2)
 		require.Equal(t, "msg1", msgs[0].Content) // ensure order ascending
 		require.Equal(t, "msg2", msgs[1].Content)
 	})
 
+	t.Run("private rooms are hidden from non-members", func(t *testing.T) {
+		require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: 500, Name: "room-500", Kind: model.ChatRoomPrivate}))
+		require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: 500, Role: model.RoleOwner}))
+		root, err := msgSvc.CreateMessage(1, 500, "secret plans")
+		require.NoError(t, err)
+
+		_, err = msgSvc.GetMessagesByChatRoom(2, 500)
+		require.ErrorIs(t, err, service.ErrChatRoomNotFound)
+		_, err = msgSvc.GetMessagesPage(2, 500, 0, 10)
+		require.ErrorIs(t, err, service.ErrChatRoomNotFound)
+		_, err = msgSvc.GetThreadPage(2, root.ID, 0, 10)
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+		_, err = msgSvc.GetMessageRevisions(2, root.ID)
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+
+		msgs, err := msgSvc.GetMessagesPage(1, 500, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		// public rooms can be read before joining
+		_, err = msgSvc.GetMessagesByChatRoom(2, 100)
+		require.NoError(t, err)
+	})
+
 	t.Run("GetMessagesWithLimit", func(t *testing.T) {
 		// create multiple messages
 		for i := 0; i < 5; i++ {
@@ -52,12 +102,40 @@ func TestMessageService(t *testing.T) {
 	t.Run("DeleteMessage", func(t *testing.T) {
 		msg, _ := msgSvc.CreateMessage(1, 300, "to delete")
 
-		err := msgSvc.DeleteMessage(msg.ID)
+		err := msgSvc.DeleteMessage(msg.ID, msg.UserID)
 		require.NoError(t, err)
 
 		// deleting again should return error
-		err = msgSvc.DeleteMessage(msg.ID)
+		err = msgSvc.DeleteMessage(msg.ID, msg.UserID)
 		require.Error(t, err)
 		require.Equal(t, "message not found", err.Error())
 	})
+
+	t.Run("EditMessage", func(t *testing.T) {
+		msg, _ := msgSvc.CreateMessage(1, 400, "helo")
+
+		edited, err := msgSvc.EditMessage(msg.ID, 1, "hello")
+		require.NoError(t, err)
+		require.Equal(t, "hello", edited.Content)
+		require.True(t, edited.Edited)
+		require.NotNil(t, edited.EditedAt)
+
+		revisions, err := msgSvc.GetMessageRevisions(1, msg.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		require.Equal(t, "helo", revisions[0].Content)
+		require.Equal(t, uint(1), revisions[0].EditedBy)
+	})
+
+	t.Run("EditMessage rejects non-author", func(t *testing.T) {
+		msg, _ := msgSvc.CreateMessage(1, 400, "mine")
+
+		_, err := msgSvc.EditMessage(msg.ID, 2, "yours now")
+		require.ErrorIs(t, err, service.ErrNotMessageAuthor)
+	})
+
+	t.Run("EditMessage missing message", func(t *testing.T) {
+		_, err := msgSvc.EditMessage(9999, 1, "ghost")
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+	})
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+)
+
+const (
+	// MentionEventType is the msg_type of the event sent to a user when a message mentions them.
+	MentionEventType = "mention"
+
+	// maxMentionsPerMessage caps the distinct @usernames looked up for one message.
+	maxMentionsPerMessage = 20
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// mentionPattern matches @name when it is not part of a word or an email address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9._@-])@([A-Za-z0-9._-]+)`)
+
+// NotificationService reads and clears a user's notification inbox.
+type NotificationService interface {
+	ListNotifications(userID, beforeID uint, limit int, unreadOnly bool) ([]model.Mention, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID, id uint) error
+	MarkAllRead(userID uint) (int64, error)
+}
+
+type notificationService struct {
+	repos *repo.RepoContainer
+}
+
+func NewNotificationService(repos *repo.RepoContainer) NotificationService {
+	return &notificationService{repos: repos}
+}
+
+// ListNotifications lists userID's mentions. Mentions from rooms the user can no longer see,
+// for example after being kicked from a private room, are kept but lose their message.
+func (s *notificationService) ListNotifications(userID, beforeID uint, limit int, unreadOnly bool) ([]model.Mention, error) {
+	mentions, err := s.repos.Mention.ListByUserID(userID, beforeID, limit, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[uint]bool{}
+	for i := range mentions {
+		roomID := mentions[i].ChatRoomID
+		ok, checked := visible[roomID]
+		if !checked {
+			_, err := getVisibleRoom(s.repos, userID, roomID)
+			if err != nil && !errors.Is(err, ErrChatRoomNotFound) {
+				return nil, err
+			}
+			ok = err == nil
+			visible[roomID] = ok
+		}
+		if !ok {
+			mentions[i].Message = nil
+		}
+	}
+	return mentions, nil
+}
+
+func (s *notificationService) CountUnread(userID uint) (int64, error) {
+	return s.repos.Mention.CountUnread(userID)
+}
+
+// MarkRead marks one of userID's notifications read. Marking a read notification again is fine.
+func (s *notificationService) MarkRead(userID, id uint) error {
+	n, err := s.repos.Mention.MarkRead(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	mention, err := s.repos.Mention.GetByID(id)
+	if err != nil || mention.UserID != userID {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.repos.Mention.MarkAllRead(userID, time.Now())
+}
+
+// parseMentions returns the distinct usernames mentioned in content and whether it mentions @room.
+func parseMentions(content string) (usernames []string, room bool) {
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// "@alice." ends a sentence rather than naming "alice."
+		name := strings.TrimRight(m[1], ".-")
+		switch {
+		case name == "":
+		case name == "room":
+			room = true
+		case !seen[name] && len(usernames) < maxMentionsPerMessage:
+			seen[name] = true
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames, room
+}
+
+// recordMentions stores a mention for every member of the room that msg names, and tells each
+// of them. Members who muted the room are left out of @room. The author is never notified.
+func (s *messageService) recordMentions(msg *model.Message) {
+	usernames, room := parseMentions(msg.Content)
+	if len(usernames) == 0 && !room {
+		return
+	}
+	members, err := s.repos.UserChatRoom.ListMembers(msg.RoomID)
+	if err != nil {
+		log.Println("[mentions] member lookup failed:", err)
+		return
+	}
+	byUser := make(map[uint]model.UserChatRoom, len(members))
+	for _, m := range members {
+		byUser[m.UserID] = m
+	}
+
+	kinds := map[uint]string{}
+	for _, username := range usernames {
+		user, err := s.repos.User.GetByUsername(username)
+		if err != nil {
+			continue
+		}
+		if _, ok := byUser[user.ID]; ok {
+			kinds[user.ID] = model.MentionUser
+		}
+	}
+	if room {
+		for _, m := range members {
+			if _, ok := kinds[m.UserID]; !ok && !m.Muted {
+				kinds[m.UserID] = model.MentionRoom
+			}
+		}
+	}
+	delete(kinds, msg.UserID)
+	if len(kinds) == 0 {
+		return
+	}
+
+	mentions := make([]model.Mention, 0, len(kinds))
+	for _, m := range members {
+		if kind, ok := kinds[m.UserID]; ok {
+			mentions = append(mentions, model.Mention{
+				UserID:     m.UserID,
+				ChatRoomID: msg.RoomID,
+				MessageID:  msg.ID,
+				AuthorID:   msg.UserID,
+				Kind:       kind,
+			})
+		}
+	}
+	if err := s.repos.Mention.CreateBatch(mentions); err != nil {
+		log.Println("[mentions] failed to store mentions:", err)
+		return
+	}
+
+	if s.Publisher == nil {
+		return
+	}
+	for i := range mentions {
+		mentions[i].Message = msg
+		content, err := json.Marshal(mentions[i])
+		if err != nil {
+			continue
+		}
+		// addressed to the mentioned user; fanout sends it to their gateways only
+		event := &kafkapb.KafkaEvent{
+			Id:      uint64(msg.ID),
+			UserId:  uint32(mentions[i].UserID),
+			RoomId:  uint32(msg.RoomID),
+			MsgType: MentionEventType,
+			Content: content,
+		}
+		if err := s.Publisher.HandleOutgoingMessage(event); err != nil {
+			log.Println("[mentions] publish failed:", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestMentions(t *testing.T) {
+	f := setupWebhookFixture(t)
+	producer := &recordingProducer{}
+	messages := service.NewMessageService(f.repos)
+	messages.Publisher = &service.KafkaService{Producer: producer}
+	notifications := service.NewNotificationService(f.repos)
+
+	carol := model.User{Username: "carol.b", Email: "carol@test.com", Password: "x"}
+	outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "x"}
+	require.NoError(t, f.repos.User.Create(&carol))
+	require.NoError(t, f.repos.User.Create(&outsider))
+	require.NoError(t, f.repos.UserChatRoom.Create(&model.UserChatRoom{UserID: carol.ID, ChatRoomID: f.room.ID, Role: model.RoleMember}))
+
+	inbox := func(userID uint) []model.Mention {
+		t.Helper()
+		mentions, err := notifications.ListNotifications(userID, 0, 50, false)
+		require.NoError(t, err)
+		return mentions
+	}
+
+	t.Run("user mentions", func(t *testing.T) {
+		msg, err := messages.CreateMessage(f.owner.ID, f.room.ID, "@member and @carol.b. please look, cc @outsider @owner owner@test.com")
+		require.NoError(t, err)
+
+		for _, userID := range []uint{f.member.ID, carol.ID} {
+			mentions := inbox(userID)
+			require.Len(t, mentions, 1)
+			require.Equal(t, model.MentionUser, mentions[0].Kind)
+			require.Equal(t, msg.ID, mentions[0].MessageID)
+			require.Equal(t, msg.Content, mentions[0].Message.Content)
+		}
+		// not a member, and authors are not notified about themselves
+		require.Empty(t, inbox(outsider.ID))
+		require.Empty(t, inbox(f.owner.ID))
+
+		require.Equal(t, 2, producer.count())
+		event := producer.nth(t, 1)
+		require.Equal(t, service.MentionEventType, event.MsgType)
+		require.Equal(t, uint32(f.room.ID), event.RoomId)
+		var mention model.Mention
+		require.NoError(t, json.Unmarshal(event.Content, &mention))
+		require.Equal(t, event.UserId, uint32(mention.UserID))
+	})
+
+	t.Run("room mentions skip muted members", func(t *testing.T) {
+		_, err := f.repos.UserChatRoom.SetMuted(carol.ID, f.room.ID, true)
+		require.NoError(t, err)
+
+		_, err = messages.CreateMessage(f.member.ID, f.room.ID, "deploy in 5 @room")
+		require.NoError(t, err)
+		require.Len(t, inbox(f.owner.ID), 1)
+		require.Equal(t, model.MentionRoom, inbox(f.owner.ID)[0].Kind)
+		require.Len(t, inbox(carol.ID), 1)
+
+		// muting only silences @room
+		_, err = messages.CreateReply(f.member.ID, f.room.ID, inbox(f.owner.ID)[0].MessageID, "@carol.b you too")
+		require.NoError(t, err)
+		require.Len(t, inbox(carol.ID), 2)
+	})
+
+	t.Run("read state", func(t *testing.T) {
+		unread, err := notifications.CountUnread(carol.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, unread)
+
+		newest := inbox(carol.ID)[0]
+		require.NoError(t, notifications.MarkRead(carol.ID, newest.ID))
+		require.NoError(t, notifications.MarkRead(carol.ID, newest.ID))
+		require.ErrorIs(t, notifications.MarkRead(f.member.ID, newest.ID), service.ErrNotificationNotFound)
+
+		onlyUnread, err := notifications.ListNotifications(carol.ID, 0, 50, true)
+		require.NoError(t, err)
+		require.Len(t, onlyUnread, 1)
+
+		n, err := notifications.MarkAllRead(carol.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+		unread, err = notifications.CountUnread(carol.ID)
+		require.NoError(t, err)
+		require.Zero(t, unread)
+	})
+	t.Run("rooms the user can no longer see hide the message", func(t *testing.T) {
+		secret := model.ChatRoom{Name: "secret", Kind: model.ChatRoomPrivate}
+		require.NoError(t, f.repos.ChatRoom.Create(&secret))
+		for _, userID := range []uint{f.owner.ID, outsider.ID} {
+			require.NoError(t, f.repos.UserChatRoom.Create(&model.UserChatRoom{UserID: userID, ChatRoomID: secret.ID, Role: model.RoleMember}))
+		}
+		_, err := messages.CreateMessage(f.owner.ID, secret.ID, "@outsider the launch codes")
+		require.NoError(t, err)
+		require.NotNil(t, inbox(outsider.ID)[0].Message)
+
+		_, err = f.repos.UserChatRoom.Delete(outsider.ID, secret.ID)
+		require.NoError(t, err)
+		mentions := inbox(outsider.ID)
+		require.Len(t, mentions, 1)
+		require.Equal(t, secret.ID, mentions[0].ChatRoomID)
+		require.Nil(t, mentions[0].Message)
+
+		// public rooms stay readable after leaving
+		_, err = f.repos.UserChatRoom.Delete(carol.ID, f.room.ID)
+		require.NoError(t, err)
+		for _, m := range inbox(carol.ID) {
+			require.NotNil(t, m.Message)
+		}
+	})
+}
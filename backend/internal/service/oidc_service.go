@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/cache"
+	"backend/internal/model"
+	"backend/internal/oidc"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+const (
+	// OIDCStateKeyPrefix namespaces pending logins in the state cache.
+	OIDCStateKeyPrefix = "oidc:state:"
+	oidcStateTTL       = 10 * time.Minute
+	oidcTimeout        = 15 * time.Second
+)
+
+var (
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed  = errors.New("single sign-on failed")
+	ErrOIDCEmailInUse   = errors.New("email is already registered, log in with your password")
+)
+
+var usernameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// OIDCLoginState is what the backend remembers about a login between redirecting to the
+// identity provider and the provider redirecting back.
+type OIDCLoginState struct {
+	Nonce    string
+	Verifier string
+}
+
+// OIDCService logs users in through an OpenID Connect identity provider.
+type OIDCService interface {
+	LoginURL() (string, error)
+	Callback(state, code string, client ClientInfo) (*AuthTokens, error)
+}
+
+type oidcService struct {
+	repos    *repo.RepoContainer
+	provider *oidc.Provider
+	states   cache.Cache[OIDCLoginState]
+}
+
+func NewOIDCService(repos *repo.RepoContainer, provider *oidc.Provider, states cache.Cache[OIDCLoginState]) OIDCService {
+	return &oidcService{repos: repos, provider: provider, states: states}
+}
+
+// LoginURL starts a login and returns the identity provider URL to send the browser to.
+func (s *oidcService) LoginURL() (string, error) {
+	state, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+	url, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+	if err := s.states.Set(OIDCStateKeyPrefix+state, OIDCLoginState{Nonce: nonce, Verifier: verifier}, oidcStateTTL); err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// Callback finishes a login: it redeems code, maps the provider's subject to a user, creating
+// one on first login, and opens a session just like a password login.
+func (s *oidcService) Callback(state, code string, client ClientInfo) (*AuthTokens, error) {
+	if state == "" || code == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	pending, ok := s.states.Get(OIDCStateKeyPrefix + state)
+	if !ok {
+		return nil, ErrInvalidOIDCState
+	}
+	// a state is good for one attempt
+	_ = s.states.Delete(OIDCStateKeyPrefix + state)
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+	idToken, err := s.provider.Exchange(ctx, code, pending.Verifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	if idToken.Nonce != pending.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+
+	user, err := s.userFor(idToken)
+	if err != nil {
+		return nil, err
+	}
+	return startSession(s.repos, user, client)
+}
+
+// userFor returns the user linked to the token's subject. An unknown subject is linked to the
+// local account with the same email when the provider has verified that email, and gets a new
+// account otherwise.
+func (s *oidcService) userFor(idToken *oidc.IDToken) (*model.User, error) {
+	identity, err := s.repos.UserIdentity.Get(idToken.Issuer, idToken.Subject)
+	if err == nil {
+		return s.repos.User.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if idToken.Email == "" {
+		return nil, fmt.Errorf("%w: the identity provider did not share an email address", ErrOIDCLoginFailed)
+	}
+	identity = &model.UserIdentity{Issuer: idToken.Issuer, Subject: idToken.Subject, Email: idToken.Email}
+
+	existing, err := s.repos.User.GetByEmail(idToken.Email)
+	switch {
+	case err == nil && idToken.EmailVerified:
+		identity.UserID = existing.ID
+		if err := s.repos.UserIdentity.Create(identity); err != nil {
+			return nil, err
+		}
+		if !existing.EmailVerified {
+			if err := s.repos.User.MarkEmailVerified(existing.ID); err != nil {
+				return nil, err
+			}
+		}
+		return existing, nil
+	case err == nil:
+		return nil, ErrOIDCEmailInUse
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	username, err := s.availableUsername(idToken)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username:      username,
+		Email:         idToken.Email,
+		EmailVerified: idToken.EmailVerified,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.repos.UserIdentity.CreateWithUser(user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableUsername derives a free username from the token's preferred username or email.
+func (s *oidcService) availableUsername(idToken *oidc.IDToken) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(idToken.Email, "@")
+	}
+	base = strings.Trim(usernameUnsafe.ReplaceAllString(base, "-"), "-.")
+	if len(base) > 32 {
+		base = base[:32]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for range 5 {
+		_, err := s.repos.User.GetByUsername(candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "-" + hex.EncodeToString(suffix)
+	}
+	return "", fmt.Errorf("%w: no free username for %q", ErrOIDCLoginFailed, base)
+}
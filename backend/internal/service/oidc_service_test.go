@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/cache"
+	"backend/internal/model"
+	"backend/internal/oidc"
+	"backend/internal/oidc/oidctest"
+	"backend/internal/repo"
+	"backend/internal/service"
+	"backend/utils"
+)
+
+// authorize follows the login redirect through the mock provider and returns the state and
+// code it sends back to the callback.
+func authorize(t *testing.T, loginURL string) (state, code string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(loginURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/api/auth/oidc/callback", callback.Path)
+	return callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+func TestOIDCService(t *testing.T) {
+	idp, err := oidctest.NewIdP("chat-app")
+	require.NoError(t, err)
+	defer idp.Close()
+
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	auth := service.NewAuthService(repos, setupTestCache(t))
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      idp.Issuer(),
+		ClientID:    "chat-app",
+		RedirectURL: "http://chat.test/api/auth/oidc/callback",
+	})
+	states := cache.NewTypedCache[service.OIDCLoginState](time.Minute, time.Minute)
+	sso := service.NewOIDCService(repos, provider, states)
+
+	login := func(t *testing.T) (*service.AuthTokens, error) {
+		t.Helper()
+		loginURL, err := sso.LoginURL()
+		require.NoError(t, err)
+		state, code := authorize(t, loginURL)
+		return sso.Callback(state, code, service.ClientInfo{UserAgent: "test"})
+	}
+
+	t.Run("first login creates the user", func(t *testing.T) {
+		idp.SetUser(oidctest.User{Subject: "sub-1", Email: "dana@corp.test", EmailVerified: true, PreferredUsername: "dana"})
+
+		tokens, err := login(t)
+		require.NoError(t, err)
+		user, session, err := auth.ValidateJWT(tokens.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, "dana", user.Username)
+		require.True(t, user.EmailVerified)
+		require.Equal(t, tokens.SessionID, session.SessionID)
+
+		again, err := login(t)
+		require.NoError(t, err)
+		require.Equal(t, tokens.UserID, again.UserID)
+		require.NotEqual(t, tokens.SessionID, again.SessionID)
+	})
+
+	t.Run("taken usernames get a suffix", func(t *testing.T) {
+		idp.SetUser(oidctest.User{Subject: "sub-2", Email: "other-dana@corp.test", PreferredUsername: "dana"})
+
+		tokens, err := login(t)
+		require.NoError(t, err)
+		user, err := repos.User.GetByID(tokens.UserID)
+		require.NoError(t, err)
+		require.Regexp(t, `^dana-[a-z0-9]+$`, user.Username)
+		require.False(t, user.EmailVerified)
+	})
+
+	t.Run("verified email links an existing account", func(t *testing.T) {
+		hash, err := utils.HashPassword("pw")
+		require.NoError(t, err)
+		local := model.User{Username: "erin", Email: "erin@corp.test", Password: hash}
+		require.NoError(t, auth.Register(&local))
+
+		idp.SetUser(oidctest.User{Subject: "sub-3", Email: "erin@corp.test", EmailVerified: false})
+		_, err = login(t)
+		require.ErrorIs(t, err, service.ErrOIDCEmailInUse)
+
+		idp.SetUser(oidctest.User{Subject: "sub-3", Email: "erin@corp.test", EmailVerified: true})
+		tokens, err := login(t)
+		require.NoError(t, err)
+		require.Equal(t, local.ID, tokens.UserID)
+	})
+
+	t.Run("state is single use", func(t *testing.T) {
+		loginURL, err := sso.LoginURL()
+		require.NoError(t, err)
+		state, code := authorize(t, loginURL)
+
+		_, err = sso.Callback(state, code, service.ClientInfo{})
+		require.NoError(t, err)
+		_, err = sso.Callback(state, code, service.ClientInfo{})
+		require.ErrorIs(t, err, service.ErrInvalidOIDCState)
+		_, err = sso.Callback("forged", code, service.ClientInfo{})
+		require.ErrorIs(t, err, service.ErrInvalidOIDCState)
+	})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+var ErrForbidden = errors.New("insufficient permissions in this chatroom")
+
+// Permission is an action in a chat room that depends on the member's role.
+type Permission string
+
+const (
+	PermDeleteRoom     Permission = "delete_room"
+	PermDeleteMessages Permission = "delete_messages"
+	PermAddMembers     Permission = "add_members"
+	PermManageRoles    Permission = "manage_roles"
+	PermRemoveMembers  Permission = "remove_members"
+	PermManageWebhooks Permission = "manage_webhooks"
+	PermEditRoom       Permission = "edit_room"
+	PermPinMessages    Permission = "pin_messages"
+)
+
+var rolePermissions = map[string][]Permission{
+	model.RoleOwner:  {PermDeleteRoom, PermDeleteMessages, PermAddMembers, PermManageRoles, PermRemoveMembers, PermManageWebhooks, PermEditRoom, PermPinMessages},
+	model.RoleAdmin:  {PermDeleteMessages, PermAddMembers, PermRemoveMembers, PermManageWebhooks, PermEditRoom, PermPinMessages},
+	model.RoleMember: {},
+}
+
+var roleRanks = map[string]int{
+	model.RoleOwner:  3,
+	model.RoleAdmin:  2,
+	model.RoleMember: 1,
+}
+
+// RoleHas reports whether role grants perm.
+func RoleHas(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks reports whether role is strictly more privileged than other.
+func Outranks(role, other string) bool {
+	return roleRanks[role] > roleRanks[other]
+}
+
+// requirePermission returns ErrForbidden unless userID is a member of chatRoomID whose role grants perm.
+func requirePermission(repos *repo.RepoContainer, userID, chatRoomID uint, perm Permission) error {
+	_, err := requireMembership(repos, userID, chatRoomID, perm)
+	return err
+}
+
+// requireMembership is requirePermission that also returns the membership of userID.
+func requireMembership(repos *repo.RepoContainer, userID, chatRoomID uint, perm Permission) (*model.UserChatRoom, error) {
+	membership, err := repos.UserChatRoom.Get(userID, chatRoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrForbidden
+		}
+		return nil, err
+	}
+	if !RoleHas(membership.Role, perm) {
+		return nil, ErrForbidden
+	}
+	return membership, nil
+}
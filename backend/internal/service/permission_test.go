@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+)
+
+func TestRoleHas(t *testing.T) {
+	require.True(t, service.RoleHas(model.RoleOwner, service.PermDeleteRoom))
+	require.True(t, service.RoleHas(model.RoleAdmin, service.PermDeleteMessages))
+	require.False(t, service.RoleHas(model.RoleAdmin, service.PermManageRoles))
+	require.False(t, service.RoleHas(model.RoleMember, service.PermAddMembers))
+	require.True(t, service.RoleHas(model.RoleAdmin, service.PermPinMessages))
+	require.False(t, service.RoleHas(model.RoleMember, service.PermPinMessages))
+	require.False(t, service.RoleHas("", service.PermAddMembers))
+}
+
+func TestRoomPermissions(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	cache := setupCache()
+	chatSvc := service.NewChatRoomService(repos, cache)
+	memberSvc := service.NewMembershipService(repos, cache)
+	msgSvc := service.NewMessageService(repos)
+
+	owner := model.User{Username: "owner", Email: "owner@test.com", Password: "test123"}
+	admin := model.User{Username: "admin", Email: "admin@test.com", Password: "test123"}
+	member := model.User{Username: "member", Email: "member@test.com", Password: "test123"}
+	outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "test123"}
+	for _, u := range []*model.User{&owner, &admin, &member, &outsider} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	room, err := chatSvc.CreateChatRoom("Staff", owner.ID)
+	require.NoError(t, err)
+
+	t.Run("creator is owner", func(t *testing.T) {
+		m, err := repos.UserChatRoom.Get(owner.ID, room.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.RoleOwner, m.Role)
+	})
+
+	t.Run("join and add members", func(t *testing.T) {
+		// anyone may join a public room themselves
+		require.NoError(t, memberSvc.AddUserToChatRoom(admin.ID, "admin", room.ID))
+		// but not add someone else
+		err := memberSvc.AddUserToChatRoom(admin.ID, "member", room.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		require.NoError(t, memberSvc.AddUserToChatRoom(owner.ID, "member", room.ID))
+	})
+
+	t.Run("promote and demote", func(t *testing.T) {
+		err := memberSvc.SetMemberRole(member.ID, room.ID, admin.ID, model.RoleAdmin)
+		require.ErrorIs(t, err, service.ErrForbidden)
+
+		require.NoError(t, memberSvc.SetMemberRole(owner.ID, room.ID, admin.ID, model.RoleAdmin))
+		m, err := repos.UserChatRoom.Get(admin.ID, room.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.RoleAdmin, m.Role)
+
+		err = memberSvc.SetMemberRole(owner.ID, room.ID, owner.ID, model.RoleMember)
+		require.ErrorIs(t, err, service.ErrOwnerRole)
+
+		err = memberSvc.SetMemberRole(owner.ID, room.ID, outsider.ID, model.RoleAdmin)
+		require.ErrorIs(t, err, service.ErrNotMember)
+
+		err = memberSvc.SetMemberRole(owner.ID, room.ID, admin.ID, model.RoleOwner)
+		require.ErrorIs(t, err, service.ErrInvalidRole)
+	})
+
+	t.Run("admins add members", func(t *testing.T) {
+		require.NoError(t, memberSvc.AddUserToChatRoom(admin.ID, "outsider", room.ID))
+	})
+
+	t.Run("delete messages", func(t *testing.T) {
+		msg, err := msgSvc.CreateMessage(owner.ID, room.ID, "rules")
+		require.NoError(t, err)
+
+		err = msgSvc.DeleteMessage(msg.ID, member.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		require.NoError(t, msgSvc.DeleteMessage(msg.ID, admin.ID))
+
+		own, err := msgSvc.CreateMessage(member.ID, room.ID, "oops")
+		require.NoError(t, err)
+		require.NoError(t, msgSvc.DeleteMessage(own.ID, member.ID))
+	})
+
+	t.Run("delete room", func(t *testing.T) {
+		err := chatSvc.DeleteChatRoom(room.ID, admin.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		require.NoError(t, chatSvc.DeleteChatRoom(room.ID, owner.ID))
+	})
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// PinEventType is the msg_type of the event sent to a room when a message is pinned or unpinned.
+const PinEventType = "pin"
+
+// MaxPinnedMessages caps the pins of a room.
+const MaxPinnedMessages = 50
+
+var (
+	ErrAlreadyPinned = errors.New("message is already pinned")
+	ErrPinNotFound   = errors.New("message is not pinned")
+	ErrTooManyPins   = errors.New("room has too many pinned messages")
+)
+
+// PinEvent is the content of a PinEventType event.
+type PinEvent struct {
+	MessageID uint `json:"message_id"`
+	Pinned    bool `json:"pinned"`
+	PinnedBy  uint `json:"pinned_by"`
+}
+
+type pinService struct {
+	repos *repo.RepoContainer
+}
+
+func NewPinService(repos *repo.RepoContainer) *pinService {
+	return &pinService{repos: repos}
+}
+
+// PinService manages the pinned messages of rooms. Room owners and admins pin and unpin,
+// every member can list them.
+type PinService interface {
+	PinMessage(userID, messageID uint) (*model.PinnedMessage, error)
+	UnpinMessage(userID, messageID uint) (*model.Message, error)
+	ListPins(userID, chatRoomID uint) ([]model.PinnedMessage, error)
+}
+
+func (s *pinService) PinMessage(userID, messageID uint) (*model.PinnedMessage, error) {
+	msg, err := s.getMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePermission(s.repos, userID, msg.RoomID, PermPinMessages); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repos.Pin.Exists(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAlreadyPinned
+	}
+	count, err := s.repos.Pin.CountByChatRoomID(msg.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxPinnedMessages {
+		return nil, ErrTooManyPins
+	}
+
+	pin := &model.PinnedMessage{
+		ChatRoomID: msg.RoomID,
+		MessageID:  msg.ID,
+		PinnedByID: userID,
+	}
+	// the Exists check above can race with a concurrent pin; the unique index settles it
+	rows, err := s.repos.Pin.Create(pin)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrAlreadyPinned
+	}
+	pin.Message = msg
+	return pin, nil
+}
+
+// UnpinMessage returns the unpinned message so callers can address events to its room.
+func (s *pinService) UnpinMessage(userID, messageID uint) (*model.Message, error) {
+	msg, err := s.getMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePermission(s.repos, userID, msg.RoomID, PermPinMessages); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repos.Pin.Delete(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrPinNotFound
+	}
+	return msg, nil
+}
+
+// ListPins returns the pins of chatRoomID newest first. Only members see them.
+func (s *pinService) ListPins(userID, chatRoomID uint) ([]model.PinnedMessage, error) {
+	member, err := s.repos.UserChatRoom.Exists(userID, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrForbidden
+	}
+	return s.repos.Pin.ListByChatRoomID(chatRoomID)
+}
+
+func (s *pinService) getMessage(messageID uint) (*model.Message, error) {
+	msg, err := s.repos.Message.GetByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/service"
+)
+
+func TestPinService(t *testing.T) {
+	f := setupWebhookFixture(t)
+	pins := service.NewPinService(f.repos)
+	messages := service.NewMessageService(f.repos)
+
+	msg, err := messages.CreateMessage(f.member.ID, f.room.ID, "release at 5pm")
+	require.NoError(t, err)
+
+	t.Run("only owners and admins pin", func(t *testing.T) {
+		_, err := pins.PinMessage(f.member.ID, msg.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		_, err = pins.PinMessage(f.owner.ID, msg.ID+100)
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+	})
+
+	t.Run("pin, list and unpin", func(t *testing.T) {
+		pin, err := pins.PinMessage(f.owner.ID, msg.ID)
+		require.NoError(t, err)
+		require.Equal(t, f.room.ID, pin.ChatRoomID)
+		require.Equal(t, f.owner.ID, pin.PinnedByID)
+		_, err = pins.PinMessage(f.owner.ID, msg.ID)
+		require.ErrorIs(t, err, service.ErrAlreadyPinned)
+		// a pin racing past the Exists check is absorbed by the unique index
+		rows, err := f.repos.Pin.Create(&model.PinnedMessage{ChatRoomID: f.room.ID, MessageID: msg.ID, PinnedByID: f.owner.ID})
+		require.NoError(t, err)
+		require.Zero(t, rows)
+
+		list, err := pins.ListPins(f.member.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, "release at 5pm", list[0].Message.Content)
+
+		_, err = pins.UnpinMessage(f.member.ID, msg.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		unpinned, err := pins.UnpinMessage(f.owner.ID, msg.ID)
+		require.NoError(t, err)
+		require.Equal(t, msg.ID, unpinned.ID)
+		_, err = pins.UnpinMessage(f.owner.ID, msg.ID)
+		require.ErrorIs(t, err, service.ErrPinNotFound)
+	})
+
+	t.Run("outsiders cannot list pins", func(t *testing.T) {
+		outsider := model.User{Username: "outsider", Email: "outsider@test.com", Password: "x"}
+		require.NoError(t, f.repos.User.Create(&outsider))
+		_, err := pins.ListPins(outsider.ID, f.room.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("deleting a message unpins it", func(t *testing.T) {
+		_, err := pins.PinMessage(f.owner.ID, msg.ID)
+		require.NoError(t, err)
+		require.NoError(t, messages.DeleteMessage(msg.ID, f.member.ID))
+
+		list, err := pins.ListPins(f.member.ID, f.room.ID)
+		require.NoError(t, err)
+		require.Empty(t, list)
+	})
+
+	t.Run("pins per room are capped", func(t *testing.T) {
+		for i := 0; i < service.MaxPinnedMessages; i++ {
+			m, err := messages.CreateMessage(f.owner.ID, f.room.ID, "note")
+			require.NoError(t, err)
+			_, err = pins.PinMessage(f.owner.ID, m.ID)
+			require.NoError(t, err)
+		}
+		m, err := messages.CreateMessage(f.owner.ID, f.room.ID, "one too many")
+		require.NoError(t, err)
+		_, err = pins.PinMessage(f.owner.ID, m.ID)
+		require.ErrorIs(t, err, service.ErrTooManyPins)
+	})
+}
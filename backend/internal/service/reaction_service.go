@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+const maxEmojiLength = 64
+
+var (
+	ErrInvalidEmoji     = errors.New("emoji is required and must be at most 64 characters")
+	ErrReactionExists   = errors.New("reaction already exists")
+	ErrReactionNotFound = errors.New("reaction not found")
+)
+
+type reactionService struct {
+	repos *repo.RepoContainer
+}
+
+func NewReactionService(repos *repo.RepoContainer) *reactionService {
+	return &reactionService{repos: repos}
+}
+
+// ReactionService manages emoji reactions. Add and remove return the message the
+// reaction belongs to so callers can address events to its room.
+type ReactionService interface {
+	AddReaction(messageID, userID uint, emoji string) (*model.Message, error)
+	RemoveReaction(messageID, userID uint, emoji string) (*model.Message, error)
+	GetReactions(messageID uint) ([]model.MessageReaction, error)
+}
+
+func (s *reactionService) AddReaction(messageID, userID uint, emoji string) (*model.Message, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" || len(emoji) > maxEmojiLength {
+		return nil, ErrInvalidEmoji
+	}
+
+	msg, err := s.getMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repos.Reaction.Exists(messageID, userID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrReactionExists
+	}
+
+	reaction := &model.MessageReaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+	}
+	if err := s.repos.Reaction.Create(reaction); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *reactionService) RemoveReaction(messageID, userID uint, emoji string) (*model.Message, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return nil, ErrInvalidEmoji
+	}
+
+	msg, err := s.getMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repos.Reaction.Delete(messageID, userID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrReactionNotFound
+	}
+	return msg, nil
+}
+
+func (s *reactionService) GetReactions(messageID uint) ([]model.MessageReaction, error) {
+	if _, err := s.getMessage(messageID); err != nil {
+		return nil, err
+	}
+	return s.repos.Reaction.GetByMessageID(messageID)
+}
+
+func (s *reactionService) getMessage(messageID uint) (*model.Message, error) {
+	msg, err := s.repos.Message.GetByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+)
+
+func TestReactionService(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	msgSvc := service.NewMessageService(repos)
+	reactionSvc := service.NewReactionService(repos)
+
+	require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: 10, Name: "ship"}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: 10, Role: model.RoleMember}))
+	msg, err := msgSvc.CreateMessage(1, 10, "ship it")
+	require.NoError(t, err)
+
+	t.Run("AddReaction", func(t *testing.T) {
+		reacted, err := reactionSvc.AddReaction(msg.ID, 1, "👍")
+		require.NoError(t, err)
+		require.Equal(t, uint(10), reacted.RoomID)
+
+		_, err = reactionSvc.AddReaction(msg.ID, 2, "👍")
+		require.NoError(t, err)
+		_, err = reactionSvc.AddReaction(msg.ID, 2, "🎉")
+		require.NoError(t, err)
+	})
+
+	t.Run("AddReaction duplicate", func(t *testing.T) {
+		_, err := reactionSvc.AddReaction(msg.ID, 1, "👍")
+		require.ErrorIs(t, err, service.ErrReactionExists)
+	})
+
+	t.Run("AddReaction invalid", func(t *testing.T) {
+		_, err := reactionSvc.AddReaction(msg.ID, 1, "  ")
+		require.ErrorIs(t, err, service.ErrInvalidEmoji)
+
+		_, err = reactionSvc.AddReaction(9999, 1, "👍")
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+	})
+
+	t.Run("messages carry reaction counts", func(t *testing.T) {
+		msgs, err := msgSvc.GetMessagesPage(1, 10, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		require.Len(t, msgs[0].Reactions, 2)
+		require.Equal(t, "👍", msgs[0].Reactions[0].Emoji)
+		require.Equal(t, int64(2), msgs[0].Reactions[0].Count)
+		require.Equal(t, "🎉", msgs[0].Reactions[1].Emoji)
+		require.Equal(t, int64(1), msgs[0].Reactions[1].Count)
+	})
+
+	t.Run("RemoveReaction", func(t *testing.T) {
+		_, err := reactionSvc.RemoveReaction(msg.ID, 2, "🎉")
+		require.NoError(t, err)
+
+		_, err = reactionSvc.RemoveReaction(msg.ID, 2, "🎉")
+		require.ErrorIs(t, err, service.ErrReactionNotFound)
+
+		reactions, err := reactionSvc.GetReactions(msg.ID)
+		require.NoError(t, err)
+		require.Len(t, reactions, 2)
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/cache"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// RevokedJTIKeyPrefix namespaces revoked JTIs in Redis. The connection gateway checks the same keys.
+const RevokedJTIKeyPrefix = "jwt:revoked:"
+
+// RevokedSessionKeyPrefix marks an entry of the revocation list as a whole session rather than
+// one token: every access token carrying that SessionID claim is revoked. The connection
+// gateway checks the same keys.
+const RevokedSessionKeyPrefix = "session:"
+
+// RevocationList is a cache.Cache[BlockEntry] shared by every replica. Entries go to the
+// BlockedJWT table first and are mirrored into a fast cache, usually Redis. Cache misses fall back
+// to the table, so revocations survive a Redis flush.
+type RevocationList struct {
+	cache cache.Cache[BlockEntry]
+	repo  repo.BlockedJWTRepo
+}
+
+func NewRevocationList(c cache.Cache[BlockEntry], blocked repo.BlockedJWTRepo) *RevocationList {
+	return &RevocationList{cache: c, repo: blocked}
+}
+
+var _ cache.Cache[BlockEntry] = (*RevocationList)(nil)
+
+func (l *RevocationList) Set(jti string, entry BlockEntry, ttl time.Duration) error {
+	if err := l.repo.Create(&model.BlockedJWT{JTI: jti, ExpiresAt: entry.Exp}); err != nil {
+		return err
+	}
+	if err := l.cache.Set(RevokedJTIKeyPrefix+jti, entry, ttl); err != nil {
+		log.Println("revocation cache set failed:", err)
+	}
+	return nil
+}
+
+func (l *RevocationList) Get(jti string) (BlockEntry, bool) {
+	if entry, ok := l.cache.Get(RevokedJTIKeyPrefix + jti); ok {
+		return entry, true
+	}
+
+	blocked, err := l.repo.GetActive(jti)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Println("revocation lookup failed:", err)
+		}
+		return BlockEntry{}, false
+	}
+	entry := BlockEntry{JTI: blocked.JTI, Exp: blocked.ExpiresAt}
+	if err := l.cache.Set(RevokedJTIKeyPrefix+jti, entry, time.Until(blocked.ExpiresAt)); err != nil {
+		log.Println("revocation cache set failed:", err)
+	}
+	return entry, true
+}
+
+// Delete only drops the cached copy; the durable record expires with the token.
+func (l *RevocationList) Delete(jti string) error {
+	return l.cache.Delete(RevokedJTIKeyPrefix + jti)
+}
+
+// Restore copies the unexpired revocations back into the cache, e.g. after Redis was flushed on
+// startup, and prunes the expired ones.
+func (l *RevocationList) Restore() error {
+	if err := l.repo.DeleteExpired(); err != nil {
+		return err
+	}
+	blocked, err := l.repo.ListActive()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocked {
+		entry := BlockEntry{JTI: b.JTI, Exp: b.ExpiresAt}
+		if err := l.cache.Set(RevokedJTIKeyPrefix+b.JTI, entry, time.Until(b.ExpiresAt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+	"backend/utils"
+)
+
+func TestRevocationList(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+
+	replicaA := setupTestCache(t)
+	list := service.NewRevocationList(replicaA, repos.BlockedJWT)
+	exp := time.Now().Add(time.Hour)
+	require.NoError(t, list.Set("jti-1", service.BlockEntry{JTI: "jti-1", Exp: exp}, time.Until(exp)))
+	require.NoError(t, list.Set("jti-1", service.BlockEntry{JTI: "jti-1", Exp: exp}, time.Until(exp)))
+
+	_, ok := replicaA.Get(service.RevokedJTIKeyPrefix + "jti-1")
+	require.True(t, ok)
+
+	t.Run("falls back to the table on a cache miss", func(t *testing.T) {
+		replicaB := setupTestCache(t)
+		other := service.NewRevocationList(replicaB, repos.BlockedJWT)
+
+		entry, ok := other.Get("jti-1")
+		require.True(t, ok)
+		require.Equal(t, "jti-1", entry.JTI)
+		_, cached := replicaB.Get(service.RevokedJTIKeyPrefix + "jti-1")
+		require.True(t, cached)
+
+		_, ok = other.Get("jti-2")
+		require.False(t, ok)
+	})
+
+	t.Run("restore refills the cache and drops expired tokens", func(t *testing.T) {
+		require.NoError(t, db.Create(&model.BlockedJWT{JTI: "old", ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+
+		flushed := setupTestCache(t)
+		restored := service.NewRevocationList(flushed, repos.BlockedJWT)
+		require.NoError(t, restored.Restore())
+
+		_, ok := flushed.Get(service.RevokedJTIKeyPrefix + "jti-1")
+		require.True(t, ok)
+		_, ok = restored.Get("old")
+		require.False(t, ok)
+		var count int64
+		require.NoError(t, db.Model(&model.BlockedJWT{}).Where("jti = ?", "old").Count(&count).Error)
+		require.Zero(t, count)
+	})
+
+	t.Run("revoked tokens fail validation", func(t *testing.T) {
+		auth := service.NewAuthService(repos, list)
+		user := model.User{Username: "revoked", Email: "revoked@test.com", Password: "pass"}
+		require.NoError(t, repos.User.Create(&user))
+		session := model.UserSession{UserID: user.ID, SessionID: "s1", SessionVersion: 1, ExpiresAt: time.Now().Add(time.Hour)}
+		require.NoError(t, repos.UserSession.Create(&session))
+
+		token, jti, exp, err := utils.GenerateJWT(user.ID, user.Username, session.SessionID, session.SessionVersion)
+		require.NoError(t, err)
+		_, _, err = auth.ValidateJWT(token)
+		require.NoError(t, err)
+
+		require.NoError(t, auth.Block(jti, exp))
+		_, _, err = auth.ValidateJWT(token)
+		require.EqualError(t, err, "token revoked")
+	})
+}
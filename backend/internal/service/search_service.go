@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/repo"
+	"backend/internal/search"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// MessageSearch is a message search on behalf of a user. Everything but Text is optional.
+type MessageSearch struct {
+	Text       string
+	ChatRoomID uint
+	Author     string
+	From       time.Time
+	To         time.Time
+	Cursor     string
+	Limit      int
+}
+
+// SearchService searches the messages of the rooms a user belongs to.
+type SearchService interface {
+	SearchMessages(userID uint, req MessageSearch) (*search.Result, error)
+}
+
+type searchService struct {
+	repos  *repo.RepoContainer
+	engine search.Engine
+}
+
+func NewSearchService(repos *repo.RepoContainer, engine search.Engine) SearchService {
+	return &searchService{repos: repos, engine: engine}
+}
+
+// SearchMessages returns the messages matching req in the rooms userID is a member of. Asking
+// for a room userID is not in returns ErrForbidden.
+func (s *searchService) SearchMessages(userID uint, req MessageSearch) (*search.Result, error) {
+	query := search.Query{
+		Text:   strings.TrimSpace(req.Text),
+		From:   req.From,
+		To:     req.To,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	}
+	if query.Text == "" {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSearch, search.ErrEmptyQuery)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidSearch)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	query.Limit = min(query.Limit, maxSearchLimit)
+
+	rooms, err := s.repos.UserChatRoom.GetChatRoomsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, room := range rooms {
+		if req.ChatRoomID == 0 || room.ID == req.ChatRoomID {
+			query.RoomIDs = append(query.RoomIDs, room.ID)
+		}
+	}
+	if req.ChatRoomID != 0 && len(query.RoomIDs) == 0 {
+		return nil, ErrForbidden
+	}
+
+	if req.Author != "" {
+		author, err := s.repos.User.GetByUsername(strings.TrimPrefix(req.Author, "@"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &search.Result{Hits: []search.Hit{}}, nil
+			}
+			return nil, err
+		}
+		query.AuthorID = author.ID
+	}
+
+	result, err := s.engine.Search(query)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) || errors.Is(err, search.ErrInvalidCursor) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSearch, err)
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/service"
+)
+
+func TestSearchService(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	messages := service.NewMessageService(repos)
+
+	alice := model.User{Username: "alice", Email: "alice@test.com", Password: "x"}
+	bob := model.User{Username: "bob", Email: "bob@test.com", Password: "x"}
+	ops := model.ChatRoom{Name: "ops"}
+	dev := model.ChatRoom{Name: "dev"}
+	secret := model.ChatRoom{Name: "secret", Kind: model.ChatRoomPrivate}
+	for _, u := range []*model.User{&alice, &bob} {
+		require.NoError(t, repos.User.Create(u))
+	}
+	for _, r := range []*model.ChatRoom{&ops, &dev, &secret} {
+		require.NoError(t, repos.ChatRoom.Create(r))
+	}
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: alice.ID, ChatRoomID: ops.ID}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: alice.ID, ChatRoomID: dev.ID}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: bob.ID, ChatRoomID: ops.ID}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: bob.ID, ChatRoomID: secret.ID}))
+
+	// written before the index exists, so it has to be backfilled
+	early, err := messages.CreateMessage(bob.ID, ops.ID, "Deploying the API to staging")
+	require.NoError(t, err)
+
+	engine, err := search.NewSQLiteEngine(db)
+	require.NoError(t, err)
+	_, err = search.NewSQLiteEngine(db)
+	require.NoError(t, err, "setting up twice is fine")
+	searches := service.NewSearchService(repos, engine)
+
+	_, err = messages.CreateMessage(alice.ID, dev.ID, "deploy <script> failed on café build")
+	require.NoError(t, err)
+	_, err = messages.CreateMessage(bob.ID, secret.ID, "the deploy password is hunter2")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = messages.CreateMessage(alice.ID, ops.ID, "another deploy note")
+		require.NoError(t, err)
+	}
+
+	t.Run("only rooms the user is in", func(t *testing.T) {
+		result, err := searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy*"})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 5)
+		for _, hit := range result.Hits {
+			require.NotEqual(t, secret.ID, hit.ChatRoomID)
+			require.WithinDuration(t, time.Now(), hit.CreatedAt, time.Minute)
+		}
+
+		_, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy", ChatRoomID: secret.ID})
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("snippets are escaped and highlighted", func(t *testing.T) {
+		result, err := searches.SearchMessages(alice.ID, service.MessageSearch{Text: "cafe", ChatRoomID: dev.ID})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 1)
+		require.Equal(t, "deploy &lt;script&gt; failed on <mark>café</mark> build", result.Hits[0].Snippet)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		result, err := searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy*", Author: "@bob"})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 1)
+		require.Equal(t, early.ID, result.Hits[0].MessageID)
+
+		result, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy", Author: "nobody"})
+		require.NoError(t, err)
+		require.Empty(t, result.Hits)
+
+		result, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy*", From: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		require.Empty(t, result.Hits)
+		result, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy*", From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 5)
+
+		_, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "   "})
+		require.ErrorIs(t, err, service.ErrInvalidSearch)
+		_, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "deploy", Cursor: "abc"})
+		require.ErrorIs(t, err, service.ErrInvalidSearch)
+	})
+
+	t.Run("cursor pages newest first", func(t *testing.T) {
+		var ids []uint
+		req := service.MessageSearch{Text: "deploy*", Limit: 2}
+		for {
+			result, err := searches.SearchMessages(alice.ID, req)
+			require.NoError(t, err)
+			for _, hit := range result.Hits {
+				ids = append(ids, hit.MessageID)
+			}
+			if result.NextCursor == "" {
+				break
+			}
+			req.Cursor = result.NextCursor
+		}
+		require.Len(t, ids, 5)
+		for i := 1; i < len(ids); i++ {
+			require.Greater(t, ids[i-1], ids[i])
+		}
+	})
+
+	t.Run("edits and deletes update the index", func(t *testing.T) {
+		_, err := messages.EditMessage(early.ID, bob.ID, "rolled back staging")
+		require.NoError(t, err)
+		result, err := searches.SearchMessages(alice.ID, service.MessageSearch{Text: "rolled"})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 1)
+		result, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "api"})
+		require.NoError(t, err)
+		require.Empty(t, result.Hits)
+
+		require.NoError(t, messages.DeleteMessage(early.ID, bob.ID))
+		result, err = searches.SearchMessages(alice.ID, service.MessageSearch{Text: "rolled"})
+		require.NoError(t, err)
+		require.Empty(t, result.Hits)
+	})
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"backend/internal/aisearch"
+	"backend/internal/model"
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+)
+
+const (
+	defaultSemanticResults = 10
+	maxSemanticResults     = 50
+)
+
+var ErrSemanticSearchUnavailable = errors.New("semantic search is unavailable")
+
+// SemanticHit is a message that matches a semantic search, with its relevance score.
+type SemanticHit struct {
+	Message model.Message `json:"message"`
+	Score   float64       `json:"score"`
+}
+
+// SemanticSearchService searches the messages of a room by meaning through the AI service.
+type SemanticSearchService interface {
+	Search(userID, chatRoomID uint, query string, k int) ([]SemanticHit, error)
+}
+
+type semanticSearchService struct {
+	repos  *repo.RepoContainer
+	client *aisearch.Client
+}
+
+func NewSemanticSearchService(repos *repo.RepoContainer, client *aisearch.Client) SemanticSearchService {
+	return &semanticSearchService{repos: repos, client: client}
+}
+
+// Search returns up to k messages of chatRoomID that best match query, best first. Only
+// members of the room may search it.
+func (s *semanticSearchService) Search(userID, chatRoomID uint, query string, k int) ([]SemanticHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSearch)
+	}
+	if k <= 0 {
+		k = defaultSemanticResults
+	}
+	k = min(k, maxSemanticResults)
+
+	member, err := s.repos.UserChatRoom.Exists(userID, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrForbidden
+	}
+
+	results, err := s.client.Search(query, k, map[string]any{"room_id": chatRoomID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSemanticSearchUnavailable, err)
+	}
+
+	hits := make([]SemanticHit, 0, len(results))
+	for _, result := range results {
+		if result.Score <= 0 {
+			continue
+		}
+		id, ok := messageIDFromDocument(result.ID)
+		if !ok {
+			continue
+		}
+		// the index can lag behind; the database has the current text and room
+		msg, err := s.repos.Message.GetByID(id)
+		if err != nil || msg.RoomID != chatRoomID {
+			continue
+		}
+		hits = append(hits, SemanticHit{Message: *msg, Score: result.Score})
+	}
+	return hits, nil
+}
+
+// SemanticIndexer keeps the AI service's index in step with the messages sent to clients.
+type SemanticIndexer struct {
+	repos  *repo.RepoContainer
+	client *aisearch.Client
+}
+
+func NewSemanticIndexer(repos *repo.RepoContainer, client *aisearch.Client) *SemanticIndexer {
+	return &SemanticIndexer{repos: repos, client: client}
+}
+
+// HandleEvent indexes new and edited messages and removes deleted ones.
+func (x *SemanticIndexer) HandleEvent(event *kafkapb.KafkaEvent) {
+	var err error
+	switch event.MsgType {
+	case "message", "edit":
+		if event.Id == 0 || len(event.Content) == 0 {
+			return
+		}
+		err = x.client.Upsert(x.document(event))
+	case MessageDeletedEventType:
+		err = x.client.Delete(messageDocumentID(uint(event.Id)))
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("[semantic] %s of message %d failed: %v", event.MsgType, event.Id, err)
+	}
+}
+
+func (x *SemanticIndexer) document(event *kafkapb.KafkaEvent) aisearch.Document {
+	metadata := map[string]any{
+		"message_id": event.Id,
+		"room_id":    event.RoomId,
+		"author_id":  event.UserId,
+	}
+	if author, err := x.repos.User.GetByID(uint(event.UserId)); err == nil {
+		metadata["author"] = author.Username
+	}
+	if event.ParentId != 0 {
+		metadata["parent_id"] = event.ParentId
+	}
+	return aisearch.Document{
+		ID:       messageDocumentID(uint(event.Id)),
+		Text:     string(event.Content),
+		Metadata: metadata,
+	}
+}
+
+func messageDocumentID(id uint) string {
+	return "message-" + strconv.FormatUint(uint64(id), 10)
+}
+
+func messageIDFromDocument(docID string) (uint, bool) {
+	raw, ok := strings.CutPrefix(docID, "message-")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	return uint(id), err == nil
+}
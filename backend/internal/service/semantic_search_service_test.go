@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/aisearch"
+	"backend/internal/model"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+// fakeAIService stands in for python_ai_service. It scores documents by the share of query
+// words they contain.
+type fakeAIService struct {
+	mu   sync.Mutex
+	docs map[string]aisearch.Document
+	down bool
+}
+
+func (f *fakeAIService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/documents":
+		var doc aisearch.Document
+		_ = json.NewDecoder(r.Body).Decode(&doc)
+		f.docs[doc.ID] = doc
+		_ = json.NewEncoder(w).Encode(doc)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/documents/"):
+		id := strings.TrimPrefix(r.URL.Path, "/documents/")
+		if _, ok := f.docs[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.docs, id)
+	case r.Method == http.MethodPost && r.URL.Path == "/search":
+		var req struct {
+			Query  string         `json:"query"`
+			K      int            `json:"k"`
+			Filter map[string]any `json:"filter"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		results := []aisearch.Result{}
+		words := strings.Fields(strings.ToLower(req.Query))
+	docs:
+		for _, doc := range f.docs {
+			for key, value := range req.Filter {
+				if doc.Metadata[key] != value {
+					continue docs
+				}
+			}
+			var hits float64
+			for _, word := range words {
+				if strings.Contains(strings.ToLower(doc.Text), word) {
+					hits++
+				}
+			}
+			results = append(results, aisearch.Result{ID: doc.ID, Score: hits / float64(len(words)), Text: doc.Text, Metadata: doc.Metadata})
+		}
+		sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+		_ = json.NewEncoder(w).Encode(results[:min(req.K, len(results))])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeAIService) doc(id string) (aisearch.Document, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	doc, ok := f.docs[id]
+	return doc, ok
+}
+
+func TestSemanticSearch(t *testing.T) {
+	f := setupWebhookFixture(t)
+	ai := &fakeAIService{docs: map[string]aisearch.Document{}}
+	srv := httptest.NewServer(ai)
+	defer srv.Close()
+	client := aisearch.NewClient(srv.URL, 0)
+	indexer := service.NewSemanticIndexer(f.repos, client)
+	searches := service.NewSemanticSearchService(f.repos, client)
+	messages := service.NewMessageService(f.repos)
+	producer := &recordingProducer{}
+	messages.Publisher = &service.KafkaService{Producer: producer}
+
+	other := model.ChatRoom{Name: "elsewhere"}
+	require.NoError(t, f.repos.ChatRoom.Create(&other))
+	require.NoError(t, f.repos.UserChatRoom.Create(&model.UserChatRoom{UserID: f.member.ID, ChatRoomID: other.ID, Role: model.RoleMember}))
+
+	post := func(roomID uint, content string) *model.Message {
+		t.Helper()
+		msg, err := messages.CreateMessage(f.member.ID, roomID, content)
+		require.NoError(t, err)
+		indexer.HandleEvent(&kafkapb.KafkaEvent{Id: uint64(msg.ID), UserId: uint32(msg.UserID), RoomId: uint32(msg.RoomID), MsgType: "message", Content: []byte(msg.Content)})
+		return msg
+	}
+	deploy := post(f.room.ID, "how we deploy the backend with docker")
+	post(f.room.ID, "lunch at noon?")
+	post(other.ID, "deploy checklist for the other team")
+
+	t.Run("indexes messages with metadata", func(t *testing.T) {
+		doc, ok := ai.doc("message-" + strconv.FormatUint(uint64(deploy.ID), 10))
+		require.True(t, ok)
+		require.Equal(t, deploy.Content, doc.Text)
+		require.EqualValues(t, f.room.ID, doc.Metadata["room_id"])
+		require.EqualValues(t, f.member.ID, doc.Metadata["author_id"])
+		require.Equal(t, "member", doc.Metadata["author"])
+	})
+
+	t.Run("searches one room for members only", func(t *testing.T) {
+		hits, err := searches.Search(f.owner.ID, f.room.ID, "deploy docker", 5)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		require.Equal(t, deploy.ID, hits[0].Message.ID)
+		require.Equal(t, 1.0, hits[0].Score)
+
+		_, err = searches.Search(f.owner.ID, other.ID, "deploy", 5)
+		require.ErrorIs(t, err, service.ErrForbidden)
+		_, err = searches.Search(f.owner.ID, f.room.ID, " ", 5)
+		require.ErrorIs(t, err, service.ErrInvalidSearch)
+	})
+
+	t.Run("edits and deletes", func(t *testing.T) {
+		indexer.HandleEvent(&kafkapb.KafkaEvent{Id: uint64(deploy.ID), UserId: uint32(f.member.ID), RoomId: uint32(f.room.ID), MsgType: "edit", Content: []byte("we ship with kubernetes now")})
+		hits, err := searches.Search(f.owner.ID, f.room.ID, "kubernetes", 5)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+
+		// a message deleted before the index catches up is left out
+		require.NoError(t, messages.DeleteMessage(deploy.ID, f.member.ID))
+		deleted := producer.nth(t, 1)
+		require.Equal(t, service.MessageDeletedEventType, deleted.MsgType)
+		require.Equal(t, uint64(deploy.ID), deleted.Id)
+		require.Equal(t, uint32(f.room.ID), deleted.RoomId)
+		hits, err = searches.Search(f.owner.ID, f.room.ID, "kubernetes", 5)
+		require.NoError(t, err)
+		require.Empty(t, hits)
+
+		indexer.HandleEvent(&kafkapb.KafkaEvent{Id: uint64(deploy.ID), RoomId: uint32(f.room.ID), MsgType: service.MessageDeletedEventType})
+		_, ok := ai.doc("message-" + strconv.FormatUint(uint64(deploy.ID), 10))
+		require.False(t, ok)
+		// deleting twice is fine
+		indexer.HandleEvent(&kafkapb.KafkaEvent{Id: uint64(deploy.ID), RoomId: uint32(f.room.ID), MsgType: service.MessageDeletedEventType})
+	})
+
+	t.Run("service down", func(t *testing.T) {
+		ai.mu.Lock()
+		ai.down = true
+		ai.mu.Unlock()
+		_, err := searches.Search(f.owner.ID, f.room.ID, "lunch", 5)
+		require.ErrorIs(t, err, service.ErrSemanticSearchUnavailable)
+	})
+}
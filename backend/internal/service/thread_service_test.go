@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+)
+
+func TestMessageService_Threads(t *testing.T) {
+	db := setupTestDB(t)
+	repos := repo.NewRepoContainer(db)
+	msgSvc := service.NewMessageService(repos)
+
+	require.NoError(t, repos.ChatRoom.Create(&model.ChatRoom{ID: 20, Name: "release"}))
+	for _, m := range []model.UserChatRoom{{UserID: 1, ChatRoomID: 20}, {UserID: 2, ChatRoomID: 20}, {UserID: 1, ChatRoomID: 21}} {
+		m.Role = model.RoleMember
+		require.NoError(t, repos.UserChatRoom.Create(&m))
+	}
+
+	root, err := msgSvc.CreateMessage(1, 20, "release tonight?")
+	require.NoError(t, err)
+
+	t.Run("CreateReply updates root", func(t *testing.T) {
+		reply, err := msgSvc.CreateReply(2, 20, root.ID, "yes")
+		require.NoError(t, err)
+		require.Equal(t, root.ID, *reply.ParentID)
+
+		// replying to a reply lands in the root thread
+		nested, err := msgSvc.CreateReply(1, 20, reply.ID, "great")
+		require.NoError(t, err)
+		require.Equal(t, root.ID, *nested.ParentID)
+
+		updated, err := repos.Message.GetByID(root.ID)
+		require.NoError(t, err)
+		require.Equal(t, 2, updated.ReplyCount)
+		require.NotNil(t, updated.LastReplyAt)
+	})
+
+	t.Run("CreateReply validates parent", func(t *testing.T) {
+		_, err := msgSvc.CreateReply(1, 20, 9999, "orphan")
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+
+		_, err = msgSvc.CreateReply(1, 21, root.ID, "wrong room")
+		require.ErrorIs(t, err, service.ErrThreadRoomMismatch)
+	})
+
+	t.Run("room listing hides replies", func(t *testing.T) {
+		msgs, err := msgSvc.GetMessagesPage(1, 20, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		require.Equal(t, root.ID, msgs[0].ID)
+	})
+
+	t.Run("GetThreadPage", func(t *testing.T) {
+		replies, err := msgSvc.GetThreadPage(1, root.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, replies, 2)
+		require.Equal(t, "yes", replies[0].Content)
+		require.Equal(t, "great", replies[1].Content)
+
+		older, err := msgSvc.GetThreadPage(1, root.ID, replies[1].ID, 10)
+		require.NoError(t, err)
+		require.Len(t, older, 1)
+		require.Equal(t, "yes", older[0].Content)
+
+		_, err = msgSvc.GetThreadPage(1, 9999, 0, 10)
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+	})
+
+	t.Run("deleting a reply updates root", func(t *testing.T) {
+		replies, err := msgSvc.GetThreadPage(1, root.ID, 0, 10)
+		require.NoError(t, err)
+		require.NoError(t, msgSvc.DeleteMessage(replies[1].ID, 1))
+
+		updated, err := repos.Message.GetByID(root.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, updated.ReplyCount)
+		require.NotNil(t, updated.LastReplyAt)
+		remaining, err := msgSvc.GetThreadPage(1, root.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+	})
+
+	t.Run("deleting a root deletes its replies", func(t *testing.T) {
+		other, err := msgSvc.CreateMessage(2, 20, "who is on call?")
+		require.NoError(t, err)
+		otherReply, err := msgSvc.CreateReply(1, 20, other.ID, "me")
+		require.NoError(t, err)
+
+		replies, err := msgSvc.GetThreadPage(1, root.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, replies, 1)
+		require.NoError(t, msgSvc.DeleteMessage(root.ID, 1))
+
+		_, err = repos.Message.GetByID(replies[0].ID)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = msgSvc.GetThreadPage(1, root.ID, 0, 10)
+		require.ErrorIs(t, err, service.ErrMessageNotFound)
+
+		// other threads are left alone
+		kept, err := msgSvc.GetThreadPage(1, other.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, kept, 1)
+		require.Equal(t, otherReply.ID, kept[0].ID)
+	})
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	thumbnailSize = 320
+	// maxImagePixels keeps decoding bounded; larger images are stored without a thumbnail.
+	maxImagePixels = 40_000_000
+)
+
+var errImageTooLarge = errors.New("image too large to decode")
+
+// imageSize returns the dimensions of an image without decoding it.
+func imageSize(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	return cfg.Width, cfg.Height, err
+}
+
+// makeThumbnail scales an image down to fit in thumbnailSize x thumbnailSize, keeping its
+// aspect ratio. JPEGs stay JPEG; everything else becomes PNG to keep transparency.
+func makeThumbnail(data []byte) ([]byte, string, error) {
+	width, height, err := imageSize(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if width*height > maxImagePixels {
+		return nil, "", errImageTooLarge
+	}
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	dst := scaleDown(src, thumbnailSize)
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "image/png", err
+}
+
+// scaleDown box-filters src so that neither side exceeds limit. Smaller images are copied as is.
+func scaleDown(src image.Image, limit int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if w > limit || h > limit {
+		if w >= h {
+			dw, dh = limit, h*limit/w
+		} else {
+			dw, dh = w*limit/h, limit
+		}
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					r, g, bl, a = r+uint64(c.R), g+uint64(c.G), bl+uint64(c.B), a+uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	kafkapb "backend/proto/kafka"
+	"gorm.io/gorm"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-Chords-Event"
+	WebhookDeliveryHeader  = "X-Chords-Delivery"
+	WebhookTimestampHeader = "X-Chords-Timestamp"
+	WebhookSignatureHeader = "X-Chords-Signature"
+)
+
+// WebhookPayload is the JSON body of a webhook delivery.
+type WebhookPayload struct {
+	Event      string `json:"event"`
+	ChatRoomID uint   `json:"chat_room_id"`
+	UserID     uint   `json:"user_id"`
+	MessageID  uint64 `json:"message_id,omitempty"`
+	ParentID   uint64 `json:"parent_id,omitempty"`
+	Content    string `json:"content,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// SignWebhook returns the signature header value for body sent at timestamp: the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the webhook secret. Receivers should recompute it and
+// reject old timestamps.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcherOptions tunes a WebhookDispatcher. Zero values fall back to the defaults noted per field.
+type WebhookDispatcherOptions struct {
+	// MaxAttempts is how often a delivery is tried before it fails (6).
+	MaxAttempts int
+	// BaseBackoff is the wait after the first failed attempt; every further attempt doubles it (10 seconds).
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between attempts (1 hour).
+	MaxBackoff time.Duration
+	// DisableAfter is how many failed deliveries in a row disable a webhook (5).
+	DisableAfter int
+	// Timeout bounds one attempt (10 seconds).
+	Timeout time.Duration
+	// PollInterval is how often due deliveries are looked up (2 seconds).
+	PollInterval time.Duration
+	// Concurrency is how many deliveries are sent at once (4).
+	Concurrency int
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local addresses, for
+	// receivers on the local network. Off by default so webhooks cannot probe internal services.
+	AllowPrivateNetworks bool
+}
+
+// WebhookDispatcher turns room events into webhook deliveries and sends them. Deliveries are
+// stored before they are sent, so they survive restarts and can be shared by several backends.
+type WebhookDispatcher struct {
+	repos  *repo.RepoContainer
+	opts   WebhookDispatcherOptions
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookDispatcher(repos *repo.RepoContainer, opts WebhookDispatcherOptions) *WebhookDispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 6
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.DisableAfter <= 0 {
+		opts.DisableAfter = 5
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	client := newOutboundClient(opts.Timeout, opts.AllowPrivateNetworks)
+	// a redirect counts as a failed delivery
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &WebhookDispatcher{repos: repos, opts: opts, client: client, now: time.Now}
+}
+
+// HandleEvent queues a delivery of event for every enabled webhook of its room that subscribes to it.
+func (d *WebhookDispatcher) HandleEvent(event *kafkapb.KafkaEvent) {
+	if event.RoomId == 0 || !slices.Contains(model.WebhookEvents, event.MsgType) {
+		return
+	}
+	hooks, err := d.repos.Webhook.ListEnabledByChatRoomID(uint(event.RoomId))
+	if err != nil {
+		log.Println("[webhooks] failed to list webhooks:", err)
+		return
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		Event:      event.MsgType,
+		ChatRoomID: uint(event.RoomId),
+		UserID:     uint(event.UserId),
+		MessageID:  event.Id,
+		ParentID:   event.ParentId,
+		Content:    string(event.Content),
+		CreatedAt:  event.CreatedAt,
+	})
+	if err != nil {
+		log.Println("[webhooks] failed to encode payload:", err)
+		return
+	}
+	for _, hook := range hooks {
+		if !hook.Subscribes(event.MsgType) {
+			continue
+		}
+		delivery := &model.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event.MsgType,
+			Payload:       string(payload),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: d.now(),
+		}
+		if err := d.repos.Webhook.CreateDelivery(delivery); err != nil {
+			log.Printf("[webhooks] failed to queue delivery for webhook %d: %v", hook.ID, err)
+		}
+	}
+}
+
+// Run sends due deliveries until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one attempt at every delivery that is due and returns how many it attempted.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) int {
+	due, err := d.repos.Webhook.ListDueDeliveries(d.now(), 100)
+	if err != nil {
+		log.Println("[webhooks] failed to list due deliveries:", err)
+		return 0
+	}
+
+	var (
+		wg        sync.WaitGroup
+		attempted int
+		slots     = make(chan struct{}, d.opts.Concurrency)
+	)
+	for i := range due {
+		delivery := &due[i]
+		// Claim a delivery only once a worker is free to send it, and hold it for longer
+		// than an attempt can take, so that other backends skip it and a crashed attempt
+		// is retried later.
+		slots <- struct{}{}
+		claimed, err := d.repos.Webhook.ClaimDelivery(delivery.ID, delivery.Attempts, d.now().Add(2*d.opts.Timeout))
+		if err != nil {
+			log.Printf("[webhooks] failed to claim delivery %d: %v", delivery.ID, err)
+		}
+		if err != nil || !claimed {
+			<-slots
+			continue
+		}
+		delivery.Attempts++
+		attempted++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := d.attempt(ctx, delivery); err != nil {
+				log.Printf("[webhooks] delivery %d: %v", delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return attempted
+}
+
+// attempt sends a claimed delivery once and records the outcome.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	hook, err := d.repos.Webhook.GetByID(delivery.WebhookID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if hook == nil || !hook.Enabled {
+		delivery.Status = model.DeliveryFailed
+		delivery.LastError = ErrWebhookDisabled.Error()
+		return d.repos.Webhook.UpdateDelivery(delivery)
+	}
+
+	code, sendErr := d.send(ctx, hook, delivery)
+	delivery.ResponseCode = code
+	if sendErr == nil {
+		now := d.now()
+		delivery.Status = model.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		if err := d.repos.Webhook.UpdateDelivery(delivery); err != nil {
+			return err
+		}
+		return d.repos.Webhook.RecordSuccess(hook.ID)
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts < d.opts.MaxAttempts {
+		delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+		return d.repos.Webhook.UpdateDelivery(delivery)
+	}
+
+	delivery.Status = model.DeliveryFailed
+	if err := d.repos.Webhook.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	disabled, err := d.repos.Webhook.RecordFailure(hook.ID, d.opts.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("[webhooks] disabled webhook %d after %d failed deliveries", hook.ID, d.opts.DisableAfter)
+	}
+	return nil
+}
+
+// send posts the delivery and returns the response status code.
+func (d *WebhookDispatcher) send(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chords-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// ErrPrivateAddress is returned for webhook receivers on loopback, private or link-local addresses.
+var ErrPrivateAddress = errors.New("webhook receivers must be on a public address")
+
+// isPublicAddress reports whether ip may be reached by webhooks.
+func isPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// newOutboundClient returns an HTTP client for requests to user supplied URLs. Unless
+// allowPrivate is set it only connects to public addresses. That is checked on the resolved
+// address of every connection, so a host that resolves to an internal address later on is
+// refused as well.
+func newOutboundClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = publicAddressOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the receiver and defeat the address check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// publicAddressOnly is a net.Dialer Control function that refuses connections to addresses
+// that are not public.
+func publicAddressOnly(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.opts.BaseBackoff
+	for i := 1; i < attempts && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.MaxBackoff)
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrWebhookDisabled  = errors.New("webhook is disabled")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookService manages the outgoing webhooks of chat rooms. Only room owners and admins
+// can see or change them.
+type WebhookService interface {
+	CreateWebhook(userID, chatRoomID uint, rawURL string, events []string) (hook *model.Webhook, secret string, err error)
+	ListWebhooks(userID, chatRoomID uint) ([]model.Webhook, error)
+	DeleteWebhook(userID, webhookID uint) error
+	EnableWebhook(userID, webhookID uint) error
+	ListDeliveries(userID, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	ReplayDelivery(userID, webhookID, deliveryID uint) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repos *repo.RepoContainer
+	// allowPrivateNetworks accepts receivers on loopback and private addresses.
+	allowPrivateNetworks bool
+}
+
+// NewWebhookService returns a WebhookService. allowPrivateNetworks should match the dispatcher's
+// option of the same name.
+func NewWebhookService(repos *repo.RepoContainer, allowPrivateNetworks bool) WebhookService {
+	return &webhookService{repos: repos, allowPrivateNetworks: allowPrivateNetworks}
+}
+
+// CreateWebhook subscribes rawURL to events in chatRoomID. The returned secret signs every
+// delivery and is not shown again.
+func (s *webhookService) CreateWebhook(userID, chatRoomID uint, rawURL string, events []string) (*model.Webhook, string, error) {
+	if err := requirePermission(s.repos, userID, chatRoomID, PermManageWebhooks); err != nil {
+		return nil, "", err
+	}
+	if err := validateWebhookURL(rawURL, s.allowPrivateNetworks); err != nil {
+		return nil, "", err
+	}
+	if len(events) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	for _, event := range events {
+		if !slices.Contains(model.WebhookEvents, event) {
+			return nil, "", fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	hook := &model.Webhook{
+		ChatRoomID: chatRoomID,
+		CreatedBy:  userID,
+		URL:        rawURL,
+		Secret:     secret,
+		Events:     strings.Join(events, " "),
+		Enabled:    true,
+	}
+	if err := s.repos.Webhook.Create(hook); err != nil {
+		return nil, "", err
+	}
+	return hook, secret, nil
+}
+
+func (s *webhookService) ListWebhooks(userID, chatRoomID uint) ([]model.Webhook, error) {
+	if err := requirePermission(s.repos, userID, chatRoomID, PermManageWebhooks); err != nil {
+		return nil, err
+	}
+	return s.repos.Webhook.ListByChatRoomID(chatRoomID)
+}
+
+func (s *webhookService) DeleteWebhook(userID, webhookID uint) error {
+	hook, err := s.manageable(userID, webhookID)
+	if err != nil {
+		return err
+	}
+	return s.repos.Webhook.Delete(hook.ID)
+}
+
+// EnableWebhook turns a webhook that was disabled after failing too often back on.
+func (s *webhookService) EnableWebhook(userID, webhookID uint) error {
+	hook, err := s.manageable(userID, webhookID)
+	if err != nil {
+		return err
+	}
+	return s.repos.Webhook.Enable(hook.ID)
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *webhookService) ListDeliveries(userID, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	hook, err := s.manageable(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Webhook.ListDeliveries(hook.ID, limit)
+}
+
+// ReplayDelivery sends the payload of an earlier delivery again as a new delivery.
+func (s *webhookService) ReplayDelivery(userID, webhookID, deliveryID uint) (*model.WebhookDelivery, error) {
+	hook, err := s.manageable(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !hook.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+	original, err := s.repos.Webhook.GetDelivery(deliveryID)
+	if err != nil || original.WebhookID != hook.ID {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	replay := &model.WebhookDelivery{
+		WebhookID:     hook.ID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.repos.Webhook.CreateDelivery(replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// manageable returns the webhook if userID may manage the webhooks of its room.
+func (s *webhookService) manageable(userID, webhookID uint) (*model.Webhook, error) {
+	hook, err := s.repos.Webhook.GetByID(webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	if err := requirePermission(s.repos, userID, hook.ChatRoomID, PermManageWebhooks); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// validateWebhookURL checks rawURL and, unless allowPrivate is set, refuses hosts that are
+// obviously internal. Host names are only resolved when a delivery is sent, where the
+// dispatcher refuses internal addresses again.
+func validateWebhookURL(rawURL string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidWebhook)
+	}
+	if allowPrivate {
+		return nil
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %w", ErrInvalidWebhook, ErrPrivateAddress)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublicAddress(ip) {
+		return fmt.Errorf("%w: %w", ErrInvalidWebhook, ErrPrivateAddress)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/service"
+	kafkapb "backend/proto/kafka"
+)
+
+// webhookFixture is a room with an owner and a plain member.
+type webhookFixture struct {
+	repos  *repo.RepoContainer
+	room   model.ChatRoom
+	owner  model.User
+	member model.User
+}
+
+func setupWebhookFixture(t *testing.T) webhookFixture {
+	t.Helper()
+	repos := repo.NewRepoContainer(setupTestDB(t))
+	f := webhookFixture{
+		repos:  repos,
+		room:   model.ChatRoom{Name: "ops"},
+		owner:  model.User{Username: "owner", Email: "owner@test.com", Password: "x"},
+		member: model.User{Username: "member", Email: "member@test.com", Password: "x"},
+	}
+	require.NoError(t, repos.User.Create(&f.owner))
+	require.NoError(t, repos.User.Create(&f.member))
+	require.NoError(t, repos.ChatRoom.Create(&f.room))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: f.owner.ID, ChatRoomID: f.room.ID, Role: model.RoleOwner}))
+	require.NoError(t, repos.UserChatRoom.Create(&model.UserChatRoom{UserID: f.member.ID, ChatRoomID: f.room.ID, Role: model.RoleMember}))
+	return f
+}
+
+func TestWebhookService(t *testing.T) {
+	f := setupWebhookFixture(t)
+	webhooks := service.NewWebhookService(f.repos, false)
+
+	t.Run("only owners and admins manage webhooks", func(t *testing.T) {
+		_, _, err := webhooks.CreateWebhook(f.member.ID, f.room.ID, "https://hooks.test/a", []string{"message"})
+		require.ErrorIs(t, err, service.ErrForbidden)
+		_, err = webhooks.ListWebhooks(f.member.ID, f.room.ID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("validates url and events", func(t *testing.T) {
+		for _, u := range []string{"ftp://hooks.test", "/relative", "https://user:pw@hooks.test"} {
+			_, _, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, u, []string{"message"})
+			require.ErrorIs(t, err, service.ErrInvalidWebhook, u)
+		}
+		_, _, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, "https://hooks.test/a", []string{"session_revoked"})
+		require.ErrorIs(t, err, service.ErrInvalidWebhook)
+	})
+
+	t.Run("refuses internal hosts", func(t *testing.T) {
+		for _, u := range []string{
+			"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://api.localhost/hook", "http://10.1.2.3/hook",
+			"http://192.168.0.10/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "http://0.0.0.0/hook",
+			"http://[::ffff:127.0.0.1]/hook", "http://[fe80::1]/hook",
+		} {
+			_, _, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, u, []string{"message"})
+			require.ErrorIs(t, err, service.ErrPrivateAddress, u)
+			require.ErrorIs(t, err, service.ErrInvalidWebhook, u)
+		}
+		_, _, err := service.NewWebhookService(f.repos, true).CreateWebhook(f.owner.ID, f.room.ID, "http://127.0.0.1:8080/hook", []string{"message"})
+		require.NoError(t, err)
+	})
+
+	t.Run("create, replay and delete", func(t *testing.T) {
+		hook, secret, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, "https://hooks.test/a", []string{"message", "edit"})
+		require.NoError(t, err)
+		require.NotEmpty(t, secret)
+		require.True(t, hook.Enabled)
+
+		original := model.WebhookDelivery{WebhookID: hook.ID, Event: "message", Payload: `{"event":"message"}`, Status: model.DeliveryFailed}
+		require.NoError(t, f.repos.Webhook.CreateDelivery(&original))
+		replay, err := webhooks.ReplayDelivery(f.owner.ID, hook.ID, original.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, original.ID, replay.ID)
+		require.Equal(t, original.Payload, replay.Payload)
+		require.Equal(t, model.DeliveryPending, replay.Status)
+
+		deliveries, err := webhooks.ListDeliveries(f.owner.ID, hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, replay.ID, deliveries[0].ID)
+
+		other, _, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, "https://hooks.test/b", []string{"message"})
+		require.NoError(t, err)
+		_, err = webhooks.ReplayDelivery(f.owner.ID, other.ID, original.ID)
+		require.ErrorIs(t, err, service.ErrDeliveryNotFound)
+
+		require.NoError(t, webhooks.DeleteWebhook(f.owner.ID, hook.ID))
+		require.ErrorIs(t, webhooks.DeleteWebhook(f.owner.ID, hook.ID), service.ErrWebhookNotFound)
+	})
+}
+
+// webhookReceiver records the requests it gets and answers with the next status in statuses.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	newDispatcher := func(repos *repo.RepoContainer) *service.WebhookDispatcher {
+		return service.NewWebhookDispatcher(repos, service.WebhookDispatcherOptions{
+			MaxAttempts:  2,
+			BaseBackoff:  time.Millisecond,
+			DisableAfter: 2,
+			Timeout:      time.Second,
+			// the receivers below listen on loopback
+			AllowPrivateNetworks: true,
+		})
+	}
+	// deliverAll runs the dispatcher until no delivery is due anymore.
+	deliverAll := func(d *service.WebhookDispatcher) {
+		for i := 0; i < 10; i++ {
+			time.Sleep(5 * time.Millisecond)
+			if d.DeliverDue(context.Background()) == 0 {
+				return
+			}
+		}
+	}
+	messageIn := func(room model.ChatRoom) *kafkapb.KafkaEvent {
+		return &kafkapb.KafkaEvent{Id: 42, UserId: 1, RoomId: uint32(room.ID), MsgType: "message", Content: []byte("deploy done"), CreatedAt: 1700000000}
+	}
+
+	t.Run("signs and delivers subscribed events", func(t *testing.T) {
+		f := setupWebhookFixture(t)
+		receiver := &webhookReceiver{}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		hook, secret, err := service.NewWebhookService(f.repos, true).CreateWebhook(f.owner.ID, f.room.ID, server.URL, []string{"message"})
+		require.NoError(t, err)
+		d := newDispatcher(f.repos)
+
+		d.HandleEvent(messageIn(f.room))
+		d.HandleEvent(&kafkapb.KafkaEvent{RoomId: uint32(f.room.ID), MsgType: "edit"})
+		d.HandleEvent(&kafkapb.KafkaEvent{RoomId: uint32(f.room.ID), MsgType: "read"})
+		deliverAll(d)
+
+		require.Len(t, receiver.requests, 1)
+		req, body := receiver.requests[0], receiver.bodies[0]
+		require.Equal(t, "message", req.Header.Get(service.WebhookEventHeader))
+		timestamp, err := strconv.ParseInt(req.Header.Get(service.WebhookTimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, service.SignWebhook(secret, timestamp, body), req.Header.Get(service.WebhookSignatureHeader))
+
+		var payload service.WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, "deploy done", payload.Content)
+		require.Equal(t, f.room.ID, payload.ChatRoomID)
+		require.Equal(t, uint64(42), payload.MessageID)
+
+		deliveries, err := f.repos.Webhook.ListDeliveries(hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, model.DeliverySucceeded, deliveries[0].Status)
+		require.Equal(t, http.StatusOK, deliveries[0].ResponseCode)
+	})
+
+	t.Run("retries and recovers", func(t *testing.T) {
+		f := setupWebhookFixture(t)
+		receiver := &webhookReceiver{statuses: []int{http.StatusBadGateway}}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		hook, _, err := service.NewWebhookService(f.repos, true).CreateWebhook(f.owner.ID, f.room.ID, server.URL, []string{"message"})
+		require.NoError(t, err)
+		d := newDispatcher(f.repos)
+		d.HandleEvent(messageIn(f.room))
+		deliverAll(d)
+
+		require.Len(t, receiver.requests, 2)
+		deliveries, err := f.repos.Webhook.ListDeliveries(hook.ID, 10)
+		require.NoError(t, err)
+		require.Equal(t, model.DeliverySucceeded, deliveries[0].Status)
+		require.Equal(t, 2, deliveries[0].Attempts)
+	})
+
+	t.Run("disables after repeated failures", func(t *testing.T) {
+		f := setupWebhookFixture(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		webhooks := service.NewWebhookService(f.repos, true)
+		hook, _, err := webhooks.CreateWebhook(f.owner.ID, f.room.ID, server.URL, []string{"message"})
+		require.NoError(t, err)
+		d := newDispatcher(f.repos)
+		for i := 0; i < 2; i++ {
+			d.HandleEvent(messageIn(f.room))
+			deliverAll(d)
+		}
+
+		hook, err = f.repos.Webhook.GetByID(hook.ID)
+		require.NoError(t, err)
+		require.False(t, hook.Enabled)
+		require.NotNil(t, hook.DisabledAt)
+
+		deliveries, err := f.repos.Webhook.ListDeliveries(hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		for _, delivery := range deliveries {
+			require.Equal(t, model.DeliveryFailed, delivery.Status)
+			require.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+		}
+
+		// disabled webhooks get no new deliveries and cannot be replayed until enabled again
+		d.HandleEvent(messageIn(f.room))
+		_, err = webhooks.ReplayDelivery(f.owner.ID, hook.ID, deliveries[0].ID)
+		require.ErrorIs(t, err, service.ErrWebhookDisabled)
+		require.NoError(t, webhooks.EnableWebhook(f.owner.ID, hook.ID))
+		_, err = webhooks.ReplayDelivery(f.owner.ID, hook.ID, deliveries[0].ID)
+		require.NoError(t, err)
+		deliveries, err = f.repos.Webhook.ListDeliveries(hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+	})
+
+	t.Run("refuses internal addresses when sending", func(t *testing.T) {
+		f := setupWebhookFixture(t)
+		receiver := &webhookReceiver{}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		// stored directly, as if the hosts had resolved to public addresses when they were created
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+		var hooks []*model.Webhook
+		for _, u := range []string{server.URL, "http://localhost:" + port} {
+			hook := &model.Webhook{ChatRoomID: f.room.ID, CreatedBy: f.owner.ID, URL: u, Secret: "s", Events: "message", Enabled: true}
+			require.NoError(t, f.repos.Webhook.Create(hook))
+			hooks = append(hooks, hook)
+		}
+
+		d := service.NewWebhookDispatcher(f.repos, service.WebhookDispatcherOptions{MaxAttempts: 1, Timeout: time.Second})
+		d.HandleEvent(messageIn(f.room))
+		deliverAll(d)
+
+		require.Empty(t, receiver.requests)
+		for _, hook := range hooks {
+			deliveries, err := f.repos.Webhook.ListDeliveries(hook.ID, 10)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			require.Equal(t, model.DeliveryFailed, deliveries[0].Status)
+			require.Contains(t, deliveries[0].LastError, service.ErrPrivateAddress.Error())
+		}
+	})
+}
@@ -37,7 +37,7 @@ func setupTestDB() *gorm.DB {
 	//assert.NoError(t, err, "failed to connect database")
 
 	// Migrate schema
-	_ = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{})
+	_ = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{}, &model.MessageRevision{}, &model.MessageReaction{}, &model.RoomBan{}, &model.RoomInvite{}, &model.BlockedJWT{}, &model.AccountToken{}, &model.UserIdentity{}, &model.APIToken{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.IncomingWebhook{}, &model.BotCommand{}, &model.Mention{}, &model.Attachment{}, &model.PinnedMessage{})
 	//assert.NoError(t, err, "failed to migrate database")
 
 	return db
@@ -59,6 +59,14 @@ func setupBasicMiddleware() *gin.Engine {
 	return r
 }
 
+// asUser stands in for the auth middleware and authenticates every request as userID.
+func asUser(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
+
 func setupAuthRoute(r *gin.Engine) {
 	db := setupTestDB()
 	repos := repo.NewRepoContainer(db)
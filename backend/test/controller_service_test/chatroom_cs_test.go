@@ -17,11 +17,11 @@ import (
 	//    "gorm.io/driver/sqlite"
 	//    "gorm.io/gorm"
 
+	"backend/internal/cache"
 	"backend/internal/controller"
 	"backend/internal/model"
 	"backend/internal/repo"
 	"backend/internal/service"
-	//	"backend/internal/cache"
 	//	"backend/internal/middleware/jwtauth"
 	"backend/internal/middleware/loadshedding"
 	//	"backend/internal/middleware/logger"
@@ -34,13 +34,14 @@ func setupChatRoomRoute(r *gin.Engine) {
 	repos := repo.NewRepoContainer(db)
 	loadsheddingFunc := loadshedding.LoadShedding(20, 5, 100*time.Millisecond)
 
-	chatRoomService := service.NewChatRoomService(repos)
+	chatRoomService := service.NewChatRoomService(repos, cache.NewTypedCache[[]model.ChatRoom](10*time.Minute, 15*time.Minute))
 	chatRoomController := controller.NewChatRoomController(chatRoomService)
 
 	chatrooms := r.Group("/api/chatrooms")
 
 	//	load shed
 	chatrooms.Use(loadsheddingFunc)
+	chatrooms.Use(asUser(1))
 	// Apply middleware to all /chatrooms routes
 	{
 		chatrooms.POST("", chatRoomController.CreateChatRoom)
@@ -58,6 +58,10 @@ func setupMembershipRoute(r *gin.Engine) {
 	membershipController := controller.NewMembershipController(s)
 	memberships := r.Group("/api/memberships")
 	memberships.Use(loadsheddingFunc)
+	memberships.Use(asUser(user.ID), func(c *gin.Context) {
+		c.Set("uid", user.Username)
+		c.Next()
+	})
 	// Apply middleware to all /chatrooms routes
 	{
 		memberships.POST("/add-user", membershipController.AddUser)
@@ -18,6 +18,7 @@ import (
 	//    "gorm.io/gorm"
 
 	"backend/internal/controller"
+	"backend/internal/model"
 	"backend/internal/repo"
 	"backend/internal/service"
 	//	"backend/internal/cache"
@@ -33,14 +34,16 @@ func setupMessageRoute(r *gin.Engine) {
 	repos := repo.NewRepoContainer(db)
 	loadsheddingFunc := loadshedding.LoadShedding(20, 5, 100*time.Millisecond)
 
+	_ = repos.ChatRoom.Create(&model.ChatRoom{ID: 1, Name: "pixies"})
+	_ = repos.UserChatRoom.Create(&model.UserChatRoom{UserID: 1, ChatRoomID: 1, Role: model.RoleMember})
 	messageService := service.NewMessageService(repos)
 	messageController := controller.NewMessageController(messageService)
 
 	//auth := jwtauth.JWTAuthMiddleware()
 
 	r.POST("/api/messages", loadsheddingFunc, messageController.CreateMessage)
-	r.GET("/api/chatrooms/:id/messages", loadsheddingFunc, messageController.GetMessagesByChatRoom)
-	r.DELETE("/api/messages/:id", loadsheddingFunc, messageController.DeleteMessage)
+	r.GET("/api/chatrooms/:id/messages", loadsheddingFunc, asUser(1), messageController.GetMessagesByChatRoom)
+	r.DELETE("/api/messages/:id", loadsheddingFunc, asUser(1), messageController.DeleteMessage)
 }
 
 func TestMessageCRUD(t *testing.T) {
@@ -62,6 +65,14 @@ func TestMessageCRUD(t *testing.T) {
 
 	require.Equal(t, http.StatusCreated, w.Code)
 
+	//non-members cannot post
+	jsonBody, _ = json.Marshal(map[string]interface{}{"content": "let me in", "user_id": 2, "chat_room_id": 1})
+	req = httptest.NewRequest(http.MethodPost, "/api/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
 	//get all
 	req = httptest.NewRequest(http.MethodGet, "/api/chatrooms/1/messages", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -35,7 +35,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	assert.NoError(t, err, "failed to connect database")
 
 	// Migrate schema
-	err = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{})
+	err = db.AutoMigrate(&model.User{}, &model.UserSession{}, &model.Message{}, &model.ChatRoom{}, &model.UserChatRoom{}, &model.MessageRevision{}, &model.MessageReaction{}, &model.RoomBan{}, &model.RoomInvite{}, &model.BlockedJWT{}, &model.AccountToken{}, &model.UserIdentity{}, &model.APIToken{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.IncomingWebhook{}, &model.BotCommand{}, &model.Mention{}, &model.Attachment{}, &model.PinnedMessage{})
 	assert.NoError(t, err, "failed to migrate database")
 
 	return db
@@ -98,12 +98,12 @@ func TestAuthLogin(t *testing.T) {
 		Return(nil)
 
 	mockService.
-		On("Login", "john", "wrong").
-		Return(uint(0), "", "", "", errors.New("invalid credentials"))
+		On("Login", "john", "wrong", mock.Anything).
+		Return(nil, errors.New("invalid credentials"))
 
 	mockService.
-		On("Login", "john", "correct").
-		Return(uint(1), "token", "jti", "sessionid", nil)
+		On("Login", "john", "correct", mock.Anything).
+		Return(&service.AuthTokens{UserID: 1, AccessToken: "token", JTI: "jti", SessionID: "sessionid", RefreshToken: "sessionid.refresh"}, nil)
 	//fail
 	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -237,13 +237,27 @@ func (m *MockAuthService) Register(user *model.User) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Login(username, password string) (uint, string, string, string, error) {
-	args := m.Called(username, password)
-	return args.Get(0).(uint),
-		args.Get(1).(string),
-		args.Get(2).(string),
-		args.Get(3).(string),
-		args.Error(4)
+func (m *MockAuthService) Login(username, password string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(username, password, client)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) ListSessions(userID uint) ([]model.UserSession, error) {
+	args := m.Called(userID)
+	sessions, _ := args.Get(0).([]model.UserSession)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID uint, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (*service.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	tokens, _ := args.Get(0).(*service.AuthTokens)
+	return tokens, args.Error(1)
 }
 
 func (m *MockAuthService) Logout(tokenStr string) error {
@@ -268,6 +282,12 @@ func (m *MockAuthService) ForceLogoutOne(userID uint, sessionID string) error {
 	return args.Error(0)
 }
 
+func (m *MockAuthService) ForceLogoutOthers(userID uint, keepSessionID string) ([]string, error) {
+	args := m.Called(userID, keepSessionID)
+	sessions, _ := args.Get(0).([]string)
+	return sessions, args.Error(1)
+}
+
 func (m *MockAuthService) GetUserIDByUsername(username string) (uint, error) {
 	args := m.Called(username)
 	return args.Get(0).(uint), args.Error(1)
@@ -18,7 +18,7 @@ import (
 	//    "gorm.io/gorm"
 
 	"backend/internal/model"
-	//    "backend/internal/service"
+	"backend/internal/service"
 	"backend/internal/controller"
 	//	"backend/internal/cache"
 	//	"backend/internal/middleware/jwtauth"
@@ -52,7 +52,7 @@ func TestChatroomCreate(t *testing.T) {
 
 	//fail
 	mockService.
-		On("CreateChatRoom", "general").
+		On("CreateChatRoom", "general", uint(1)).
 		Return(&model.ChatRoom{}, errors.New("DB error")).
 		Once()
 	body := map[string]string{"name": "general"}
@@ -71,7 +71,7 @@ func TestChatroomCreate(t *testing.T) {
 	//success
 	chatRoom := &model.ChatRoom{ID: 1, Name: "general"}
 	mockService.
-		On("CreateChatRoom", "general").
+		On("CreateChatRoom", "general", uint(1)).
 		Return(chatRoom, nil).
 		Once()
 	body = map[string]string{"name": "general"}
@@ -104,7 +104,7 @@ func TestChatroomGetAll(t *testing.T) {
 
 	//fail
 	mockService.
-		On("GetAllChatRooms").
+		On("GetAllChatRooms", uint(1)).
 		Return([]model.ChatRoom{}, errors.New("DB error")).
 		Once()
 
@@ -121,7 +121,7 @@ func TestChatroomGetAll(t *testing.T) {
 	//success
 	chatRoom := []model.ChatRoom{model.ChatRoom{ID: 1, Name: "general"}}
 	mockService.
-		On("GetAllChatRooms").
+		On("GetAllChatRooms", uint(1)).
 		Return(chatRoom, nil).
 		Once()
 
@@ -164,7 +164,7 @@ func TestChatroomGetByID(t *testing.T) {
 	//success
 	//chatRoom := new(model.ChatRoom{ID: 1, Name: "general"})
 	mockService.
-		On("GetChatRoomByID", uint(1)).
+		On("GetVisibleChatRoom", uint(1), uint(1)).
 		Return(&model.ChatRoom{}, nil).
 		Once()
 	req = httptest.NewRequest(http.MethodGet, "/api/chatrooms/1", nil)
@@ -206,7 +206,7 @@ func TestChatroomDelete(t *testing.T) {
 	//success
 	//chatRoom := new(model.ChatRoom{ID: 1, Name: "general"})
 	mockService.
-		On("DeleteChatRoom", uint(1)).
+		On("DeleteChatRoom", uint(1), uint(1)).
 		Return(nil).
 		Once()
 	req = httptest.NewRequest(http.MethodDelete, "/api/chatrooms/1", nil)
@@ -236,7 +236,7 @@ func TestChatroomSearch(t *testing.T) {
 
 	//fail
 	mockService.
-		On("SearchChatRoomsByName", "cccchat").
+		On("SearchChatRoomsByName", uint(1), "cccchat").
 		Return([]model.ChatRoom{}, errors.New("DB error")).
 		Once()
 	req := httptest.NewRequest(http.MethodGet, "/api/chatrooms/search?q=cccchat", nil)
@@ -252,7 +252,7 @@ func TestChatroomSearch(t *testing.T) {
 	//success
 	chatRoom := []model.ChatRoom{model.ChatRoom{ID: 1, Name: "general"}}
 	mockService.
-		On("SearchChatRoomsByName", "gen").
+		On("SearchChatRoomsByName", uint(1), "gen").
 		Return(chatRoom, nil).
 		Once()
 	req = httptest.NewRequest(http.MethodGet, "/api/chatrooms/search?q=gen", nil)
@@ -269,13 +269,23 @@ type MockChatRoomService struct {
 	mock.Mock
 }
 
-func (m *MockChatRoomService) CreateChatRoom(name string) (*model.ChatRoom, error) {
-	args := m.Called(name)
+func (m *MockChatRoomService) CreateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	args := m.Called(name, creatorID)
+	return args.Get(0).(*model.ChatRoom), args.Error(1)
+}
+
+func (m *MockChatRoomService) CreatePrivateChatRoom(name string, creatorID uint) (*model.ChatRoom, error) {
+	args := m.Called(name, creatorID)
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) GetAllChatRooms() ([]model.ChatRoom, error) {
-	args := m.Called()
+func (m *MockChatRoomService) GetOrCreateDirectRoom(userID uint, peerUsername string) (*model.ChatRoom, bool, error) {
+	args := m.Called(userID, peerUsername)
+	return args.Get(0).(*model.ChatRoom), args.Bool(1), args.Error(2)
+}
+
+func (m *MockChatRoomService) GetAllChatRooms(userID uint) ([]model.ChatRoom, error) {
+	args := m.Called(userID)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
 
@@ -284,8 +294,14 @@ func (m *MockChatRoomService) GetChatRoomByID(id uint) (*model.ChatRoom, error)
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) DeleteChatRoom(id uint) error {
-	args := m.Called(id)
+func (m *MockChatRoomService) GetVisibleChatRoom(userID, id uint) (*model.ChatRoom, error) {
+	args := m.Called(userID, id)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockChatRoomService) DeleteChatRoom(id, userID uint) error {
+	args := m.Called(id, userID)
 	return args.Error(0)
 }
 
@@ -294,7 +310,19 @@ func (m *MockChatRoomService) GetChatRoomByName(name string) (*model.ChatRoom, e
 	return args.Get(0).(*model.ChatRoom), args.Error(1)
 }
 
-func (m *MockChatRoomService) SearchChatRoomsByName(keyword string) ([]model.ChatRoom, error) {
-	args := m.Called(keyword)
+func (m *MockChatRoomService) SearchChatRoomsByName(userID uint, keyword string) ([]model.ChatRoom, error) {
+	args := m.Called(userID, keyword)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
+
+func (m *MockChatRoomService) SetTopic(actorID, id uint, topic string) (*model.ChatRoom, error) {
+	args := m.Called(actorID, id, topic)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockChatRoomService) UpdateRoom(actorID, id uint, update service.RoomUpdate) (*model.ChatRoom, error) {
+	args := m.Called(actorID, id, update)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
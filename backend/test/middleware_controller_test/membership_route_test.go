@@ -21,7 +21,7 @@ import (
 	//    "backend/internal/service"
 	"backend/internal/controller"
 	//	"backend/internal/cache"
-	//	"backend/internal/middleware/jwtauth"
+	"backend/internal/middleware/jwtauth"
 	"backend/internal/middleware/loadshedding"
 	//	"backend/internal/middleware/logger"
 	//	"backend/internal/logrus"
@@ -48,7 +48,7 @@ func TestAddUserToChatRoom(t *testing.T) {
 	jsonBody, _ := json.Marshal(msg)
 
 	mockService.
-		On("AddUserToChatRoom", "Thom Yorke", uint(1)).
+		On("AddUserToChatRoom", uint(1), "Thom Yorke", uint(1)).
 		Once().
 		Return(errors.New("DB error"))
 
@@ -65,7 +65,7 @@ func TestAddUserToChatRoom(t *testing.T) {
 	// msg := model.User{Content: "Schizophrenia is taking me home", UserID: uint(1), ChatRoomID: uint(1)}
 	// jsonBody, _ = json.Marshal(msg)
 	mockService.
-		On("AddUserToChatRoom", "Thom Yorke", uint(1)).
+		On("AddUserToChatRoom", uint(1), "Thom Yorke", uint(1)).
 		Once().
 		Return(nil)
 
@@ -87,9 +87,15 @@ func TestGetChatRoomByUser(t *testing.T) {
 	mockService := new(MockMembershipService)
 	membershipController := controller.NewMembershipController(mockService)
 
+	// the token belongs to DaveGrohl, who may only list his own rooms
+	mockAuth := new(MockAuthService)
+	mockAuth.
+		On("ValidateJWT", "correct").
+		Return(&model.User{ID: 1, Username: "DaveGrohl"}, new(model.UserSession), nil)
+
 	membershipRoute := r.Group("/api/memberships")
 	membershipRoute.Use(loadsheddingFunc)
-	membershipRoute.Use(setupAuthMiddleware(t))
+	membershipRoute.Use(jwtauth.NewAuthMiddleware(mockAuth).Auth())
 	{
 		membershipRoute.GET("/:username/chatrooms", func(c *gin.Context) {
 			username := c.Param("username") // <-- string, no conversion
@@ -132,6 +138,10 @@ func TestGetChatRoomByUser(t *testing.T) {
 		On("GetUserSubscribedChatRooms", "DaveGrohl").
 		Once().
 		Return([]model.ChatRoom{model.ChatRoom{}}, nil)
+	mockService.
+		On("GetUnreadCounts", "DaveGrohl").
+		Once().
+		Return(map[uint]int64{}, nil)
 
 	req = httptest.NewRequest(http.MethodGet, "/api/memberships/DaveGrohl/chatrooms", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -140,15 +150,24 @@ func TestGetChatRoomByUser(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	require.Equal(t, http.StatusOK, w.Code)
-	//mockService.AssertExpectations(t)
+
+	//someone else's rooms
+	req = httptest.NewRequest(http.MethodGet, "/api/memberships/KurtCobain/chatrooms", nil)
+	req.Header.Set("Authorization", "Bearer correct")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertNotCalled(t, "GetUserSubscribedChatRooms", "KurtCobain")
+	mockService.AssertNotCalled(t, "GetUnreadCounts", "KurtCobain")
 }
 
 type MockMembershipService struct {
 	mock.Mock
 }
 
-func (m *MockMembershipService) AddUserToChatRoom(username string, chatRoomID uint) error {
-	args := m.Called(username, chatRoomID)
+func (m *MockMembershipService) AddUserToChatRoom(actorID uint, username string, chatRoomID uint) error {
+	args := m.Called(actorID, username, chatRoomID)
 	return args.Error(0)
 }
 
@@ -161,3 +180,56 @@ func (m *MockMembershipService) GetUserChatRoomsFromDB(username string) ([]model
 	args := m.Called(username)
 	return args.Get(0).([]model.ChatRoom), args.Error(1)
 }
+
+func (m *MockMembershipService) GetUnreadCounts(username string) (map[uint]int64, error) {
+	args := m.Called(username)
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
+func (m *MockMembershipService) MarkRead(userID, chatRoomID, messageID uint) (uint, error) {
+	args := m.Called(userID, chatRoomID, messageID)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockMembershipService) SetMemberRole(actorID, chatRoomID, targetUserID uint, role string) error {
+	args := m.Called(actorID, chatRoomID, targetUserID, role)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) LeaveChatRoom(userID, chatRoomID uint) error {
+	args := m.Called(userID, chatRoomID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) KickMember(actorID, chatRoomID, targetUserID uint) error {
+	args := m.Called(actorID, chatRoomID, targetUserID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) BanMember(actorID, chatRoomID, targetUserID uint) error {
+	args := m.Called(actorID, chatRoomID, targetUserID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) CreateInvite(actorID, chatRoomID uint, ttl time.Duration, maxUses int) (*model.RoomInvite, error) {
+	args := m.Called(actorID, chatRoomID, ttl, maxUses)
+	invite, _ := args.Get(0).(*model.RoomInvite)
+	return invite, args.Error(1)
+}
+
+func (m *MockMembershipService) PreviewInvite(token string) (*model.InvitePreview, error) {
+	args := m.Called(token)
+	preview, _ := args.Get(0).(*model.InvitePreview)
+	return preview, args.Error(1)
+}
+
+func (m *MockMembershipService) AcceptInvite(userID uint, token string) (*model.ChatRoom, error) {
+	args := m.Called(userID, token)
+	room, _ := args.Get(0).(*model.ChatRoom)
+	return room, args.Error(1)
+}
+
+func (m *MockMembershipService) RevokeInvite(actorID uint, token string) error {
+	args := m.Called(actorID, token)
+	return args.Error(0)
+}
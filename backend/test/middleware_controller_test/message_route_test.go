@@ -103,7 +103,7 @@ func TestGetMessageByChatRoom(t *testing.T) {
 
 	//fail
 	mockService.
-		On("GetMessagesByChatRoom", uint(1)).
+		On("GetMessagesByChatRoom", uint(1), uint(1)).
 		Once().
 		Return([]model.Message{}, errors.New("DB error"))
 
@@ -120,7 +120,7 @@ func TestGetMessageByChatRoom(t *testing.T) {
 	// msg := model.User{Content: "Schizophrenia is taking me home", UserID: uint(1), ChatRoomID: uint(1)}
 	// jsonBody, _ = json.Marshal(msg)
 	mockService.
-		On("GetMessagesByChatRoom", uint(1)).
+		On("GetMessagesByChatRoom", uint(1), uint(1)).
 		Once().
 		Return([]model.Message{model.Message{Content: "Where is my mind"}}, nil)
 
@@ -152,7 +152,7 @@ func TestMessageDelete(t *testing.T) {
 
 	//fail
 	mockService.
-		On("DeleteMessage", uint(1)).
+		On("DeleteMessage", uint(1), uint(1)).
 		Once().
 		Return(errors.New("DB error"))
 
@@ -169,7 +169,7 @@ func TestMessageDelete(t *testing.T) {
 	// msg := model.User{Content: "Schizophrenia is taking me home", UserID: uint(1), ChatRoomID: uint(1)}
 	// jsonBody, _ = json.Marshal(msg)
 	mockService.
-		On("DeleteMessage", uint(1)).
+		On("DeleteMessage", uint(1), uint(1)).
 		Once().
 		Return(nil)
 
@@ -195,13 +195,13 @@ func (m *MockMessageService) CreateMessage(userID, roomID uint, content string)
 	return nil, args.Error(1)
 }
 
-func (m *MockMessageService) GetMessagesByChatRoom(chatRoomID uint) ([]model.Message, error) {
-	args := m.Called(chatRoomID)
+func (m *MockMessageService) GetMessagesByChatRoom(userID, chatRoomID uint) ([]model.Message, error) {
+	args := m.Called(userID, chatRoomID)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
-func (m *MockMessageService) DeleteMessage(id uint) error {
-	args := m.Called(id)
+func (m *MockMessageService) DeleteMessage(id, userID uint) error {
+	args := m.Called(id, userID)
 	return args.Error(0)
 }
 
@@ -210,7 +210,33 @@ func (m *MockMessageService) GetMessagesWithLimit(roomID uint, limit int) ([]mod
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
-func (m *MockMessageService) GetMessagesPage(roomID uint, beforeID uint, limit int) ([]model.Message, error) {
-	args := m.Called(roomID, beforeID, limit)
+func (m *MockMessageService) GetMessagesPage(userID, roomID uint, beforeID uint, limit int) ([]model.Message, error) {
+	args := m.Called(userID, roomID, beforeID, limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func (m *MockMessageService) EditMessage(id, userID uint, content string) (*model.Message, error) {
+	args := m.Called(id, userID, content)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetMessageRevisions(userID, id uint) ([]model.MessageRevision, error) {
+	args := m.Called(userID, id)
+	return args.Get(0).([]model.MessageRevision), args.Error(1)
+}
+
+func (m *MockMessageService) CreateReply(userID, roomID, parentID uint, content string) (*model.Message, error) {
+	args := m.Called(userID, roomID, parentID, content)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*model.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetThreadPage(userID, parentID uint, beforeID uint, limit int) ([]model.Message, error) {
+	args := m.Called(userID, parentID, beforeID, limit)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
@@ -32,7 +32,7 @@ func setupAuthMiddleware(t *testing.T) gin.HandlerFunc {
 	mockService := new(MockAuthService)
 	mockService.
 		On("ValidateJWT", "correct").
-		Return(new(model.User), new(model.UserSession), nil).
+		Return(&model.User{ID: 1}, new(model.UserSession), nil).
 		Twice()
 	return jwtauth.NewAuthMiddleware(mockService).Auth()
 }
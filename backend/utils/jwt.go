@@ -2,44 +2,70 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const devSharedJWTSecret = "dev-shared-jwt-secret"
-
-var secret = []byte(devSharedJWTSecret)
+// signingAlgs are the algorithms ParseJWT accepts; SigningKey only supports these.
+var signingAlgs = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
 
 type Claims struct {
-	Username  string `json:"Username"`
-	SessionID string
+	Username       string `json:"Username"`
+	SessionID      string
+	SessionVersion int
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(username string, sessionID string) (token string, jti string, exp time.Time, err error) {
-	exp = time.Now().Add(1 * time.Hour)
+// AccessTokenLifetime is how long access tokens issued by GenerateJWT stay valid.
+const AccessTokenLifetime = time.Hour
+
+// GenerateJWT issues a 1-hour access token for a session. sessionVersion ties the token to the
+// session's current refresh generation. The subject is the user id, which the connection gateway
+// binds websocket events to.
+func GenerateJWT(userID uint, username string, sessionID string, sessionVersion int) (token string, jti string, exp time.Time, err error) {
+	exp = time.Now().Add(AccessTokenLifetime)
 	jti = generateJTI()
 
 	claims := Claims{
-		Username:  username,
-		SessionID: sessionID,
+		Username:       username,
+		SessionID:      sessionID,
+		SessionVersion: sessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
 			ExpiresAt: jwt.NewNumericDate(exp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ID:        jti,
 		},
 	}
 
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err = tokenObj.SignedString(secret)
+	key, err := CurrentKeySet().Current(time.Now())
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	tokenObj := jwt.NewWithClaims(key.Method, claims)
+	tokenObj.Header["kid"] = key.ID
+	token, err = tokenObj.SignedString(key.Signer)
 	return
 }
 
 func ParseJWT(tokenStr string) (*Claims, error) {
+	ks := CurrentKeySet()
 	tok, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+		kid, _ := t.Header["kid"].(string)
+		key, ok := ks.Lookup(kid, time.Now())
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if key.Method.Alg() != t.Method.Alg() {
+			return nil, fmt.Errorf("signing key %q does not use %s", kid, t.Method.Alg())
+		}
+		return key.Signer.Public(), nil
+	}, jwt.WithValidMethods(signingAlgs))
 	if err != nil {
 		return nil, err
 	}
@@ -48,5 +74,10 @@ func ParseJWT(tokenStr string) (*Claims, error) {
 }
 
 func generateJTI() string {
-	return jwt.NewNumericDate(time.Now()).String()
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken
+		panic(err)
+	}
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is a private key access tokens are signed with, identified by the kid header.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Signer crypto.Signer
+	// ActivateAt is when the key starts signing new tokens; zero means immediately.
+	ActivateAt time.Time
+	// RetireAt is when tokens signed with the key stop validating; zero means never.
+	RetireAt time.Time
+}
+
+func (k *SigningKey) active(now time.Time) bool {
+	return !now.Before(k.ActivateAt) && !k.retired(now)
+}
+
+func (k *SigningKey) retired(now time.Time) bool {
+	return !k.RetireAt.IsZero() && !now.Before(k.RetireAt)
+}
+
+// KeySet holds the signing keys in rotation. The newest active key signs; every key that isn't
+// retired verifies and is published, including scheduled ones so verifiers learn them early.
+type KeySet struct {
+	keys []SigningKey
+}
+
+func NewKeySet(keys ...SigningKey) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("signing key id is required")
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate signing key id %q", k.ID)
+		}
+		seen[k.ID] = true
+		if k.Signer == nil || k.Method == nil {
+			return nil, fmt.Errorf("signing key %q has no private key", k.ID)
+		}
+	}
+	sorted := append([]SigningKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivateAt.After(sorted[j].ActivateAt)
+	})
+	return &KeySet{keys: sorted}, nil
+}
+
+// Current returns the key that signs tokens issued at now.
+func (s *KeySet) Current(now time.Time) (*SigningKey, error) {
+	for i := range s.keys {
+		if s.keys[i].active(now) {
+			return &s.keys[i], nil
+		}
+	}
+	return nil, errors.New("no active signing key")
+}
+
+// Lookup returns the key with id if tokens signed by it still validate at now.
+func (s *KeySet) Lookup(id string, now time.Time) (*SigningKey, bool) {
+	for i := range s.keys {
+		if s.keys[i].ID == id && !s.keys[i].retired(now) {
+			return &s.keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// JWK is the public half of a signing key as published in a JWKS.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every key that isn't retired at now.
+func (s *KeySet) JWKS(now time.Time) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		if k.retired(now) {
+			continue
+		}
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch pub := k.Signer.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// ParseSigningKey reads a PEM private key for alg, which must be RS256 or EdDSA.
+func ParseSigningKey(id, alg string, pemBytes []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q: no PEM block found", id)
+	}
+
+	var parsed any
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", id, err)
+	}
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		key, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %q: RS256 needs an RSA key", id)
+		}
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Signer: key}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %q: EdDSA needs an Ed25519 key", id)
+		}
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Signer: key}, nil
+	default:
+		return nil, fmt.Errorf("signing key %q: unsupported alg %q", id, alg)
+	}
+}
+
+// NewEphemeralKeySet returns a key set with a fresh Ed25519 key. Tokens signed with it stop
+// validating on restart, so it is only meant for development and tests.
+func NewEphemeralKeySet() (*KeySet, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, err
+	}
+	return NewKeySet(SigningKey{
+		ID:     "ephemeral-" + base64.RawURLEncoding.EncodeToString(kid),
+		Method: jwt.SigningMethodEdDSA,
+		Signer: priv,
+	})
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   *KeySet
+)
+
+// SetKeySet replaces the keys GenerateJWT and ParseJWT use.
+func SetKeySet(ks *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys = ks
+}
+
+// CurrentKeySet returns the keys in use, creating an ephemeral set if none was configured.
+func CurrentKeySet() *KeySet {
+	keysMu.RLock()
+	ks := keys
+	keysMu.RUnlock()
+	if ks != nil {
+		return ks
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if keys == nil {
+		ephemeral, err := NewEphemeralKeySet()
+		if err != nil {
+			panic(err)
+		}
+		keys = ephemeral
+	}
+	return keys
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T, id string, activateAt, retireAt time.Time) SigningKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Signer: priv, ActivateAt: activateAt, RetireAt: retireAt}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	now := time.Now()
+	old := newTestKey(t, "old", now.Add(-48*time.Hour), now.Add(time.Hour))
+	current := newTestKey(t, "current", now.Add(-time.Hour), time.Time{})
+	next := newTestKey(t, "next", now.Add(24*time.Hour), time.Time{})
+	retired := newTestKey(t, "retired", now.Add(-72*time.Hour), now.Add(-time.Hour))
+
+	ks, err := NewKeySet(old, current, next, retired)
+	require.NoError(t, err)
+
+	key, err := ks.Current(now)
+	require.NoError(t, err)
+	require.Equal(t, "current", key.ID)
+	key, err = ks.Current(now.Add(25 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "next", key.ID)
+
+	_, ok := ks.Lookup("old", now)
+	require.True(t, ok)
+	_, ok = ks.Lookup("retired", now)
+	require.False(t, ok)
+
+	var kids []string
+	for _, jwk := range ks.JWKS(now).Keys {
+		kids = append(kids, jwk.Kid)
+		require.Equal(t, "OKP", jwk.Kty)
+		require.NotEmpty(t, jwk.X)
+	}
+	require.ElementsMatch(t, []string{"old", "current", "next"}, kids)
+
+	_, err = NewKeySet(current, current)
+	require.Error(t, err)
+}
+
+func TestGenerateJWT_SignsWithCurrentKid(t *testing.T) {
+	prev := CurrentKeySet()
+	defer SetKeySet(prev)
+
+	now := time.Now()
+	old := newTestKey(t, "old", now.Add(-48*time.Hour), time.Time{})
+	ks, err := NewKeySet(old)
+	require.NoError(t, err)
+	SetKeySet(ks)
+
+	oldToken, _, _, err := GenerateJWT(1, "alice", "sess", 1)
+	require.NoError(t, err)
+
+	// rotate: the new key signs, tokens from the old key keep validating until it retires
+	ks, err = NewKeySet(old, newTestKey(t, "new", now.Add(-time.Minute), time.Time{}))
+	require.NoError(t, err)
+	SetKeySet(ks)
+
+	newToken, _, _, err := GenerateJWT(1, "alice", "sess", 1)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, "new", parsed.Header["kid"])
+
+	_, err = ParseJWT(oldToken)
+	require.NoError(t, err)
+
+	old.RetireAt = now.Add(-time.Second)
+	ks, err = NewKeySet(old, newTestKey(t, "new", now.Add(-time.Minute), time.Time{}))
+	require.NoError(t, err)
+	SetKeySet(ks)
+	_, err = ParseJWT(oldToken)
+	require.Error(t, err)
+}
+
+func TestParseSigningKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	key, err := ParseSigningKey("rsa-1", "RS256", rsaPEM)
+	require.NoError(t, err)
+	require.Equal(t, jwt.SigningMethodRS256, key.Method)
+
+	ks, err := NewKeySet(*key)
+	require.NoError(t, err)
+	jwks := ks.JWKS(time.Now())
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "RSA", jwks.Keys[0].Kty)
+	require.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	_, err = ParseSigningKey("rsa-1", "EdDSA", rsaPEM)
+	require.Error(t, err)
+	_, err = ParseSigningKey("rsa-1", "HS256", rsaPEM)
+	require.Error(t, err)
+}
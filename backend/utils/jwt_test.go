@@ -12,7 +12,7 @@ func TestGenerateAndParseJWT(t *testing.T) {
 	username := "alice"
 	sessionID := "sess123"
 
-	token, jti, exp, err := GenerateJWT(username, sessionID)
+	token, jti, exp, err := GenerateJWT(7, username, sessionID, 1)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, jti)
@@ -23,7 +23,9 @@ func TestGenerateAndParseJWT(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, username, claims.Username)
 	require.Equal(t, sessionID, claims.SessionID)
+	require.Equal(t, 1, claims.SessionVersion)
 	require.Equal(t, jti, claims.ID)
+	require.Equal(t, "7", claims.Subject)
 	require.WithinDuration(t, exp, claims.ExpiresAt.Time, 5*time.Second)
 }
 
@@ -44,11 +46,28 @@ func TestParseJWT_ExpiredToken(t *testing.T) {
 			ID:        "jti123",
 		},
 	}
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := tokenObj.SignedString(secret)
+	key, err := CurrentKeySet().Current(time.Now())
+	require.NoError(t, err)
+	tokenObj := jwt.NewWithClaims(key.Method, claims)
+	tokenObj.Header["kid"] = key.ID
+	token, err := tokenObj.SignedString(key.Signer)
 	require.NoError(t, err)
 
 	_, err = ParseJWT(token)
 	require.Error(t, err)
 	//require.True(t, parsedClaims.ExpiresAt.Time.Before(time.Now()))
 }
+
+func TestParseJWT_RejectsSharedSecretToken(t *testing.T) {
+	claims := Claims{
+		Username: "mallory",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("dev-shared-jwt-secret"))
+	require.NoError(t, err)
+
+	_, err = ParseJWT(token)
+	require.Error(t, err)
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -22,15 +23,31 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-const devSharedJWTSecret = "dev-shared-jwt-secret"
-
 var nextWSClientID atomic.Uint32
 
+// ConnectionJWTClaims are the backend access token claims; the subject is the user id.
 type ConnectionJWTClaims struct {
-	UserID string `json:"user_id"`
+	SessionID string
 	jwt.RegisteredClaims
 }
 
+// revokedSessionPrefix marks revoked sessions among the revoked JTIs. It must match
+// RevokedSessionKeyPrefix in the backend.
+const revokedSessionPrefix = "session:"
+
+func (c *ConnectionJWTClaims) userID() (uint32, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("jwt subject %q is not a user id", c.Subject)
+	}
+	return uint32(id), nil
+}
+
+// RevocationChecker reports whether a token id was revoked.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
 type FanoutRegistry interface {
 	AddRoomUser(ctx context.Context, roomID, userID uint32) error
 	RemoveRoomUser(ctx context.Context, roomID, userID uint32) error
@@ -176,9 +193,36 @@ func setupHandlerChain(
 		}
 	}
 	// TODO: add logging middleware, etc.
-	// TODO: add jwt auth middleware after deciced jwt claims structure and how to pass jwt from client (e.g., via query param or subprotocol)
 	finalSinkHandler := handler.SinkHandler(messageEventSinkWriter(hub, multiSink))
-	return handler.NewHandlerChain(finalSinkHandler, rateLimitMiddleware, groupAssignmentMiddleware).Build()
+	return handler.NewHandlerChain(finalSinkHandler, rateLimitMiddleware, jwtMiddleware, bindUserMiddleware(hub), groupAssignmentMiddleware).Build()
+}
+
+// bindUserMiddleware replaces the user id a client puts in an event with the subject of its
+// verified token, so nobody can act as another user. The token's session is recorded on the
+// client, so revoking that session closes the websocket.
+func bindUserMiddleware(hub *gateway.Hub[*kafkapb.KafkaEvent]) handler.Middleware {
+	return func(next handler.HandlerFunc) handler.HandlerFunc {
+		return func(c *handler.Context) error {
+			claims, ok := c.Values[handler.JWTClaimsContextKey].(*ConnectionJWTClaims)
+			if !ok {
+				return fmt.Errorf("missing jwt claims")
+			}
+			userID, err := claims.userID()
+			if err != nil {
+				return err
+			}
+			inbound, ok := c.Event.(gateway.InboundEvent[*kafkapb.KafkaEvent])
+			if !ok {
+				return fmt.Errorf("unexpected event type: %T", c.Event)
+			}
+			if inbound.Event == nil {
+				return fmt.Errorf("empty inbound event")
+			}
+			inbound.Event.UserId = userID
+			hub.SetClientSessionID(inbound.ClientID, claims.SessionID)
+			return next(c)
+		}
+	}
 }
 
 func main() {
@@ -231,11 +275,24 @@ func main() {
 	}
 	startPresenceRefresher(reg, hub, cfg.Fanout.AdvertiseAddr, cfg.Redis.PresenceRefresh)
 
-	jwtMiddleware := newJWTMiddleware()
+	revocations := registry.NewRedisRevocationList(redisClient, cfg.Redis.RevokedJTIPrefix)
+	jwks := middlewares.NewJWKS(middlewares.JWKSOptions{
+		URL:             cfg.JWT.JWKSURL,
+		RefreshInterval: cfg.JWT.JWKSRefresh,
+	})
+	jwks.Start(context.Background())
+	jwtMiddleware := newJWTMiddleware(jwks.Keyfunc(), revocations)
 	inboundHandler := setupHandlerChain(hub, multiSink, jwtMiddleware, reg, cfg.Fanout.AdvertiseAddr)
 
 	mux := newMux(hub, inboundHandler)
 	fanoutSource := source.NewFanoutHTTPHandler(hub, cfg.Fanout.Address)
+	if reg != nil {
+		fanoutSource.SetMembershipRemovedHandler(func(roomID uint32, userID uint32) {
+			if err := reg.RemoveRoomUser(context.Background(), roomID, userID); err != nil {
+				log.Printf("failed to remove room user: %v", err)
+			}
+		})
+	}
 	if err := fanoutSource.Start(context.Background()); err != nil {
 		log.Fatalf("failed to start fanout http source: %v", err)
 	}
@@ -269,9 +326,11 @@ func mustLoadConfig(path string) *app.Config {
 }
 
 func newHub(eventCodec codec.EventCodec[*kafkapb.KafkaEvent]) *gateway.Hub[*kafkapb.KafkaEvent] {
+	// Edits and reactions are not forwarded: they go through the REST API, which checks who makes them.
 	eventRouter := gateway.EventRouter[*kafkapb.KafkaEvent]{
-		MsgType: func(e *kafkapb.KafkaEvent) string { return e.MsgType },
-		GroupID: func(e *kafkapb.KafkaEvent) uint32 { return e.RoomId },
+		MsgType:   func(e *kafkapb.KafkaEvent) string { return e.MsgType },
+		GroupID:   func(e *kafkapb.KafkaEvent) uint32 { return e.RoomId },
+		SessionID: func(e *kafkapb.KafkaEvent) string { return string(e.Content) },
 	}
 	return gateway.NewHub(gateway.NewMemoryStore(), eventCodec, eventRouter)
 }
@@ -291,16 +350,24 @@ func mustSetupMultiSink(
 	}
 }
 
-func newJWTMiddleware() handler.Middleware {
+func newJWTMiddleware(keyfunc jwt.Keyfunc, revocations RevocationChecker) handler.Middleware {
 	return middlewares.JWTAuthMiddleware[*ConnectionJWTClaims](middlewares.JWTAuthOptions[*ConnectionJWTClaims]{
 		NewClaims: func() *ConnectionJWTClaims {
 			return &ConnectionJWTClaims{}
 		},
-		Keyfunc: middlewares.KeyfuncByAlgorithm(map[string]any{
-			jwt.SigningMethodHS256.Alg(): []byte(devSharedJWTSecret),
-			jwt.SigningMethodHS384.Alg(): []byte(devSharedJWTSecret),
-			jwt.SigningMethodHS512.Alg(): []byte(devSharedJWTSecret),
-		}),
+		Keyfunc: keyfunc,
+		AllowedAlgorithms: []string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		},
+		IsRevoked: func(ctx context.Context, claims *ConnectionJWTClaims) (bool, error) {
+			revoked, err := revocations.IsRevoked(ctx, claims.ID)
+			if err != nil || revoked || claims.SessionID == "" {
+				return revoked, err
+			}
+			// logging a device out revokes its session rather than each token it was issued
+			return revocations.IsRevoked(ctx, revokedSessionPrefix+claims.SessionID)
+		},
 	})
 }
 
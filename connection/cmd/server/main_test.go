@@ -0,0 +1,164 @@
+package main
+
+import (
+	"connection/internal/event/codec"
+	"connection/internal/gateway"
+	"connection/internal/handler"
+	kafkapb "connection/proto/kafka"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type recordingSink struct {
+	events []*kafkapb.KafkaEvent
+}
+
+func (s *recordingSink) Write(_ context.Context, event *kafkapb.KafkaEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+type revokedJTIs map[string]bool
+
+func (r revokedJTIs) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return r[jti], nil
+}
+
+func TestHandlerChain_AuthenticatesInboundEvents(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signSession := func(subject, jti, sessionID string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, ConnectionJWTClaims{
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   subject,
+				ID:        jti,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}).SignedString(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	sign := func(subject, jti string) string {
+		return signSession(subject, jti, "laptop")
+	}
+
+	hub := newHub(codec.NewJSONEventCodec[*kafkapb.KafkaEvent]())
+	sink := &recordingSink{}
+	keyfunc := func(*jwt.Token) (any, error) { return pub, nil }
+	chain := setupHandlerChain(hub, sink, newJWTMiddleware(keyfunc, revokedJTIs{"logged-out": true, "session:stolen-phone": true}), nil, "gateway:9000")
+
+	send := func(authorization string, userID uint32, content string) error {
+		req, _ := http.NewRequest(http.MethodGet, "/ws", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", "Bearer "+authorization)
+		}
+		return chain(&handler.Context{
+			Context:  context.Background(),
+			ClientID: 1,
+			Event: gateway.InboundEvent[*kafkapb.KafkaEvent]{
+				ClientID: 1,
+				Event:    &kafkapb.KafkaEvent{MsgType: "message", RoomId: 3, UserId: userID, Content: []byte(content)},
+			},
+			Values: map[string]any{handler.RequestContextKey: req},
+		})
+	}
+
+	// the user id a client claims is replaced with the token subject
+	if err := send(sign("7", "active"), 99, "hi"); err != nil {
+		t.Fatalf("valid token refused: %v", err)
+	}
+	if len(sink.events) != 1 || sink.events[0].UserId != 7 {
+		t.Fatalf("expected one event from user 7, got %+v", sink.events)
+	}
+
+	// slash commands are run by the backend as the event's user, so they must not carry a
+	// claimed actor either: a member cannot run /topic as the room owner
+	if err := send(sign("8", "active"), 1, "/topic taken over"); err != nil {
+		t.Fatalf("valid token refused: %v", err)
+	}
+	if len(sink.events) != 2 || sink.events[1].UserId != 8 {
+		t.Fatalf("expected the command to run as user 8, got %+v", sink.events)
+	}
+
+	for name, token := range map[string]string{
+		"revoked token":   sign("7", "logged-out"),
+		"revoked session": signSession("7", "active", "stolen-phone"),
+		"missing token":   "",
+		"non-numeric sub": sign("alice", "active"),
+	} {
+		if err := send(token, 7, "hi"); err == nil {
+			t.Fatalf("%s: expected the event to be refused", name)
+		}
+	}
+	if len(sink.events) != 2 {
+		t.Fatalf("refused events reached the sink: %+v", sink.events)
+	}
+}
+
+func TestHandlerChain_BindsTokenSessionToClient(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := newHub(codec.NewJSONEventCodec[*kafkapb.KafkaEvent]())
+	keyfunc := func(*jwt.Token) (any, error) { return pub, nil }
+	chain := setupHandlerChain(hub, &recordingSink{}, newJWTMiddleware(keyfunc, revokedJTIs{}), nil, "gateway:9000")
+
+	laptop := &gateway.Client{ID: 1, SendChan: make(chan []byte, 1)}
+	phone := &gateway.Client{ID: 2, SendChan: make(chan []byte, 1)}
+	for _, client := range []*gateway.Client{laptop, phone} {
+		hub.AddClient(client)
+		hub.SetClientUserID(client.ID, 7)
+	}
+
+	for clientID, sessionID := range map[uint32]string{1: "laptop", 2: "phone"} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, ConnectionJWTClaims{
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "7",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}).SignedString(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the session a client asks for in the URL is ignored; only the token counts
+		req, _ := http.NewRequest(http.MethodGet, "/ws?session_id=phone", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		err = chain(&handler.Context{
+			Context:  context.Background(),
+			ClientID: clientID,
+			Event: gateway.InboundEvent[*kafkapb.KafkaEvent]{
+				ClientID: clientID,
+				Event:    &kafkapb.KafkaEvent{MsgType: "join", RoomId: 3},
+			},
+			Values: map[string]any{handler.RequestContextKey: req},
+		})
+		if err != nil {
+			t.Fatalf("valid token refused: %v", err)
+		}
+	}
+
+	hub.CloseUserSession(7, "laptop")
+	if _, open := <-laptop.SendChan; open {
+		t.Fatal("expected the revoked session's websocket to be closed")
+	}
+	select {
+	case _, open := <-phone.SendChan:
+		if !open {
+			t.Fatal("the other session's websocket was closed too")
+		}
+	default:
+	}
+}
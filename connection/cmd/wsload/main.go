@@ -38,6 +38,7 @@ var eventSeq atomic.Uint64
 func main() {
 	var (
 		wsURL        string
+		token        string
 		users        int
 		roomID       uint
 		messagesEach int
@@ -47,6 +48,7 @@ func main() {
 	)
 
 	flag.StringVar(&wsURL, "url", "ws://localhost:8000/ws", "WebSocket endpoint")
+	flag.StringVar(&token, "token", "", "access token every simulated client connects with; the gateway refuses events without one")
 	flag.IntVar(&users, "users", 200, "number of concurrent users")
 	flag.UintVar(&roomID, "room", 1, "chat room id")
 	flag.IntVar(&messagesEach, "messages", 3, "messages sent per user")
@@ -73,7 +75,7 @@ func main() {
 	start := time.Now()
 	st := &stats{}
 
-	clients := connectClients(ctx, wsURL, users, st)
+	clients := connectClients(ctx, wsURL, token, users, st)
 	if len(clients) == 0 {
 		log.Fatal("no clients connected")
 	}
@@ -132,7 +134,7 @@ func main() {
 	log.Printf("events_received=%d recv_decode_fail=%d", st.received.Load(), st.recvDecode.Load())
 }
 
-func connectClients(ctx context.Context, wsURL string, users int, st *stats) []simClient {
+func connectClients(ctx context.Context, wsURL, token string, users int, st *stats) []simClient {
 	clients := make([]simClient, 0, users)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -151,6 +153,9 @@ func connectClients(ctx context.Context, wsURL string, users int, st *stats) []s
 			}
 
 			header := http.Header{}
+			if token != "" {
+				header.Set("Authorization", "Bearer "+token)
+			}
 			conn, _, err := dialer.DialContext(ctx, wsURL, header)
 			if err != nil {
 				st.connectFail.Add(1)
@@ -34,7 +34,14 @@ type Config struct {
 		RoomUsersTTL      time.Duration `yaml:"room_users_ttl"`
 		UserGatewayTTL    time.Duration `yaml:"user_gateway_ttl"`
 		PresenceRefresh   time.Duration `yaml:"presence_refresh_interval"`
+		// RevokedJTIPrefix must match the prefix the backend stores revoked tokens under.
+		RevokedJTIPrefix string `yaml:"revoked_jti_prefix"`
 	} `yaml:"redis"`
+	JWT struct {
+		// JWKSURL is where the backend publishes its token verification keys.
+		JWKSURL     string        `yaml:"jwks_url"`
+		JWKSRefresh time.Duration `yaml:"jwks_refresh_interval"`
+	} `yaml:"jwt"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -97,4 +104,13 @@ func (c *Config) withDefaults() {
 	if c.Redis.PresenceRefresh == 0 {
 		c.Redis.PresenceRefresh = 30 * time.Second
 	}
+	if c.Redis.RevokedJTIPrefix == "" {
+		c.Redis.RevokedJTIPrefix = "jwt:revoked:"
+	}
+	if c.JWT.JWKSURL == "" {
+		c.JWT.JWKSURL = "http://app:8080/.well-known/jwks.json"
+	}
+	if c.JWT.JWKSRefresh == 0 {
+		c.JWT.JWKSRefresh = 5 * time.Minute
+	}
 }
@@ -13,8 +13,11 @@ type ConnectionStore interface {
 	GetClient(clientID uint32) *Client
 	SetClientUserID(clientID uint32, userID uint32)
 	GetClientUserID(clientID uint32) uint32
+	SetClientSessionID(clientID uint32, sessionID string)
+	GetClientSessionID(clientID uint32) string
 	GroupsForClient(clientID uint32) []uint32
 	GetClientsInGroup(groupID uint32) []*Client
+	GetClientsForUser(userID uint32) []*Client
 	GetAllClients() []*Client
 }
 
@@ -70,6 +73,23 @@ func (s *MemoryStore) GetClientUserID(clientID uint32) uint32 {
 	return 0
 }
 
+func (s *MemoryStore) SetClientSessionID(clientID uint32, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.clients[clientID]; ok && c != nil {
+		c.SessionID = sessionID
+	}
+}
+
+func (s *MemoryStore) GetClientSessionID(clientID uint32) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c, ok := s.clients[clientID]; ok && c != nil {
+		return c.SessionID
+	}
+	return ""
+}
+
 func (s *MemoryStore) GroupsForClient(clientID uint32) []uint32 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -119,6 +139,19 @@ func (s *MemoryStore) GetClientsInGroup(groupID uint32) []*Client {
 	return res
 }
 
+func (s *MemoryStore) GetClientsForUser(userID uint32) []*Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var res []*Client
+	for _, c := range s.clients {
+		if c != nil && c.UserID == userID {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
 func (s *MemoryStore) GetAllClients() []*Client {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
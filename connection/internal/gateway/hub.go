@@ -10,8 +10,10 @@ import (
 )
 
 type Client struct {
-	ID        uint32
-	UserID    uint32
+	ID     uint32
+	UserID uint32
+	// SessionID is the backend login session of the token the websocket authenticates with.
+	SessionID string
 	Conn      *Connection
 	SendChan  chan []byte
 	wsMsgType int
@@ -39,7 +41,12 @@ type EventRouter[T any] struct {
 	GroupID     func(T) uint32
 	JoinType    string
 	LeaveType   string
+	KickedType  string
 	MessageType string
+	// SessionRevokedType closes the connections of a revoked login session.
+	SessionRevokedType string
+	// SessionID returns the revoked session of a SessionRevokedType event; empty means every session of its user.
+	SessionID func(T) string
 }
 
 func (r EventRouter[T]) withDefaults() EventRouter[T] {
@@ -49,9 +56,15 @@ func (r EventRouter[T]) withDefaults() EventRouter[T] {
 	if r.LeaveType == "" {
 		r.LeaveType = "leave"
 	}
+	if r.KickedType == "" {
+		r.KickedType = "kicked"
+	}
 	if r.MessageType == "" {
 		r.MessageType = "message"
 	}
+	if r.SessionRevokedType == "" {
+		r.SessionRevokedType = "session_revoked"
+	}
 	return r
 }
 
@@ -95,6 +108,29 @@ func (h *Hub[T]) RemoveClientFromGroup(clientID uint32, groupID uint32) {
 	h.store.RemoveClientFromGroup(clientID, groupID)
 }
 
+// RemoveUserFromGroup removes every connection of userID from groupID.
+func (h *Hub[T]) RemoveUserFromGroup(userID uint32, groupID uint32) {
+	if h == nil || userID == 0 {
+		return
+	}
+	for _, client := range h.store.GetClientsForUser(userID) {
+		h.store.RemoveClientFromGroup(client.ID, groupID)
+	}
+}
+
+// CloseUserSession closes the connections of userID opened for sessionID, or all of them when sessionID is empty.
+func (h *Hub[T]) CloseUserSession(userID uint32, sessionID string) {
+	if h == nil || userID == 0 {
+		return
+	}
+	for _, client := range h.store.GetClientsForUser(userID) {
+		if sessionID != "" && h.store.GetClientSessionID(client.ID) != sessionID {
+			continue
+		}
+		client.Close()
+	}
+}
+
 func (h *Hub[T]) AllClients() []*Client {
 	if h == nil {
 		return nil
@@ -116,6 +152,14 @@ func (h *Hub[T]) SetClientUserID(clientID uint32, userID uint32) {
 	h.store.SetClientUserID(clientID, userID)
 }
 
+// SetClientSessionID records the login session a client's verified token belongs to.
+func (h *Hub[T]) SetClientSessionID(clientID uint32, sessionID string) {
+	if h == nil || sessionID == "" {
+		return
+	}
+	h.store.SetClientSessionID(clientID, sessionID)
+}
+
 func (h *Hub[T]) RemoveClientAndGroups(clientID uint32) (uint32, []uint32) {
 	if h == nil {
 		return 0, nil
@@ -157,6 +201,18 @@ func (h *Hub[T]) SendToClients(clientIDs []uint32, msg []byte) {
 	}
 }
 
+// SendToUsers delivers msg to every connection bound to one of userIDs.
+func (h *Hub[T]) SendToUsers(userIDs []uint32, msg []byte) {
+	for _, userID := range userIDs {
+		if userID == 0 {
+			continue
+		}
+		for _, client := range h.store.GetClientsForUser(userID) {
+			h.sendToClient(client, msg, 0)
+		}
+	}
+}
+
 func (h *Hub[T]) sendToClient(client *Client, msg []byte, groupID uint32) {
 	if client == nil {
 		return
@@ -204,6 +260,24 @@ func (h *Hub[T]) IsLeave(event T) bool {
 	return h.MsgType(event) == h.event.LeaveType
 }
 
+// IsMembershipRemoval reports whether an outbound event removes its user from the group.
+func (h *Hub[T]) IsMembershipRemoval(event T) bool {
+	msgType := h.MsgType(event)
+	return msgType == h.event.LeaveType || msgType == h.event.KickedType
+}
+
+func (h *Hub[T]) IsSessionRevoked(event T) bool {
+	return h.MsgType(event) == h.event.SessionRevokedType
+}
+
+// RevokedSessionID returns the session a SessionRevokedType event is about.
+func (h *Hub[T]) RevokedSessionID(event T) string {
+	if h.event.SessionID == nil {
+		return ""
+	}
+	return h.event.SessionID(event)
+}
+
 func (h *Hub[T]) IsMessage(event T) bool {
 	return h.MsgType(event) == h.event.MessageType
 }
@@ -0,0 +1,238 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKSOptions configures a JWKS key cache.
+type JWKSOptions struct {
+	// URL is the JWKS endpoint published by the token issuer.
+	URL string
+	// RefreshInterval controls how often the key set is re-fetched in the background.
+	// When zero, 5 minutes is used.
+	RefreshInterval time.Duration
+	// MinRefreshInterval rate-limits re-fetches triggered by tokens with an unknown kid.
+	// When zero, 10 seconds is used.
+	MinRefreshInterval time.Duration
+	// Client is used for fetching. When nil, a client with a 5 second timeout is used.
+	Client *http.Client
+}
+
+type jwksKey struct {
+	alg string
+	key any
+}
+
+// JWKS caches the verification keys of a remote JWKS endpoint.
+type JWKS struct {
+	opts JWKSOptions
+
+	mu        sync.RWMutex
+	keys      map[string]jwksKey
+	lastFetch time.Time
+	fetchMu   sync.Mutex
+}
+
+// NewJWKS returns an empty key cache; call Refresh or Start to load keys.
+func NewJWKS(opts JWKSOptions) *JWKS {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = 5 * time.Minute
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &JWKS{opts: opts, keys: map[string]jwksKey{}}
+}
+
+// Start loads the key set and keeps refreshing it until ctx is done.
+// A failed initial fetch is logged; unknown kids trigger another attempt.
+func (j *JWKS) Start(ctx context.Context) {
+	if err := j.Refresh(ctx); err != nil {
+		log.Printf("initial jwks fetch failed: %v", err)
+	}
+
+	ticker := time.NewTicker(j.opts.RefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := j.Refresh(ctx); err != nil {
+					log.Printf("jwks refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Refresh fetches the key set and replaces the cached keys.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+	return j.fetch(ctx)
+}
+
+func (j *JWKS) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.opts.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.opts.Client.Do(req)
+
+	j.mu.Lock()
+	j.lastFetch = time.Now()
+	j.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks endpoint returned %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" {
+			continue
+		}
+		var (
+			key any
+			alg = k.Alg
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAJWK(k.N, k.E)
+			if alg == "" {
+				alg = jwt.SigningMethodRS256.Alg()
+			}
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				continue
+			}
+			key, err = parseEd25519JWK(k.X)
+			alg = jwt.SigningMethodEdDSA.Alg()
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("skipping jwk %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = jwksKey{alg: alg, key: key}
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+// refreshForUnknownKid re-fetches the key set unless it was fetched recently.
+func (j *JWKS) refreshForUnknownKid(ctx context.Context) {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+
+	j.mu.RLock()
+	recent := time.Since(j.lastFetch) < j.opts.MinRefreshInterval
+	j.mu.RUnlock()
+	if recent {
+		return
+	}
+	if err := j.fetch(ctx); err != nil {
+		log.Printf("jwks refresh for unknown kid failed: %v", err)
+	}
+}
+
+func (j *JWKS) lookup(kid string) (jwksKey, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// Keyfunc returns a keyfunc that resolves keys by the JWT "kid" header.
+func (j *JWKS) Keyfunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		if token == nil || token.Method == nil {
+			return nil, errors.New("token signing method is missing")
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token kid header is missing")
+		}
+
+		key, ok := j.lookup(kid)
+		if !ok {
+			j.refreshForUnknownKid(context.Background())
+			key, ok = j.lookup(kid)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if alg := token.Method.Alg(); alg != key.alg {
+			return nil, fmt.Errorf("signing key %q does not accept alg %q", kid, alg)
+		}
+		return key.key, nil
+	}
+}
+
+func parseRSAJWK(n, e string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("decode modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("decode exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(eBytes)
+	if len(nBytes) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, errors.New("invalid rsa key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nBytes), E: int(exponent.Int64())}, nil
+}
+
+func parseEd25519JWK(x string) (ed25519.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 key size")
+	}
+	return ed25519.PublicKey(raw), nil
+}
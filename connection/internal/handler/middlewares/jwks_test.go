@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testJWKSServer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches atomic.Int32
+}
+
+func (s *testJWKSServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *testJWKSServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func ed25519JWK(kid string, pub ed25519.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP", "kid": kid, "alg": "EdDSA", "crv": "Ed25519",
+		"x": base64.RawURLEncoding.EncodeToString(pub),
+	}
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func signWithKid(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestJWKS_KeyfuncVerifiesByKid(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	srv := &testJWKSServer{}
+	srv.setKeys(ed25519JWK("ed", edPub), rsaJWK("rsa", &rsaKey.PublicKey))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	jwks := NewJWKS(JWKSOptions{URL: ts.URL})
+	if err := jwks.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    any
+	}{
+		{"eddsa", jwt.SigningMethodEdDSA, "ed", edPriv},
+		{"rs256", jwt.SigningMethodRS256, "rsa", rsaKey},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			signed := signWithKid(t, tc.method, tc.kid, tc.key)
+			if _, err := jwt.Parse(signed, jwks.Keyfunc()); err != nil {
+				t.Fatalf("expected token to verify, got %v", err)
+			}
+		})
+	}
+
+	// A token claiming one key's kid under another algorithm must be rejected.
+	signed := signWithKid(t, jwt.SigningMethodRS256, "ed", rsaKey)
+	if _, err := jwt.Parse(signed, jwks.Keyfunc()); err == nil {
+		t.Fatal("expected alg mismatch to be rejected")
+	}
+}
+
+func TestJWKS_UnknownKidTriggersRateLimitedRefresh(t *testing.T) {
+	oldPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	newPub, newPriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	srv := &testJWKSServer{}
+	srv.setKeys(ed25519JWK("old", oldPub))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	jwks := NewJWKS(JWKSOptions{URL: ts.URL, MinRefreshInterval: time.Hour})
+	if err := jwks.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	// Pretend the last fetch happened long ago so the rotated key is picked up.
+	jwks.mu.Lock()
+	jwks.lastFetch = time.Time{}
+	jwks.mu.Unlock()
+
+	srv.setKeys(ed25519JWK("old", oldPub), ed25519JWK("new", newPub))
+	signed := signWithKid(t, jwt.SigningMethodEdDSA, "new", newPriv)
+	if _, err := jwt.Parse(signed, jwks.Keyfunc()); err != nil {
+		t.Fatalf("expected rotated key to verify after refresh, got %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Fatalf("expected 2 fetches, got %d", got)
+	}
+
+	// Further unknown kids within MinRefreshInterval must not hit the endpoint.
+	unknown := signWithKid(t, jwt.SigningMethodEdDSA, "missing", newPriv)
+	for i := 0; i < 3; i++ {
+		if _, err := jwt.Parse(unknown, jwks.Keyfunc()); err == nil {
+			t.Fatal("expected unknown kid to be rejected")
+		}
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Fatalf("expected refreshes to be rate limited, got %d fetches", got)
+	}
+}
@@ -36,6 +36,8 @@ type JWTAuthOptions[C jwt.Claims] struct {
 	// ClaimsContextKey sets where validated claims are stored.
 	// When empty, handler.JWTClaimsContextKey is used.
 	ClaimsContextKey string
+	// IsRevoked optionally rejects validated tokens that were revoked, e.g. by a backend logout.
+	IsRevoked func(ctx context.Context, claims C) (bool, error)
 }
 
 // KeyfuncByAlgorithm returns a keyfunc that resolves keys by JWT "alg".
@@ -95,6 +97,19 @@ func JWTAuthMiddleware[C jwt.Claims](opts JWTAuthOptions[C]) handler.Middleware
 			if !token.Valid {
 				return errors.New("jwt token is invalid")
 			}
+			if opts.IsRevoked != nil {
+				ctx := c.Context
+				if ctx == nil {
+					ctx = context.Background()
+				}
+				revoked, err := opts.IsRevoked(ctx, claims)
+				if err != nil {
+					return fmt.Errorf("jwt revocation check failed: %w", err)
+				}
+				if revoked {
+					return errors.New("jwt token is revoked")
+				}
+			}
 
 			if c.Values == nil {
 				c.Values = make(map[string]any)
@@ -126,8 +141,15 @@ func requestFromContext(c *handler.Context) (*http.Request, error) {
 	return req, nil
 }
 
+// bearerToken reads the token from the Authorization header, or from the access_token query
+// parameter since browsers cannot set headers on a websocket upgrade.
 func bearerToken(req *http.Request) (string, error) {
 	authHeader := req.Header.Get("Authorization")
+	if authHeader == "" {
+		if token := req.URL.Query().Get("access_token"); token != "" {
+			return token, nil
+		}
+	}
 	parts := strings.Fields(authHeader)
 	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
 		return "", errors.New("missing or invalid Authorization header")
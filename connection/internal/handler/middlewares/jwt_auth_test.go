@@ -99,6 +99,44 @@ func TestJWTAuthMiddleware_RejectsExpiredToken(t *testing.T) {
 	}
 }
 
+func TestJWTAuthMiddleware_RejectsRevokedToken(t *testing.T) {
+	secret := []byte("test-secret")
+	sign := func(jti string) string {
+		return mustSignToken(t, jwt.SigningMethodHS256, secret, &testClaims{
+			Username: "carol",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+				ID:        jti,
+			},
+		})
+	}
+
+	mw := JWTAuthMiddleware[*testClaims](JWTAuthOptions[*testClaims]{
+		NewClaims: func() *testClaims { return &testClaims{} },
+		Keyfunc: KeyfuncByAlgorithm(map[string]any{
+			jwt.SigningMethodHS256.Alg(): secret,
+		}),
+		IsRevoked: func(_ context.Context, claims *testClaims) (bool, error) {
+			return claims.ID == "logged-out", nil
+		},
+	})
+
+	for jti, wantErr := range map[string]bool{"logged-out": true, "active": false} {
+		req, _ := http.NewRequest(http.MethodGet, "/ws", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(jti))
+		ctx := &handler.Context{
+			Values: map[string]any{
+				handler.RequestContextKey: req,
+			},
+		}
+
+		err := mw(func(_ *handler.Context) error { return nil })(ctx)
+		if (err != nil) != wantErr {
+			t.Fatalf("jti %q: got err %v, want error %v", jti, err, wantErr)
+		}
+	}
+}
+
 func TestJWTAuthMiddleware_RejectsMissingAuthorizationHeader(t *testing.T) {
 	secret := []byte("test-secret")
 	mw := JWTAuthMiddleware[*testClaims](JWTAuthOptions[*testClaims]{
@@ -165,3 +203,30 @@ func mustSignToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.C
 	}
 	return signed
 }
+
+func TestJWTAuthMiddleware_AcceptsAccessTokenQueryParameter(t *testing.T) {
+	secret := []byte("test-secret")
+	token := mustSignToken(t, jwt.SigningMethodHS256, secret, &testClaims{
+		Username: "dave",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+		},
+	})
+	mw := JWTAuthMiddleware[*testClaims](JWTAuthOptions[*testClaims]{
+		NewClaims: func() *testClaims { return &testClaims{} },
+		Keyfunc: KeyfuncByAlgorithm(map[string]any{
+			jwt.SigningMethodHS256.Alg(): secret,
+		}),
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/ws?access_token="+token, nil)
+	ctx := &handler.Context{
+		Values: map[string]any{
+			handler.RequestContextKey: req,
+		},
+	}
+
+	if err := mw(func(_ *handler.Context) error { return nil })(ctx); err != nil {
+		t.Fatalf("expected query token to be accepted: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRevocationList reads the revoked JTIs and sessions the backend writes to Redis on logout.
+type RedisRevocationList struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRevocationList(client *redis.Client, prefix string) *RedisRevocationList {
+	return &RedisRevocationList{client: client, prefix: prefix}
+}
+
+func (l *RedisRevocationList) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if l == nil || l.client == nil || jti == "" {
+		return false, nil
+	}
+	n, err := l.client.Exists(ctx, l.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -19,18 +19,29 @@ type FanoutRequest struct {
 	RoomID  uint32              `json:"room_id"`
 	UserIDs []uint32            `json:"user_ids"`
 	Event   *kafkapb.KafkaEvent `json:"event"`
+	// Direct delivers the event only to the connections of UserIDs instead of the room.
+	Direct bool `json:"direct,omitempty"`
 }
 
+// MembershipRemovedHandler is called after a leave or kicked event removed userID from roomID.
+type MembershipRemovedHandler func(roomID uint32, userID uint32)
+
 type FanoutHTTPSource struct {
 	hub     *gateway.Hub[*kafkapb.KafkaEvent]
 	address string
 	server  *http.Server
+
+	onMembershipRemoved MembershipRemovedHandler
 }
 
 func NewFanoutHTTPHandler(hub *gateway.Hub[*kafkapb.KafkaEvent], address string) *FanoutHTTPSource {
 	return &FanoutHTTPSource{hub: hub, address: address}
 }
 
+func (s *FanoutHTTPSource) SetMembershipRemovedHandler(handler MembershipRemovedHandler) {
+	s.onMembershipRemoved = handler
+}
+
 func (s *FanoutHTTPSource) Start(_ context.Context) error {
 	if s.server != nil {
 		return errors.New("fanout http source already started")
@@ -96,6 +107,8 @@ func (s *FanoutHTTPSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	s.applyMembershipRemoval(req.Event)
+	s.applySessionRevocation(req.Event)
 
 	log.Printf("[fanout-http] room=%d users=%d msg_type=%s", req.Event.RoomId, len(req.UserIDs), req.Event.MsgType)
 	w.WriteHeader(http.StatusNoContent)
@@ -115,6 +128,13 @@ func applyFanout(hub *gateway.Hub[*kafkapb.KafkaEvent], req *FanoutRequest) erro
 		return errors.New("failed to encode event")
 	}
 
+	if req.Direct {
+		if len(req.UserIDs) == 0 {
+			return errors.New("user_ids is required for direct delivery")
+		}
+		hub.SendToUsers(req.UserIDs, payload)
+		return nil
+	}
 	if req.RoomID != 0 {
 		hub.Broadcast(req.RoomID, payload)
 		return nil
@@ -125,3 +145,23 @@ func applyFanout(hub *gateway.Hub[*kafkapb.KafkaEvent], req *FanoutRequest) erro
 	hub.SendToClients(req.UserIDs, payload)
 	return nil
 }
+
+// applyMembershipRemoval drops the user's connections from the room once the event was delivered,
+// so the removed user still receives it.
+func (s *FanoutHTTPSource) applyMembershipRemoval(event *kafkapb.KafkaEvent) {
+	if !s.hub.IsMembershipRemoval(event) || event.UserId == 0 || event.RoomId == 0 {
+		return
+	}
+	s.hub.RemoveUserFromGroup(event.UserId, event.RoomId)
+	if s.onMembershipRemoved != nil {
+		s.onMembershipRemoved(event.RoomId, event.UserId)
+	}
+}
+
+// applySessionRevocation closes the websockets of a revoked session after it was told about it.
+func (s *FanoutHTTPSource) applySessionRevocation(event *kafkapb.KafkaEvent) {
+	if !s.hub.IsSessionRevoked(event) || event.UserId == 0 {
+		return
+	}
+	s.hub.CloseUserSession(event.UserId, s.hub.RevokedSessionID(event))
+}
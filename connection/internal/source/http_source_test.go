@@ -57,6 +57,7 @@ func newTestHub(t *testing.T, eventCodec codec.EventCodec[*kafkapb.KafkaEvent])
 			}
 			return e.RoomId
 		},
+		SessionID: func(e *kafkapb.KafkaEvent) string { return string(e.Content) },
 	}
 	return gateway.NewHub(gateway.NewMemoryStore(), eventCodec, router)
 }
@@ -183,6 +184,133 @@ func TestFanoutHTTPSource_ServeHTTP_SendsToUsers(t *testing.T) {
 	codecMock.AssertNumberOfCalls(t, "Encode", 1)
 }
 
+func TestFanoutHTTPSource_ServeHTTP_DirectSendsToUserConnections(t *testing.T) {
+	// Arrange
+	codecMock := &mockEventCodec{}
+	codecMock.On("Encode", mock.Anything).Return([]byte("encoded"), nil)
+	hub := newTestHub(t, codecMock)
+	laptop := newClient(1)
+	phone := newClient(2)
+	other := newClient(3)
+	for _, c := range []*gateway.Client{laptop, phone, other} {
+		hub.AddClient(c)
+		hub.AddClientToGroup(c.ID, 7)
+	}
+	hub.SetClientUserID(laptop.ID, 42)
+	hub.SetClientUserID(phone.ID, 42)
+	hub.SetClientUserID(other.ID, 43)
+	source := NewFanoutHTTPHandler(hub, ":0")
+
+	event := &kafkapb.KafkaEvent{RoomId: 7, UserId: 42, MsgType: "read"}
+	payload, err := json.Marshal(FanoutRequest{RoomID: 7, UserIDs: []uint32{42}, Event: event, Direct: true})
+	require.NoError(t, err)
+	q := httptest.NewRequest(http.MethodPost, "/fanout", bytes.NewBuffer(payload))
+	recorder := httptest.NewRecorder()
+
+	// Act
+	source.ServeHTTP(recorder, q)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	for _, c := range []*gateway.Client{laptop, phone} {
+		select {
+		case got := <-c.SendChan:
+			assert.Equal(t, []byte("encoded"), got)
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("expected payload to be sent to client %d", c.ID)
+		}
+	}
+	select {
+	case <-other.SendChan:
+		t.Fatal("did not expect payload for another user")
+	default:
+	}
+}
+
+func TestFanoutHTTPSource_ServeHTTP_KickedRemovesUserFromRoom(t *testing.T) {
+	// Arrange
+	codecMock := &mockEventCodec{}
+	codecMock.On("Encode", mock.Anything).Return([]byte("encoded"), nil)
+	hub := newTestHub(t, codecMock)
+	kicked := newClient(1)
+	other := newClient(2)
+	for _, c := range []*gateway.Client{kicked, other} {
+		hub.AddClient(c)
+		hub.AddClientToGroup(c.ID, 7)
+	}
+	hub.SetClientUserID(kicked.ID, 42)
+	hub.SetClientUserID(other.ID, 43)
+	source := NewFanoutHTTPHandler(hub, ":0")
+	var removedRoom, removedUser uint32
+	source.SetMembershipRemovedHandler(func(roomID uint32, userID uint32) {
+		removedRoom, removedUser = roomID, userID
+	})
+
+	event := &kafkapb.KafkaEvent{RoomId: 7, UserId: 42, MsgType: "kicked"}
+	payload, err := json.Marshal(FanoutRequest{RoomID: 7, UserIDs: []uint32{42, 43}, Event: event})
+	require.NoError(t, err)
+	q := httptest.NewRequest(http.MethodPost, "/fanout", bytes.NewBuffer(payload))
+	recorder := httptest.NewRecorder()
+
+	// Act
+	source.ServeHTTP(recorder, q)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	select {
+	case got := <-kicked.SendChan:
+		assert.Equal(t, []byte("encoded"), got)
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("expected the kicked user to receive the event")
+	}
+	assert.Empty(t, hub.GroupsForClient(kicked.ID))
+	assert.Equal(t, []uint32{7}, hub.GroupsForClient(other.ID))
+	assert.Equal(t, uint32(7), removedRoom)
+	assert.Equal(t, uint32(42), removedUser)
+}
+
+func TestFanoutHTTPSource_ServeHTTP_SessionRevokedClosesSessionConnections(t *testing.T) {
+	// Arrange
+	codecMock := &mockEventCodec{}
+	codecMock.On("Encode", mock.Anything).Return([]byte("encoded"), nil)
+	hub := newTestHub(t, codecMock)
+	revoked := newClient(1)
+	revoked.SessionID = "laptop"
+	kept := newClient(2)
+	kept.SessionID = "phone"
+	for _, c := range []*gateway.Client{revoked, kept} {
+		hub.AddClient(c)
+		hub.SetClientUserID(c.ID, 42)
+	}
+	source := NewFanoutHTTPHandler(hub, ":0")
+
+	event := &kafkapb.KafkaEvent{UserId: 42, MsgType: "session_revoked", Content: []byte("laptop")}
+	payload, err := json.Marshal(FanoutRequest{UserIDs: []uint32{42}, Event: event, Direct: true})
+	require.NoError(t, err)
+	q := httptest.NewRequest(http.MethodPost, "/fanout", bytes.NewBuffer(payload))
+	recorder := httptest.NewRecorder()
+
+	// Act
+	source.ServeHTTP(recorder, q)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	got, ok := <-revoked.SendChan
+	assert.True(t, ok)
+	assert.Equal(t, []byte("encoded"), got)
+	_, ok = <-revoked.SendChan
+	assert.False(t, ok, "expected the revoked session's connection to be closed")
+
+	got, ok = <-kept.SendChan
+	assert.True(t, ok)
+	assert.Equal(t, []byte("encoded"), got)
+	select {
+	case _, ok := <-kept.SendChan:
+		assert.True(t, ok, "did not expect the other session's connection to be closed")
+	default:
+	}
+}
+
 func TestApplyFanout_Errors(t *testing.T) {
 	// Arrange
 	codecMock := &mockEventCodec{}
@@ -31,6 +31,8 @@ type KafkaEvent struct {
 	Content       []byte                 `protobuf:"bytes,5,opt,name=content,json=Content,proto3" json:"content,omitempty"`
 	TempId        string                 `protobuf:"bytes,6,opt,name=temp_id,json=TempID,proto3" json:"temp_id,omitempty"`
 	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=CreateAt,proto3" json:"created_at,omitempty"`
+	ParentId      uint64                 `protobuf:"varint,8,opt,name=parent_id,json=ParentID,proto3" json:"parent_id,omitempty"`
+	Attachments   []*AttachmentRef       `protobuf:"bytes,9,rep,name=attachments,json=Attachments,proto3" json:"attachments,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -114,11 +116,118 @@ func (x *KafkaEvent) GetCreatedAt() int64 {
 	return 0
 }
 
+func (x *KafkaEvent) GetParentId() uint64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *KafkaEvent) GetAttachments() []*AttachmentRef {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+// AttachmentRef describes a file attached to a message. url serves its metadata and download links.
+type AttachmentRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,json=ID,proto3" json:"id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,json=Filename,proto3" json:"filename,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=ContentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,json=Size,proto3" json:"size,omitempty"`
+	Url           string                 `protobuf:"bytes,5,opt,name=url,json=URL,proto3" json:"url,omitempty"`
+	Width         uint32                 `protobuf:"varint,6,opt,name=width,json=Width,proto3" json:"width,omitempty"`
+	Height        uint32                 `protobuf:"varint,7,opt,name=height,json=Height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachmentRef) Reset() {
+	*x = AttachmentRef{}
+	mi := &file_proto_kafka_event_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachmentRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentRef) ProtoMessage() {}
+
+func (x *AttachmentRef) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kafka_event_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachmentRef.ProtoReflect.Descriptor instead.
+func (*AttachmentRef) Descriptor() ([]byte, []int) {
+	return file_proto_kafka_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AttachmentRef) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AttachmentRef) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *AttachmentRef) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *AttachmentRef) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AttachmentRef) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *AttachmentRef) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *AttachmentRef) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
 var File_proto_kafka_event_proto protoreflect.FileDescriptor
 
 const file_proto_kafka_event_proto_rawDesc = "" +
 	"\n" +
-	"\x17proto/kafka/event.proto\x12\bkafka.v1\"\xba\x01\n" +
+	"\x17proto/kafka/event.proto\x12\bkafka.v1\"\x92\x02\n" +
 	"\n" +
 	"KafkaEvent\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x04R\x02ID\x12\x17\n" +
@@ -128,7 +237,17 @@ const file_proto_kafka_event_proto_rawDesc = "" +
 	"\acontent\x18\x05 \x01(\fR\aContent\x12\x17\n" +
 	"\atemp_id\x18\x06 \x01(\tR\x06TempID\x12\x1c\n" +
 	"\n" +
-	"created_at\x18\a \x01(\x03R\bCreateAtB/Z-connection/internal/platform/kafka/pb;kafkapbb\x06proto3"
+	"created_at\x18\a \x01(\x03R\bCreateAt\x12\x1b\n" +
+	"\tparent_id\x18\b \x01(\x04R\bParentID\x129\n" +
+	"\vattachments\x18\t \x03(\v2\x17.kafka.v1.AttachmentRefR\vAttachments\"\xb2\x01\n" +
+	"\rAttachmentRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02ID\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bFilename\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vContentType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04Size\x12\x10\n" +
+	"\x03url\x18\x05 \x01(\tR\x03URL\x12\x14\n" +
+	"\x05width\x18\x06 \x01(\rR\x05Width\x12\x16\n" +
+	"\x06height\x18\a \x01(\rR\x06HeightB/Z-connection/internal/platform/kafka/pb;kafkapbb\x06proto3"
 
 var (
 	file_proto_kafka_event_proto_rawDescOnce sync.Once
@@ -142,16 +261,18 @@ func file_proto_kafka_event_proto_rawDescGZIP() []byte {
 	return file_proto_kafka_event_proto_rawDescData
 }
 
-var file_proto_kafka_event_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_kafka_event_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_proto_kafka_event_proto_goTypes = []any{
-	(*KafkaEvent)(nil), // 0: kafka.v1.KafkaEvent
+	(*KafkaEvent)(nil),    // 0: kafka.v1.KafkaEvent
+	(*AttachmentRef)(nil), // 1: kafka.v1.AttachmentRef
 }
 var file_proto_kafka_event_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: kafka.v1.KafkaEvent.attachments:type_name -> kafka.v1.AttachmentRef
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_kafka_event_proto_init() }
@@ -165,7 +286,7 @@ func file_proto_kafka_event_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_kafka_event_proto_rawDesc), len(file_proto_kafka_event_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -39,7 +39,10 @@ func main() {
 	})
 
 	httpClient := &http.Client{Timeout: cfg.Fanout.RequestTimeout}
-	dispatcher := fanout.NewDispatcher(reg, httpClient, fanout.Config{GatewayPath: cfg.Fanout.GatewayPath})
+	dispatcher := fanout.NewDispatcher(reg, httpClient, fanout.Config{
+		GatewayPath: cfg.Fanout.GatewayPath,
+		DirectTypes: cfg.Fanout.DirectTypes,
+	})
 
 	consumer, err := kafka.NewNotificationConsumer(
 		cfg.Kafka.Brokers,
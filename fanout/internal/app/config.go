@@ -26,6 +26,7 @@ type Config struct {
 	Fanout struct {
 		GatewayPath    string        `yaml:"gateway_path"`
 		RequestTimeout time.Duration `yaml:"request_timeout"`
+		DirectTypes    []string      `yaml:"direct_types"`
 	} `yaml:"fanout"`
 }
 
@@ -76,4 +77,7 @@ func (c *Config) withDefaults() {
 	if c.Fanout.RequestTimeout == 0 {
 		c.Fanout.RequestTimeout = 3 * time.Second
 	}
+	if len(c.Fanout.DirectTypes) == 0 {
+		c.Fanout.DirectTypes = []string{"read", "session_revoked", "ephemeral", "mention"}
+	}
 }
@@ -17,6 +17,8 @@ import (
 
 type Config struct {
 	GatewayPath string
+	// DirectTypes lists event types addressed to event.UserId only rather than the whole room.
+	DirectTypes []string
 }
 
 type Dispatcher struct {
@@ -33,6 +35,7 @@ type FanoutRequest struct {
 	RoomID  uint32              `json:"room_id"`
 	UserIDs []uint32            `json:"user_ids"`
 	Event   *kafkapb.KafkaEvent `json:"event"`
+	Direct  bool                `json:"direct,omitempty"`
 }
 
 func (d *Dispatcher) Dispatch(ctx context.Context, event *kafkapb.KafkaEvent) error {
@@ -40,9 +43,19 @@ func (d *Dispatcher) Dispatch(ctx context.Context, event *kafkapb.KafkaEvent) er
 		return nil
 	}
 
-	userIDs, err := d.registry.RoomUsers(ctx, event.RoomId)
-	if err != nil {
-		return err
+	direct := d.isDirect(event)
+	var userIDs []uint32
+	if direct {
+		if event.UserId == 0 {
+			return nil
+		}
+		userIDs = []uint32{event.UserId}
+	} else {
+		roomUsers, err := d.registry.RoomUsers(ctx, event.RoomId)
+		if err != nil {
+			return err
+		}
+		userIDs = roomUsers
 	}
 	if len(userIDs) == 0 {
 		return nil
@@ -67,7 +80,7 @@ func (d *Dispatcher) Dispatch(ctx context.Context, event *kafkapb.KafkaEvent) er
 		if len(ids) == 0 {
 			continue
 		}
-		if err := d.send(ctx, addr, ids, event); err != nil {
+		if err := d.send(ctx, addr, ids, event, direct); err != nil {
 			log.Printf("[fanout] dispatch to %s failed: %v", addr, err)
 			dispatchErr = err
 		}
@@ -76,11 +89,21 @@ func (d *Dispatcher) Dispatch(ctx context.Context, event *kafkapb.KafkaEvent) er
 	return dispatchErr
 }
 
-func (d *Dispatcher) send(ctx context.Context, addr string, userIDs []uint32, event *kafkapb.KafkaEvent) error {
+func (d *Dispatcher) isDirect(event *kafkapb.KafkaEvent) bool {
+	for _, msgType := range d.cfg.DirectTypes {
+		if event.MsgType == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) send(ctx context.Context, addr string, userIDs []uint32, event *kafkapb.KafkaEvent, direct bool) error {
 	payload, err := json.Marshal(FanoutRequest{
 		RoomID:  event.RoomId,
 		UserIDs: userIDs,
 		Event:   event,
+		Direct:  direct,
 	})
 	if err != nil {
 		return fmt.Errorf("marshal fanout request: %w", err)
@@ -4,6 +4,7 @@ import ChatWindow from "./ChatWindow";
 import { useChatrooms } from "../../hooks/useChatrooms";
 import { useMessages } from "../../hooks/useMessages";
 import { useChatSocket } from "../../hooks/useChatSocket";
+import { usePins } from "../../hooks/usePins";
 import { Card } from "../ui/card";
 import { KafkaEvent } from "../../proto/kafka/event";
 
@@ -18,15 +19,21 @@ function toIsoTime(createdAt: string): string {
   return Number.isNaN(date.getTime()) ? new Date().toISOString() : date.toISOString();
 }
 
+// Slash commands are run by the server and never stored; "//" escapes a leading slash.
+function isCommand(text: string): boolean {
+  return text.length > 1 && text[0] === "/" && text[1] !== "/" && text[1] !== " ";
+}
+
 export default function ChatRoom() {
   const storedUser = localStorage.getItem("user");
   const token = localStorage.getItem("jwt") || undefined;
 
   const user = useMemo(() => (storedUser ? JSON.parse(storedUser) : null), [storedUser]);
 
-  const { chatrooms, loading } = useChatrooms(user?.username, token);
+  const { chatrooms, loading, refresh: refreshChatrooms } = useChatrooms(user?.username, token);
   const [room, setRoom] = useState<any>(null);
   const msgStore = useMessages(room?.ID, user?.id, token);
+  const pinStore = usePins(room?.ID, token);
 
   const handleSocketMessage = useCallback(
     (payload: KafkaEvent) => {
@@ -42,9 +49,29 @@ export default function ChatRoom() {
           status: "sent",
           fromself: payload.userId === user?.id,
         });
+      } else if (payload.msgType === "ephemeral" && payload.roomId === room?.ID) {
+        // command replies are only shown to us and are gone after a reload
+        const id = `ephemeral-${Date.now()}`;
+        msgStore.add({
+          ID: id,
+          UserID: 0,
+          RoomID: payload.roomId,
+          Content: new TextDecoder().decode(payload.content),
+          CreatedAt: new Date().toISOString(),
+          TempID: id,
+          status: "sent",
+          fromself: false,
+        });
+      } else if (payload.msgType === "room_updated") {
+        // the event carries the whole room, so the header changes without a reload
+        const updated = JSON.parse(new TextDecoder().decode(payload.content));
+        setRoom((current: any) => (current?.ID === updated.ID ? { ...current, ...updated } : current));
+        refreshChatrooms();
+      } else if (payload.msgType === "pin" && payload.roomId === room?.ID) {
+        pinStore.refresh();
       }
     },
-    [msgStore, user?.id]
+    [msgStore, pinStore, refreshChatrooms, room?.ID, user?.id]
   );
 
   const socket = useChatSocket(user, token, handleSocketMessage);
@@ -69,7 +96,10 @@ export default function ChatRoom() {
       content: new TextEncoder().encode(text),
       tempId: id,
       createdAt: String(Date.now()),
+      parentId: "0",
+      attachments: [],
     });
+    if (isCommand(text)) return;
 
     msgStore.add({
       ID: id,
@@ -96,6 +126,7 @@ export default function ChatRoom() {
         <ChatWindow
           chatroom={room}
           messages={msgStore.messages}
+          pins={pinStore.pins}
           loading={msgStore.loading}
           loadingMore={msgStore.loadingMore}
           hasMore={msgStore.hasMore}
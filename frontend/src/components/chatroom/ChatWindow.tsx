@@ -2,16 +2,20 @@ import { useEffect, useState } from 'react';
 import type { FormEvent } from 'react';
 import MessageList from "./MessageList";
 import MessageComposer from "./MessageComposer";
-import type { ChatMessage } from '../../types/chat';
+import type { ChatMessage, PinnedMessage } from '../../types/chat';
 
 interface ChatroomInfo {
   ID: number;
   Name: string;
+  Topic?: string;
+  Description?: string;
+  AvatarURL?: string;
 }
 
 interface ChatWindowProps {
   chatroom: ChatroomInfo | null;
   messages: ChatMessage[];
+  pins: PinnedMessage[];
   loading: boolean;
   loadingMore: boolean;
   hasMore: boolean;
@@ -19,7 +23,7 @@ interface ChatWindowProps {
   onLoadMore: () => void;
 }
 
-const ChatWindow = ({ chatroom, messages, loading, loadingMore, hasMore, onSendMessage, onLoadMore }: ChatWindowProps) => {
+const ChatWindow = ({ chatroom, messages, pins, loading, loadingMore, hasMore, onSendMessage, onLoadMore }: ChatWindowProps) => {
   const [draft, setDraft] = useState("");
   console.log("messages in ChatWindow:", messages);
 
@@ -45,12 +49,37 @@ const ChatWindow = ({ chatroom, messages, loading, loadingMore, hasMore, onSendM
   return (
     <section className="flex h-full min-h-0 flex-1 flex-col bg-card">
       <header className="flex items-center justify-between border-b border-border/70 bg-background/40 px-6 py-4">
-        <div>
-          <h2 className="text-lg font-semibold">{chatroom.Name}</h2>
-          <p className="text-xs text-muted-foreground">Room ID #{chatroom.ID}</p>
+        <div className="flex min-w-0 items-center gap-3">
+          {chatroom.AvatarURL && (
+            <img src={chatroom.AvatarURL} alt="" className="h-10 w-10 shrink-0 rounded-full object-cover" />
+          )}
+          <div className="min-w-0">
+            <h2 className="text-lg font-semibold">{chatroom.Name}</h2>
+            <p className="truncate text-xs text-muted-foreground">
+              {chatroom.Topic || `Room ID #${chatroom.ID}`}
+            </p>
+            {chatroom.Description && (
+              <p className="mt-1 line-clamp-2 text-xs text-muted-foreground">{chatroom.Description}</p>
+            )}
+          </div>
         </div>
       </header>
 
+      {pins.length > 0 && (
+        <div className="border-b border-border/70 bg-secondary/20 px-6 py-2 text-xs">
+          <span className="font-semibold uppercase tracking-wide text-muted-foreground">
+            Pinned ({pins.length})
+          </span>
+          <ul className="mt-1 max-h-20 space-y-1 overflow-y-auto">
+            {pins.map((pin) => (
+              <li key={pin.id} className="truncate">
+                {pin.message?.Content ?? "Message unavailable"}
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       <MessageList
         messages={messages}
         loading={loading}
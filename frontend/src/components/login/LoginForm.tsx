@@ -13,6 +13,7 @@ interface LoginFormProps {
 interface LoginResponse {
   token: string;
   sessionID: string;
+  refreshToken: string;
   id: string | number;
 }
 
@@ -58,6 +59,7 @@ function LoginForm({ setIsAuth }: LoginFormProps) {
       localStorage.setItem('jwt', token);
       localStorage.setItem('user', JSON.stringify(userInfo));
       localStorage.setItem('sessionID', sessionID);
+      localStorage.setItem('refreshToken', data.refreshToken);
 
       
       setIsAuth(true);
@@ -16,6 +16,7 @@ export function useChatSocket(user?: UserInfo, token?: string, onMessage?: (p: a
 
   useEffect(() => {
     if (!user || !token) return;
+    const accessToken: string = token;
 
     shouldReconnectRef.current = true;
 
@@ -36,7 +37,7 @@ export function useChatSocket(user?: UserInfo, token?: string, onMessage?: (p: a
 
     const connect = () => {
       if (!shouldReconnectRef.current) return;
-      const socket = createSocket();
+      const socket = createSocket(accessToken);
       socketRef.current = socket;
 
       socket.onopen = () => {
@@ -103,6 +104,8 @@ export function useChatSocket(user?: UserInfo, token?: string, onMessage?: (p: a
       tempId: id,
       content: new Uint8Array(0),
       createdAt: String(Date.now()),
+      parentId: "0",
+      attachments: [],
     });
   }
 
@@ -116,6 +119,8 @@ export function useChatSocket(user?: UserInfo, token?: string, onMessage?: (p: a
       tempId: id,
       content: new Uint8Array(0),
       createdAt: String(Date.now()),
+      parentId: "0",
+      attachments: [],
     });
   }
 
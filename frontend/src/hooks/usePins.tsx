@@ -0,0 +1,25 @@
+import { useCallback, useEffect, useState } from "react";
+import { apiFetch } from "../services/api";
+import { PinnedMessage, PinnedMessageResponse } from "../types/chat";
+
+export function usePins(roomID?: number, token?: string) {
+  const [pins, setPins] = useState<PinnedMessage[]>([]);
+  const [error, setError] = useState<string | null>(null);
+  const [refreshKey, setRefreshKey] = useState(0);
+
+  const refresh = useCallback(() => setRefreshKey((k) => k + 1), []);
+
+  // pins of the previous room must not show while the new ones load
+  useEffect(() => setPins([]), [roomID]);
+
+  useEffect(() => {
+    if (!roomID || !token) return;
+
+    setError(null);
+    apiFetch<PinnedMessageResponse>(`/chatrooms/${roomID}/pins`, token)
+      .then((data) => setPins(data.data ?? []))
+      .catch((e) => setError(e.message));
+  }, [roomID, token, refreshKey]);
+
+  return { pins, error, refresh };
+}
@@ -18,10 +18,23 @@ export interface KafkaEvent {
   content: Uint8Array;
   tempId: string;
   createdAt: string;
+  parentId: string;
+  attachments: AttachmentRef[];
+}
+
+/** AttachmentRef describes a file attached to a message. url serves its metadata and download links. */
+export interface AttachmentRef {
+  id: string;
+  filename: string;
+  contentType: string;
+  size: string;
+  url: string;
+  width: number;
+  height: number;
 }
 
 function createBaseKafkaEvent(): KafkaEvent {
-  return { id: "0", userId: 0, roomId: 0, msgType: "", content: new Uint8Array(0), tempId: "", createdAt: "0" };
+  return { id: "0", userId: 0, roomId: 0, msgType: "", content: new Uint8Array(0), tempId: "", createdAt: "0", parentId: "0", attachments: [] };
 }
 
 export const KafkaEvent: MessageFns<KafkaEvent> = {
@@ -47,6 +60,12 @@ export const KafkaEvent: MessageFns<KafkaEvent> = {
     if (message.createdAt !== "0") {
       writer.uint32(56).int64(message.createdAt);
     }
+    if (message.parentId !== "0") {
+      writer.uint32(64).uint64(message.parentId);
+    }
+    for (const v of message.attachments) {
+      AttachmentRef.encode(v!, writer.uint32(74).fork()).join();
+    }
     return writer;
   },
 
@@ -113,6 +132,22 @@ export const KafkaEvent: MessageFns<KafkaEvent> = {
           message.createdAt = reader.int64().toString();
           continue;
         }
+        case 8: {
+          if (tag !== 64) {
+            break;
+          }
+
+          message.parentId = reader.uint64().toString();
+          continue;
+        }
+        case 9: {
+          if (tag !== 74) {
+            break;
+          }
+
+          message.attachments.push(AttachmentRef.decode(reader, reader.uint32()));
+          continue;
+        }
       }
       if ((tag & 7) === 4 || tag === 0) {
         break;
@@ -155,6 +190,16 @@ export const KafkaEvent: MessageFns<KafkaEvent> = {
         : isSet(object.created_at)
         ? globalThis.String(object.created_at)
         : "0",
+      parentId: isSet(object.ParentID)
+        ? globalThis.String(object.ParentID)
+        : isSet(object.parent_id)
+        ? globalThis.String(object.parent_id)
+        : "0",
+      attachments: globalThis.Array.isArray(object?.Attachments)
+        ? object.Attachments.map((e: any) => AttachmentRef.fromJSON(e))
+        : globalThis.Array.isArray(object?.attachments)
+        ? object.attachments.map((e: any) => AttachmentRef.fromJSON(e))
+        : [],
     };
   },
 
@@ -181,6 +226,12 @@ export const KafkaEvent: MessageFns<KafkaEvent> = {
     if (message.createdAt !== "0") {
       obj.CreateAt = message.createdAt;
     }
+    if (message.parentId !== "0") {
+      obj.ParentID = message.parentId;
+    }
+    if (message.attachments?.length) {
+      obj.Attachments = message.attachments.map((e) => AttachmentRef.toJSON(e));
+    }
     return obj;
   },
 
@@ -196,6 +247,176 @@ export const KafkaEvent: MessageFns<KafkaEvent> = {
     message.content = object.content ?? new Uint8Array(0);
     message.tempId = object.tempId ?? "";
     message.createdAt = object.createdAt ?? "0";
+    message.parentId = object.parentId ?? "0";
+    message.attachments = object.attachments?.map((e) => AttachmentRef.fromPartial(e)) || [];
+    return message;
+  },
+};
+
+function createBaseAttachmentRef(): AttachmentRef {
+  return { id: "0", filename: "", contentType: "", size: "0", url: "", width: 0, height: 0 };
+}
+
+export const AttachmentRef: MessageFns<AttachmentRef> = {
+  encode(message: AttachmentRef, writer: BinaryWriter = new BinaryWriter()): BinaryWriter {
+    if (message.id !== "0") {
+      writer.uint32(8).uint64(message.id);
+    }
+    if (message.filename !== "") {
+      writer.uint32(18).string(message.filename);
+    }
+    if (message.contentType !== "") {
+      writer.uint32(26).string(message.contentType);
+    }
+    if (message.size !== "0") {
+      writer.uint32(32).int64(message.size);
+    }
+    if (message.url !== "") {
+      writer.uint32(42).string(message.url);
+    }
+    if (message.width !== 0) {
+      writer.uint32(48).uint32(message.width);
+    }
+    if (message.height !== 0) {
+      writer.uint32(56).uint32(message.height);
+    }
+    return writer;
+  },
+
+  decode(input: BinaryReader | Uint8Array, length?: number): AttachmentRef {
+    const reader = input instanceof BinaryReader ? input : new BinaryReader(input);
+    const end = length === undefined ? reader.len : reader.pos + length;
+    const message = createBaseAttachmentRef();
+    while (reader.pos < end) {
+      const tag = reader.uint32();
+      switch (tag >>> 3) {
+        case 1: {
+          if (tag !== 8) {
+            break;
+          }
+
+          message.id = reader.uint64().toString();
+          continue;
+        }
+        case 2: {
+          if (tag !== 18) {
+            break;
+          }
+
+          message.filename = reader.string();
+          continue;
+        }
+        case 3: {
+          if (tag !== 26) {
+            break;
+          }
+
+          message.contentType = reader.string();
+          continue;
+        }
+        case 4: {
+          if (tag !== 32) {
+            break;
+          }
+
+          message.size = reader.int64().toString();
+          continue;
+        }
+        case 5: {
+          if (tag !== 42) {
+            break;
+          }
+
+          message.url = reader.string();
+          continue;
+        }
+        case 6: {
+          if (tag !== 48) {
+            break;
+          }
+
+          message.width = reader.uint32();
+          continue;
+        }
+        case 7: {
+          if (tag !== 56) {
+            break;
+          }
+
+          message.height = reader.uint32();
+          continue;
+        }
+      }
+      if ((tag & 7) === 4 || tag === 0) {
+        break;
+      }
+      reader.skip(tag & 7);
+    }
+    return message;
+  },
+
+  fromJSON(object: any): AttachmentRef {
+    return {
+      id: isSet(object.ID) ? globalThis.String(object.ID) : isSet(object.id) ? globalThis.String(object.id) : "0",
+      filename: isSet(object.Filename)
+        ? globalThis.String(object.Filename)
+        : isSet(object.filename)
+        ? globalThis.String(object.filename)
+        : "",
+      contentType: isSet(object.ContentType)
+        ? globalThis.String(object.ContentType)
+        : isSet(object.content_type)
+        ? globalThis.String(object.content_type)
+        : "",
+      size: isSet(object.Size) ? globalThis.String(object.Size) : isSet(object.size) ? globalThis.String(object.size) : "0",
+      url: isSet(object.URL) ? globalThis.String(object.URL) : isSet(object.url) ? globalThis.String(object.url) : "",
+      width: isSet(object.Width) ? globalThis.Number(object.Width) : isSet(object.width) ? globalThis.Number(object.width) : 0,
+      height: isSet(object.Height)
+        ? globalThis.Number(object.Height)
+        : isSet(object.height)
+        ? globalThis.Number(object.height)
+        : 0,
+    };
+  },
+
+  toJSON(message: AttachmentRef): unknown {
+    const obj: any = {};
+    if (message.id !== "0") {
+      obj.ID = message.id;
+    }
+    if (message.filename !== "") {
+      obj.Filename = message.filename;
+    }
+    if (message.contentType !== "") {
+      obj.ContentType = message.contentType;
+    }
+    if (message.size !== "0") {
+      obj.Size = message.size;
+    }
+    if (message.url !== "") {
+      obj.URL = message.url;
+    }
+    if (message.width !== 0) {
+      obj.Width = Math.round(message.width);
+    }
+    if (message.height !== 0) {
+      obj.Height = Math.round(message.height);
+    }
+    return obj;
+  },
+
+  create<I extends Exact<DeepPartial<AttachmentRef>, I>>(base?: I): AttachmentRef {
+    return AttachmentRef.fromPartial(base ?? ({} as any));
+  },
+  fromPartial<I extends Exact<DeepPartial<AttachmentRef>, I>>(object: I): AttachmentRef {
+    const message = createBaseAttachmentRef();
+    message.id = object.id ?? "0";
+    message.filename = object.filename ?? "";
+    message.contentType = object.contentType ?? "";
+    message.size = object.size ?? "0";
+    message.url = object.url ?? "";
+    message.width = object.width ?? 0;
+    message.height = object.height ?? 0;
     return message;
   },
 };
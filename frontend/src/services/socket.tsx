@@ -1,7 +1,10 @@
 export const SOCKET_URL = "ws://localhost:8000/ws";
 
-export function createSocket() {
-  const socket = new WebSocket(SOCKET_URL);
+export function createSocket(token: string) {
+  // Browsers cannot set headers on a websocket, so the access token goes in the query string.
+  // The gateway closes the socket once the token's session is revoked.
+  const params = new URLSearchParams({ access_token: token });
+  const socket = new WebSocket(`${SOCKET_URL}?${params}`);
 
   socket.binaryType = "arraybuffer";
 
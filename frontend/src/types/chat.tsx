@@ -1,6 +1,28 @@
 export interface Chatroom {
   ID: number;
   Name: string;
+  Kind?: "public" | "private" | "direct";
+  Topic?: string;
+  Description?: string;
+  AvatarURL?: string;
+}
+
+export interface PinnedMessage {
+  id: number;
+  chat_room_id: number;
+  message_id: number;
+  pinned_by: number;
+  pinned_at: string;
+  message?: {
+    ID: number;
+    UserID: number;
+    Content: string;
+    CreatedAt: string;
+  };
+}
+
+export interface PinnedMessageResponse {
+  data: PinnedMessage[];
 }
 
 export interface ChatMessage {
@@ -3,3 +3,5 @@ from __future__ import annotations
 from pathlib import Path
 
 DATA_PATH = Path(__file__).resolve().parent.parent / "data" / "store.json"
+# Writes are flushed to DATA_PATH at most this often, so bursts of messages share one save.
+SAVE_INTERVAL_SECONDS = 5.0
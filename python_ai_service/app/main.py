@@ -7,12 +7,20 @@ from .schemas import (
     DocumentOut,
     SearchRequest,
     SearchResult,
+    SummarizeRequest,
+    SummaryResponse,
 )
 from .storage import index
+from .summarize import summarize
 
 app = FastAPI(title="AI Search Service", version="0.1.0")
 
 
+@app.on_event("shutdown")
+def flush_index() -> None:
+    index.flush()
+
+
 @app.get("/health")
 def health() -> dict[str, str]:
     return {"status": "ok"}
@@ -52,7 +60,7 @@ def delete_document(doc_id: str) -> dict[str, str]:
 
 @app.post("/search", response_model=list[SearchResult])
 def search(req: SearchRequest) -> list[SearchResult]:
-    return index.search(req.query, req.k)
+    return index.search(req.query, req.k, req.filter)
 
 
 @app.post("/answer", response_model=AnswerResponse)
@@ -73,3 +81,9 @@ def answer(req: AnswerRequest) -> AnswerResponse:
         answer_text += "."
 
     return AnswerResponse(answer=answer_text or "No relevant content found.", sources=results)
+
+
+@app.post("/summarize", response_model=SummaryResponse)
+def summarize_documents(req: SummarizeRequest) -> SummaryResponse:
+    # The documents are summarized as given; nothing is added to the index.
+    return summarize(req.documents, req.max_sentences)
@@ -21,6 +21,8 @@ class DocumentOut(BaseModel):
 class SearchRequest(BaseModel):
     query: str = Field(..., min_length=1)
     k: int = Field(5, ge=1, le=50)
+    # Only documents whose metadata has every key/value pair in filter are returned.
+    filter: Dict[str, Any] = Field(default_factory=dict)
 
 
 class AnswerRequest(BaseModel):
@@ -29,6 +31,11 @@ class AnswerRequest(BaseModel):
     max_sentences: int = Field(3, ge=1, le=8)
 
 
+class SummarizeRequest(BaseModel):
+    documents: List[DocumentIn] = Field(..., min_length=1, max_length=500)
+    max_sentences: int = Field(3, ge=1, le=8)
+
+
 class SearchResult(BaseModel):
     id: str
     score: float
@@ -39,3 +46,9 @@ class SearchResult(BaseModel):
 class AnswerResponse(BaseModel):
     answer: str
     sources: List[SearchResult]
+
+
+class SummaryResponse(BaseModel):
+    summary: str
+    # Ids of the documents the summary sentences were taken from, in document order.
+    sources: List[str]
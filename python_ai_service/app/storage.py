@@ -4,15 +4,15 @@ from __future__ import annotations
 
 from datetime import datetime, timezone
 from json import dumps, loads
-from threading import Lock
-from typing import Dict, List
+from threading import Lock, Timer
+from typing import Any, Dict, List
 from uuid import uuid4
 
 import numpy as np
 from sklearn.feature_extraction.text import TfidfVectorizer
 from sklearn.metrics.pairwise import cosine_similarity
 
-from .config import DATA_PATH
+from .config import DATA_PATH, SAVE_INTERVAL_SECONDS
 from .schemas import DocumentIn, DocumentOut, SearchResult
 
 
@@ -20,21 +20,43 @@ def utc_now() -> str:
     return datetime.now(timezone.utc).isoformat()
 
 
+def _matches(metadata: Dict[str, Any], filter: Dict[str, Any]) -> bool:
+    return all(metadata.get(key) == value for key, value in filter.items())
+
+
 class InMemoryIndex:
+    """Documents with a TF-IDF matrix over them.
+
+    Writes only mark the matrix stale and schedule a save, so indexing a busy
+    chat costs a dict update per message. The matrix is refit by the next
+    search and the file is rewritten once per SAVE_INTERVAL_SECONDS.
+    """
+
     def __init__(self) -> None:
         self._data_path = DATA_PATH
         self._lock = Lock()
         self._docs: Dict[str, DocumentOut] = {}
         self._vectorizer = TfidfVectorizer(stop_words="english")
         self._matrix = np.empty((0, 0))
+        self._stale = False
+        self._save_timer: Timer | None = None
 
     def _rebuild_matrix(self) -> None:
+        self._stale = False
         texts = [doc.text for doc in self._docs.values()]
         if not texts:
             self._matrix = np.empty((0, 0))
             return
         self._matrix = self._vectorizer.fit_transform(texts)
 
+    def _changed(self) -> None:
+        # callers hold self._lock
+        self._stale = True
+        if self._save_timer is None:
+            self._save_timer = Timer(SAVE_INTERVAL_SECONDS, self.flush)
+            self._save_timer.daemon = True
+            self._save_timer.start()
+
     def load(self) -> None:
         if not self._data_path.exists():
             return
@@ -46,7 +68,18 @@ class InMemoryIndex:
     def save(self) -> None:
         self._data_path.parent.mkdir(parents=True, exist_ok=True)
         payload = [doc.model_dump() for doc in self._docs.values()]
-        self._data_path.write_text(dumps(payload, indent=2))
+        tmp_path = self._data_path.with_suffix(".tmp")
+        tmp_path.write_text(dumps(payload, indent=2))
+        tmp_path.replace(self._data_path)
+
+    def flush(self) -> None:
+        """Writes pending changes to disk now. Called by the save timer and on shutdown."""
+        with self._lock:
+            if self._save_timer is None:
+                return
+            self._save_timer.cancel()
+            self._save_timer = None
+            self.save()
 
     def add(self, doc: DocumentIn) -> DocumentOut:
         doc_id = doc.id or str(uuid4())
@@ -58,8 +91,7 @@ class InMemoryIndex:
         )
         with self._lock:
             self._docs[doc_id] = new_doc
-            self._rebuild_matrix()
-            self.save()
+            self._changed()
         return new_doc
 
     def add_many(self, docs: List[DocumentIn]) -> List[DocumentOut]:
@@ -75,8 +107,7 @@ class InMemoryIndex:
                 )
                 self._docs[doc_id] = new_doc
                 added.append(new_doc)
-            self._rebuild_matrix()
-            self.save()
+            self._changed()
         return added
 
     def get(self, doc_id: str) -> DocumentOut:
@@ -91,17 +122,22 @@ class InMemoryIndex:
             if doc_id not in self._docs:
                 raise KeyError(doc_id)
             self._docs.pop(doc_id)
-            self._rebuild_matrix()
-            self.save()
+            self._changed()
 
-    def search(self, query: str, k: int) -> List[SearchResult]:
+    def search(self, query: str, k: int, filter: Dict[str, Any] | None = None) -> List[SearchResult]:
         with self._lock:
             if not self._docs:
                 return []
+            if self._stale:
+                self._rebuild_matrix()
             query_vec = self._vectorizer.transform([query])
             sims = cosine_similarity(query_vec, self._matrix).flatten()
-            order = sims.argsort()[::-1][:k]
             docs = list(self._docs.values())
+            order = [
+                idx
+                for idx in sims.argsort()[::-1]
+                if _matches(docs[int(idx)].metadata, filter or {})
+            ][:k]
 
         results = []
         for idx in order:
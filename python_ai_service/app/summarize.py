@@ -0,0 +1,52 @@
+from __future__ import annotations
+
+import re
+from typing import List, Tuple
+
+import numpy as np
+from sklearn.feature_extraction.text import TfidfVectorizer
+
+from .schemas import DocumentIn, SummaryResponse
+
+_SENTENCE_END = re.compile(r"(?<=[.!?])\s+|\n+")
+
+
+def _sentences(docs: List[DocumentIn]) -> List[Tuple[int, str]]:
+    out: List[Tuple[int, str]] = []
+    for pos, doc in enumerate(docs):
+        for sentence in _SENTENCE_END.split(doc.text):
+            clean = sentence.strip()
+            if clean:
+                out.append((pos, clean))
+    return out
+
+
+def summarize(docs: List[DocumentIn], max_sentences: int) -> SummaryResponse:
+    """Extractive summary: the sentences closest to the TF-IDF centroid, in document order."""
+    sentences = _sentences(docs)
+    if not sentences:
+        return SummaryResponse(summary="", sources=[])
+
+    if len(sentences) <= max_sentences:
+        chosen = list(range(len(sentences)))
+    else:
+        try:
+            matrix = TfidfVectorizer(stop_words="english").fit_transform([text for _, text in sentences])
+        except ValueError:
+            # only stop words; fall back to the most recent sentences
+            chosen = list(range(len(sentences) - max_sentences, len(sentences)))
+        else:
+            centroid = np.asarray(matrix.mean(axis=0))
+            scores = np.asarray(matrix @ centroid.T).flatten()
+            # stable sort so ties go to the earlier sentence
+            chosen = sorted(np.argsort(-scores, kind="stable")[:max_sentences].tolist())
+
+    parts: List[str] = []
+    sources: List[str] = []
+    for idx in chosen:
+        pos, text = sentences[idx]
+        parts.append(text if text[-1] in ".!?" else text + ".")
+        doc_id = docs[pos].id or str(pos)
+        if doc_id not in sources:
+            sources.append(doc_id)
+    return SummaryResponse(summary=" ".join(parts), sources=sources)